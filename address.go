@@ -0,0 +1,90 @@
+package btc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// decodeAddress 解析地址并检查是否属于指定网络
+func decodeAddress(addr string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	trimmed := strings.TrimSpace(addr)
+	if trimmed == "" {
+		return nil, fmt.Errorf("地址不能为空")
+	}
+
+	// BIP173: bech32地址不区分大小写但不能混用，全大写(如二维码中的地址)统一转为小写再解析
+	if isBech32Address(trimmed) {
+		lower := strings.ToLower(trimmed)
+		if trimmed != lower && trimmed != strings.ToUpper(trimmed) {
+			return nil, ErrMixedCaseBech32
+		}
+		trimmed = lower
+	}
+
+	decoded, err := btcutil.DecodeAddress(trimmed, netParams)
+	if err != nil {
+		return nil, fmt.Errorf("解析地址失败: %w", err)
+	}
+
+	if !decoded.IsForNet(netParams) {
+		return nil, fmt.Errorf("地址与当前网络不匹配")
+	}
+
+	return decoded, nil
+}
+
+// isBech32Address 判断地址是否带有已注册网络的bech32前缀(如bc1、tb1、bcrt1)，不区分大小写
+// Base58地址本身大小写混合，只能对bech32地址做大小写检查
+func isBech32Address(addr string) bool {
+	sep := strings.LastIndexByte(addr, '1')
+	if sep < 1 {
+		return false
+	}
+	return chaincfg.IsBech32SegwitPrefix(strings.ToLower(addr[:sep+1]))
+}
+
+// addressTypeOf 根据解析后的地址类型返回对应的AddressType
+func addressTypeOf(addr btcutil.Address) (AddressType, error) {
+	switch addr.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return P2PKH, nil
+	case *btcutil.AddressWitnessPubKeyHash:
+		return P2WPKH, nil
+	case *btcutil.AddressScriptHash:
+		return P2SH, nil
+	case *btcutil.AddressTaproot:
+		return P2TR, nil
+	case *btcutil.AddressWitnessScriptHash:
+		return P2WSH, nil
+	default:
+		return "", fmt.Errorf("不支持的地址类型: %T", addr)
+	}
+}
+
+// ValidateAddress 校验地址格式和所属网络，并返回地址类型
+func ValidateAddress(address string, network Network) (AddressType, error) {
+	netParams, _, err := networkParams(network)
+	if err != nil {
+		return "", err
+	}
+
+	addr, err := decodeAddress(address, netParams)
+	if err != nil {
+		return "", err
+	}
+
+	return addressTypeOf(addr)
+}
+
+// ClassifyAddress 按钱包所在网络解析地址并返回其地址类型
+func (w *BitcoinWallet) ClassifyAddress(address string) (AddressType, error) {
+	addr, err := w.decodeAndValidateAddress(address)
+	if err != nil {
+		return "", err
+	}
+
+	return addressTypeOf(addr)
+}
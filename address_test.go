@@ -0,0 +1,109 @@
+package btc
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateAddress(t *testing.T) {
+	w := newTestWallet(t)
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		addr, err := w.GetAddress(addrType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ValidateAddress(addr, TestNet)
+		if err != nil {
+			t.Fatalf("%s地址%s验证失败: %v", addrType, addr, err)
+		}
+		if got != addrType {
+			t.Fatalf("地址%s的类型为%s，期望%s", addr, got, addrType)
+		}
+	}
+}
+
+func TestValidateAddressRejectsWrongNetwork(t *testing.T) {
+	const mainnetAddr = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+
+	if got, err := ValidateAddress(mainnetAddr, MainNet); err != nil || got != P2WPKH {
+		t.Fatalf("主网地址在主网上应有效: %s, %v", got, err)
+	}
+	if _, err := ValidateAddress(mainnetAddr, TestNet); err == nil {
+		t.Fatal("主网地址在测试网上应被拒绝")
+	}
+	if _, err := ValidateAddress("not-an-address", MainNet); err == nil {
+		t.Fatal("无效地址应被拒绝")
+	}
+}
+
+func TestBech32UppercaseAddress(t *testing.T) {
+	w := newTestWallet(t)
+	for _, addrType := range []AddressType{P2WPKH, P2TR} {
+		addr, _ := w.GetAddress(addrType)
+		upper := strings.ToUpper(addr)
+
+		// 二维码常用全大写bech32以使用字母数字模式，BIP173规定其有效
+		got, err := w.ClassifyAddress(upper)
+		if err != nil {
+			t.Fatalf("全大写地址%s识别失败: %v", upper, err)
+		}
+		if got != addrType {
+			t.Fatalf("全大写地址识别为%s，期望%s", got, addrType)
+		}
+		if _, err := ValidateAddress(upper, TestNet); err != nil {
+			t.Fatalf("全大写地址%s验证失败: %v", upper, err)
+		}
+	}
+}
+
+func TestBech32MixedCaseAddress(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	mixed := strings.ToUpper(addr[:6]) + addr[6:]
+	if _, err := w.ClassifyAddress(mixed); !errors.Is(err, ErrMixedCaseBech32) {
+		t.Fatalf("大小写混合的地址应返回ErrMixedCaseBech32，实际为%v", err)
+	}
+	if _, err := ValidateAddress(mixed, TestNet); !errors.Is(err, ErrMixedCaseBech32) {
+		t.Fatalf("大小写混合的地址应返回ErrMixedCaseBech32，实际为%v", err)
+	}
+
+	// base58地址区分大小写，不受影响
+	p2pkh, _ := w.GetAddress(P2PKH)
+	if _, err := w.ClassifyAddress(p2pkh); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClassifyAddressFamilies(t *testing.T) {
+	w, err := NewWalletFromBytes(bytes.Repeat([]byte{0x01}, 32), true, MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// BIP173、BIP86等文档中的主网示例地址
+	cases := map[string]AddressType{
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2":                             P2PKH,
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy":                             P2SH,
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4":                     P2WPKH,
+		"bc1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3qccfmv3": P2WSH,
+		"bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr": P2TR,
+	}
+	for addr, want := range cases {
+		got, err := w.ClassifyAddress(addr)
+		if err != nil {
+			t.Fatalf("地址%s识别失败: %v", addr, err)
+		}
+		if got != want {
+			t.Fatalf("地址%s识别为%s，期望%s", addr, got, want)
+		}
+	}
+
+	// 按钱包所在网络解析，测试网地址在主网钱包上被拒绝
+	if _, err := w.ClassifyAddress(testAddress(t, "classify")); err == nil {
+		t.Fatal("主网钱包应拒绝测试网地址")
+	}
+}
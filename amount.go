@@ -0,0 +1,61 @@
+package btc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// satsDecimals 1 BTC = 10^8 聪，对应的小数位数
+const satsDecimals = 8
+
+// BTCToSats 将十进制BTC金额字符串(如 "0.0015")精确换算为聪
+// 按十进制逐位解析，不经过float64，避免0.1+0.2之类的舍入误差
+// 只接受数字和可选的小数点，小数位超过8位、负数或超过比特币总量时返回错误
+func BTCToSats(btc string) (int64, error) {
+	s := strings.TrimSpace(btc)
+	if strings.HasPrefix(s, "-") {
+		return 0, fmt.Errorf("金额不能为负数: %s", btc)
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("金额不能为空")
+	}
+	if len(frac) > satsDecimals {
+		return 0, fmt.Errorf("金额小数位超过%d位: %s", satsDecimals, btc)
+	}
+
+	var sats int64
+	for _, c := range whole + frac + strings.Repeat("0", satsDecimals-len(frac)) {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("金额格式错误: %s", btc)
+		}
+		sats = sats*10 + int64(c-'0')
+		if sats > btcutil.MaxSatoshi {
+			return 0, fmt.Errorf("金额超过比特币总量: %s", btc)
+		}
+	}
+
+	return sats, nil
+}
+
+// SatsToBTC 将聪格式化为十进制BTC字符串，去掉小数末尾的0(如 150000000 → "1.5")
+func SatsToBTC(sats int64) string {
+	sign := ""
+	abs := uint64(sats)
+	if sats < 0 {
+		sign = "-"
+		abs = uint64(-sats)
+	}
+
+	whole := strconv.FormatUint(abs/btcutil.SatoshiPerBitcoin, 10)
+	frac := fmt.Sprintf("%0*d", satsDecimals, abs%btcutil.SatoshiPerBitcoin)
+	frac = strings.TrimRight(frac, "0")
+	if frac == "" {
+		return sign + whole
+	}
+	return sign + whole + "." + frac
+}
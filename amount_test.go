@@ -0,0 +1,73 @@
+package btc
+
+import "testing"
+
+func TestBTCToSats(t *testing.T) {
+	tests := map[string]int64{
+		"0":                 0,
+		"0.00000001":        1,
+		"0.29":              29000000, // float64(0.29)*1e8 = 28999999.999999996
+		"20.3":              2030000000,
+		"1.":                100000000,
+		".5":                50000000,
+		"21000000":          2100000000000000,
+		"21000000.00000000": 2100000000000000,
+	}
+	for in, want := range tests {
+		got, err := BTCToSats(in)
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("%s 换算为%d聪，期望%d", in, got, want)
+		}
+	}
+}
+
+func TestBTCToSatsNoFloatDrift(t *testing.T) {
+	a, _ := BTCToSats("0.1")
+	b, _ := BTCToSats("0.2")
+	c, _ := BTCToSats("0.3")
+	if a+b != c {
+		t.Fatalf("0.1+0.2换算为%d聪，0.3换算为%d聪", a+b, c)
+	}
+}
+
+func TestBTCToSatsRejects(t *testing.T) {
+	tests := []string{
+		"", ".", "-1", "+1", "1e3", "0x10", "1,5", "1 1",
+		"0.000000001",          // 超过8位小数
+		"21000000.00000001",    // 超过总量上限
+		"99999999999999999999", // 溢出int64
+	}
+	for _, in := range tests {
+		if _, err := BTCToSats(in); err == nil {
+			t.Errorf("%q 应返回错误", in)
+		}
+	}
+}
+
+func TestSatsToBTC(t *testing.T) {
+	tests := map[int64]string{
+		0:                "0",
+		1:                "0.00000001",
+		100000000:        "1",
+		150000000:        "1.5",
+		1234567891:       "12.34567891",
+		2100000000000000: "21000000",
+		-5000:            "-0.00005",
+	}
+	for sats, want := range tests {
+		got := SatsToBTC(sats)
+		if got != want {
+			t.Errorf("%d聪格式化为%q，期望%q", sats, got, want)
+		}
+		if sats < 0 {
+			continue
+		}
+		if back, err := BTCToSats(got); err != nil || back != sats {
+			t.Errorf("%q 换算回%d聪, %v", got, back, err)
+		}
+	}
+}
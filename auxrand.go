@@ -0,0 +1,95 @@
+package btc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SetAuxRand 设置Schnorr(Taproot)签名使用的BIP340辅助随机数，传入nil时恢复默认
+// 默认按RFC6979从私钥和消息派生随机数，相同交易的签名本身就是确定的；
+// 设置32字节的aux后改用BIP340标准的随机数派生，全零aux可与BIP340测试向量及其他实现逐字节对照。
+// aux在多次签名间复用不会泄露私钥，随机数仍随消息变化
+func (w *BitcoinWallet) SetAuxRand(aux []byte) error {
+	if aux == nil {
+		w.auxRand = nil
+		return nil
+	}
+	if len(aux) != 32 {
+		return fmt.Errorf("辅助随机数必须为32字节，实际为%d字节", len(aux))
+	}
+
+	var auxRand [32]byte
+	copy(auxRand[:], aux)
+	w.auxRand = &auxRand
+	return nil
+}
+
+// schnorrSign 按辅助随机数设置生成BIP340签名
+func (w *BitcoinWallet) schnorrSign(privKey *btcec.PrivateKey, hash []byte) (*schnorr.Signature, error) {
+	if w.auxRand == nil {
+		return schnorr.Sign(privKey, hash)
+	}
+	return schnorr.Sign(privKey, hash, schnorr.CustomNonce(*w.auxRand))
+}
+
+// taprootKeySpendSignature 生成key-path签名，与txscript.RawTxInTaprootSignature相同但遵循辅助随机数设置
+// merkleRoot为空时按无脚本树的输出调整私钥，hashType不是SigHashDefault时在签名后追加一字节
+func (w *BitcoinWallet) taprootKeySpendSignature(
+	tx *wire.MsgTx,
+	sighashes *txscript.TxSigHashes,
+	idx int,
+	value int64,
+	prevScript []byte,
+	merkleRoot []byte,
+	hashType txscript.SigHashType,
+) ([]byte, error) {
+	prevFetcher := txscript.NewCannedPrevOutputFetcher(prevScript, value)
+	sigHash, err := txscript.CalcTaprootSignatureHash(sighashes, hashType, tx, idx, prevFetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	tweaked := txscript.TweakTaprootPrivKey(*w.privateKey, merkleRoot)
+	sig, err := w.schnorrSign(tweaked, sigHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendTaprootHashType(sig.Serialize(), hashType), nil
+}
+
+// tapscriptSignature 生成脚本路径签名，与txscript.RawTxInTapscriptSignature相同但遵循辅助随机数设置
+func (w *BitcoinWallet) tapscriptSignature(
+	tx *wire.MsgTx,
+	sighashes *txscript.TxSigHashes,
+	idx int,
+	value int64,
+	prevScript []byte,
+	tapLeaf txscript.TapLeaf,
+	hashType txscript.SigHashType,
+) ([]byte, error) {
+	prevFetcher := txscript.NewCannedPrevOutputFetcher(prevScript, value)
+	sigHash, err := txscript.CalcTapscriptSignaturehash(sighashes, hashType, tx, idx, prevFetcher, tapLeaf)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := w.schnorrSign(w.privateKey, sigHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendTaprootHashType(sig.Serialize(), hashType), nil
+}
+
+// appendTaprootHashType SigHashDefault的签名为64字节，其他类型在末尾追加签名类型
+func appendTaprootHashType(sig []byte, hashType txscript.SigHashType) []byte {
+	if hashType == txscript.SigHashDefault {
+		return sig
+	}
+	return append(sig, byte(hashType))
+}
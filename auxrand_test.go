@@ -0,0 +1,74 @@
+package btc
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// signTaprootInput 对固定的单输入交易进行P2TR签名，返回Schnorr签名的十六进制
+func signTaprootInput(t *testing.T, w *BitcoinWallet) string {
+	t.Helper()
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+
+	utxos := []UTXO{{TxID: tx.TxIn[0].PreviousOutPoint.Hash.String(), Vout: 0, Value: 5000}}
+	if err := w.SignTransaction(tx, P2TR, utxos); err != nil {
+		t.Fatal(err)
+	}
+	script, _ := w.addressScript(P2TR)
+	verifyTx(t, tx, [][]byte{script}, []int64{5000})
+	return hex.EncodeToString(tx.TxIn[0].Witness[0])
+}
+
+func TestTaprootSignatureDeterministic(t *testing.T) {
+	first := signTaprootInput(t, newTestWallet(t))
+	if second := signTaprootInput(t, newTestWallet(t)); first != second {
+		t.Fatalf("默认设置下相同交易的签名应一致:\n%s\n%s", first, second)
+	}
+
+	w := newTestWallet(t)
+	if err := w.SetAuxRand(make([]byte, 31)); err == nil {
+		t.Fatal("辅助随机数不是32字节时应返回错误")
+	}
+	if err := w.SetAuxRand(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+	withAux := signTaprootInput(t, w)
+	if withAux != signTaprootInput(t, w) {
+		t.Fatal("设置辅助随机数后相同交易的签名应一致")
+	}
+	if withAux == first {
+		t.Fatal("BIP340随机数派生应与默认派生得到不同的签名")
+	}
+
+	w.SetAuxRand(nil)
+	if signTaprootInput(t, w) != first {
+		t.Fatal("传入nil后应恢复默认签名")
+	}
+}
+
+// TestSchnorrSignBIP340Vector 使用BIP340测试向量0(私钥3、全零消息和全零aux)
+func TestSchnorrSignBIP340Vector(t *testing.T) {
+	w := newTestWallet(t)
+	if err := w.SetAuxRand(make([]byte, 32)); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := hex.DecodeString(strings.Repeat("00", 31) + "03")
+	privKey, _ := btcec.PrivKeyFromBytes(key)
+	sig, err := w.schnorrSign(privKey, make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0"
+	if got := strings.ToUpper(hex.EncodeToString(sig.Serialize())); got != want {
+		t.Fatalf("签名为%s，期望%s", got, want)
+	}
+}
@@ -0,0 +1,256 @@
+package btc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Backend 区块链数据后端，负责查询链上数据和广播交易
+type Backend interface {
+	// Balance 获取地址的已确认余额
+	Balance(ctx context.Context, address string) (int64, error)
+	// UTXOs 获取地址的UTXO
+	UTXOs(ctx context.Context, address string) ([]UTXO, error)
+	// TxHex 获取交易的原始十六进制数据
+	TxHex(ctx context.Context, txID string) (string, error)
+	// Broadcast 广播交易并返回交易ID
+	Broadcast(ctx context.Context, txHex string) (string, error)
+	// FeeEstimates 获取推荐费率
+	FeeEstimates(ctx context.Context) (FeeEstimates, error)
+	// TransactionHistory 获取地址的交易历史
+	TransactionHistory(ctx context.Context, address string) ([]TxSummary, error)
+	// TxStatus 获取交易的确认状态，不含确认数
+	TxStatus(ctx context.Context, txID string) (TxStatus, error)
+	// TipHeight 获取当前最新区块高度
+	TipHeight(ctx context.Context) (int64, error)
+}
+
+// httpBackend 基于apiClient实现的后端，钱包的HTTP设置作用于其客户端
+type httpBackend interface {
+	Backend
+	apiClient() *apiClient
+}
+
+// EsploraBackend Blockstream Esplora接口后端
+type EsploraBackend struct {
+	client *apiClient
+}
+
+// NewEsploraBackend 创建Esplora后端，apiURL如 https://blockstream.info/api
+func NewEsploraBackend(apiURL string) *EsploraBackend {
+	return &EsploraBackend{client: newAPIClient(apiURL)}
+}
+
+// apiClient 返回后端使用的HTTP客户端
+func (b *EsploraBackend) apiClient() *apiClient {
+	return b.client
+}
+
+// Balance 获取地址的已确认余额
+func (b *EsploraBackend) Balance(ctx context.Context, address string) (int64, error) {
+	path := fmt.Sprintf("/address/%s", address)
+
+	data, err := b.client.doRequest(ctx, http.MethodGet, path, nil, "请求余额")
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		ChainStats struct {
+			FundedTxoSum int64 `json:"funded_txo_sum"`
+			SpentTxoSum  int64 `json:"spent_txo_sum"`
+		} `json:"chain_stats"`
+	}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return 0, fmt.Errorf("解析余额失败: %w", err)
+	}
+
+	return result.ChainStats.FundedTxoSum - result.ChainStats.SpentTxoSum, nil
+}
+
+// UTXOs 获取地址的UTXO
+func (b *EsploraBackend) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	path := fmt.Sprintf("/address/%s/utxo", address)
+
+	data, err := b.client.doRequest(ctx, http.MethodGet, path, nil, "请求UTXO")
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有200响应且内容为JSON数组时才表示UTXO列表，null或空响应按异常处理，不当作空地址
+	var utxos []UTXO
+	if err := json.Unmarshal(data, &utxos); err != nil {
+		return nil, fmt.Errorf("解析UTXO失败: %w", err)
+	}
+	if utxos == nil {
+		return nil, fmt.Errorf("解析UTXO失败: 响应不是UTXO列表: %q", data)
+	}
+
+	return utxos, nil
+}
+
+// TxHex 获取交易的原始十六进制数据
+func (b *EsploraBackend) TxHex(ctx context.Context, txID string) (string, error) {
+	path := fmt.Sprintf("/tx/%s/hex", txID)
+
+	data, err := b.client.doRequest(ctx, http.MethodGet, path, nil, "请求交易数据")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// Broadcast 向所有API地址广播交易并返回交易ID
+func (b *EsploraBackend) Broadcast(ctx context.Context, txHex string) (string, error) {
+	data, err := b.client.doBroadcast(ctx, "/tx", []byte(txHex), "广播交易")
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// TxStatus 获取交易的确认状态，不含确认数
+func (b *EsploraBackend) TxStatus(ctx context.Context, txID string) (TxStatus, error) {
+	path := fmt.Sprintf("/tx/%s/status", txID)
+
+	data, err := b.client.doRequest(ctx, http.MethodGet, path, nil, "请求交易状态")
+	if err != nil {
+		return TxStatus{}, err
+	}
+
+	var status TxStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return TxStatus{}, fmt.Errorf("解析交易状态失败: %w", err)
+	}
+
+	return status, nil
+}
+
+// TipHeight 获取当前最新区块高度
+func (b *EsploraBackend) TipHeight(ctx context.Context) (int64, error) {
+	data, err := b.client.doRequest(ctx, http.MethodGet, "/blocks/tip/height", nil, "请求区块高度")
+	if err != nil {
+		return 0, err
+	}
+
+	height, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析区块高度失败: %w", err)
+	}
+
+	return height, nil
+}
+
+// FeeEstimates 从/fee-estimates接口获取推荐费率
+func (b *EsploraBackend) FeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	data, err := b.client.doRequest(ctx, http.MethodGet, "/fee-estimates", nil, "请求费率")
+	if err != nil {
+		return nil, err
+	}
+
+	// Esplora返回字符串形式的目标区块数到浮点费率的映射
+	var raw map[string]float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析费率失败: %w", err)
+	}
+
+	estimates := make(FeeEstimates, len(raw))
+	for key, rate := range raw {
+		target, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("解析费率目标失败(%s): %w", key, err)
+		}
+		estimates[target] = rate
+	}
+
+	return estimates, nil
+}
+
+// MempoolSpaceBackend mempool.space接口后端，地址和交易接口与Esplora兼容，费率接口不同
+type MempoolSpaceBackend struct {
+	EsploraBackend
+}
+
+// NewMempoolSpaceBackend 创建mempool.space后端，apiURL如 https://mempool.space/api
+func NewMempoolSpaceBackend(apiURL string) *MempoolSpaceBackend {
+	return &MempoolSpaceBackend{EsploraBackend{client: newAPIClient(apiURL)}}
+}
+
+// mempool.space推荐费率档位对应的确认目标区块数
+const (
+	mempoolFastestTarget  = 1
+	mempoolHalfHourTarget = 3
+	mempoolHourTarget     = 6
+	mempoolEconomyTarget  = 144
+	mempoolMinimumTarget  = 1008
+)
+
+// FeeEstimates 从/v1/fees/recommended接口获取推荐费率并换算为确认目标区块数
+func (b *MempoolSpaceBackend) FeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	data, err := b.client.doRequest(ctx, http.MethodGet, "/v1/fees/recommended", nil, "请求费率")
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		FastestFee  float64 `json:"fastestFee"`
+		HalfHourFee float64 `json:"halfHourFee"`
+		HourFee     float64 `json:"hourFee"`
+		EconomyFee  float64 `json:"economyFee"`
+		MinimumFee  float64 `json:"minimumFee"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析费率失败: %w", err)
+	}
+
+	return FeeEstimates{
+		mempoolFastestTarget:  result.FastestFee,
+		mempoolHalfHourTarget: result.HalfHourFee,
+		mempoolHourTarget:     result.HourFee,
+		mempoolEconomyTarget:  result.EconomyFee,
+		mempoolMinimumTarget:  result.MinimumFee,
+	}, nil
+}
+
+// SetBackend 设置区块链数据后端，传入nil时恢复默认的Esplora后端
+// 切换到内置后端时沿用钱包已设置的HTTP客户端、重试策略、限速和观察者，API地址使用新后端自己的地址，
+// 需要多个地址时在切换后调用SetAPIEndpoints。自定义后端不使用这些设置，但会保留下来，
+// 之后切换回内置后端时继续生效；已设置的观察者会转交给实现了SetObserver的自定义后端
+func (w *BitcoinWallet) SetBackend(b Backend) {
+	if b == nil {
+		b = NewEsploraBackend(w.defaultAPIURL)
+	}
+
+	prev := w.client
+	w.backend = b
+	w.feeCache.reset()
+	w.tipCache.reset()
+	if hb, ok := b.(httpBackend); ok {
+		w.client = hb.apiClient()
+	} else {
+		// 自定义后端不使用HTTP客户端，保留独立实例避免设置方法出现空指针
+		w.client = newAPIClient(w.defaultAPIURL)
+	}
+	if prev != nil {
+		w.client.inheritSettings(prev)
+	}
+
+	// 已设置的观察者转交给自行发送请求的后端
+	if ob, ok := b.(observableBackend); ok && w.client.observer != nil {
+		if _, isHTTP := b.(httpBackend); !isHTTP {
+			ob.SetObserver(w.client.observer)
+		}
+	}
+}
+
+// Backend 返回钱包当前使用的区块链数据后端
+func (w *BitcoinWallet) Backend() Backend {
+	return w.backend
+}
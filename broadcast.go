@@ -0,0 +1,36 @@
+package btc
+
+import (
+	"context"
+)
+
+// BroadcastResult 批量广播中单笔交易的结果
+type BroadcastResult struct {
+	TxID string // 本地计算的交易ID，交易无法解析时为空
+	Err  error  // 广播失败的原因，成功时为nil
+}
+
+// BroadcastBatch 按顺序广播多笔已签名交易，单笔失败不影响其余交易
+func (w *BitcoinWallet) BroadcastBatch(txHexes []string) ([]BroadcastResult, error) {
+	return w.BroadcastBatchContext(context.Background(), txHexes)
+}
+
+// BroadcastBatchContext 按顺序广播多笔交易，结果与txHexes一一对应
+// 配置了请求限流时每笔广播都会经过限流器；ctx被取消时停止广播，未处理的交易记录ctx的错误
+func (w *BitcoinWallet) BroadcastBatchContext(ctx context.Context, txHexes []string) ([]BroadcastResult, error) {
+	results := make([]BroadcastResult, len(txHexes))
+
+	for i, txHex := range txHexes {
+		if err := ctx.Err(); err != nil {
+			for j := i; j < len(results); j++ {
+				results[j].Err = err
+			}
+			return results, err
+		}
+
+		txID, err := w.BroadcastTransactionContext(ctx, txHex)
+		results[i] = BroadcastResult{TxID: txID, Err: err}
+	}
+
+	return results, nil
+}
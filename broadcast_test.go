@@ -0,0 +1,49 @@
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestBroadcastBatchMalformedHex(t *testing.T) {
+	w := newTestWallet(t)
+	var broadcasted []string
+	newTestServer(t, w, "[]", func(txHex string) {
+		broadcasted = append(broadcasted, txHex)
+	})
+
+	var hexes, txIDs []string
+	for i := byte(1); i <= 2; i++ {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{i}, 0), nil, nil))
+		tx.AddTxOut(wire.NewTxOut(10000, []byte{txscript.OP_TRUE}))
+		hexes = append(hexes, serializeTx(t, tx))
+		txIDs = append(txIDs, tx.TxHash().String())
+	}
+	// 中间一笔不是合法的十六进制
+	hexes = []string{hexes[0], "not-a-transaction", hexes[1]}
+
+	results, err := w.BroadcastBatch(hexes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("应返回3个结果，实际为%d个", len(results))
+	}
+	if results[1].Err == nil || results[1].TxID != "" {
+		t.Fatalf("格式错误的交易应在对应位置报告错误，实际为%+v", results[1])
+	}
+	for i, want := range map[int]string{0: txIDs[0], 2: txIDs[1]} {
+		if results[i].Err != nil || results[i].TxID != want {
+			t.Fatalf("第%d笔交易结果为%+v，期望交易ID %s", i, results[i], want)
+		}
+	}
+
+	// 格式错误的交易不会发送给服务端，其余交易按顺序广播
+	if len(broadcasted) != 2 || broadcasted[0] != hexes[0] || broadcasted[1] != hexes[2] {
+		t.Fatalf("服务端收到%d笔交易，期望收到第1和第3笔", len(broadcasted))
+	}
+}
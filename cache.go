@@ -0,0 +1,117 @@
+package btc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ttlCache 带有效期的缓存值，过期后由第一个调用方刷新，并发调用方等待同一次请求的结果
+type ttlCache[T any] struct {
+	mu       sync.Mutex
+	value    T
+	expires  time.Time
+	inflight *cacheCall[T] // 正在进行的刷新请求，为空表示没有
+	gen      uint64        // 每次reset递增，用于丢弃reset之前发起的请求结果
+}
+
+// cacheCall 一次进行中的刷新请求
+type cacheCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// get 缓存未过期时直接返回，否则调用fetch刷新，ttl<=0时不使用缓存
+// 请求失败时不更新缓存，下一次调用会重新请求
+func (c *ttlCache[T]) get(ctx context.Context, ttl time.Duration, fetch func(context.Context) (T, error)) (T, error) {
+	if ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	c.mu.Lock()
+	if time.Now().Before(c.expires) {
+		value := c.value
+		c.mu.Unlock()
+		return value, nil
+	}
+
+	if call := c.inflight; call != nil {
+		c.mu.Unlock()
+		return call.wait(ctx)
+	}
+
+	call := &cacheCall[T]{done: make(chan struct{})}
+	c.inflight = call
+	gen := c.gen
+	c.mu.Unlock()
+
+	// 共享的请求不随发起方的ctx取消，否则发起方取消时其他仍在等待的调用方也会失败，
+	// 每个调用方(包括发起方)只按自己的ctx停止等待
+	go c.refresh(context.WithoutCancel(ctx), call, gen, ttl, fetch)
+	return call.wait(ctx)
+}
+
+// refresh 执行一次共享的刷新请求，成功时写入缓存，完成后通知所有等待的调用方
+func (c *ttlCache[T]) refresh(ctx context.Context, call *cacheCall[T], gen uint64, ttl time.Duration, fetch func(context.Context) (T, error)) {
+	call.value, call.err = fetch(ctx)
+
+	c.mu.Lock()
+	// 请求期间发生过reset时结果可能来自旧后端，只返回给已在等待的调用方，不写入缓存
+	if call.err == nil && c.gen == gen {
+		c.value = call.value
+		c.expires = time.Now().Add(ttl)
+	}
+	if c.inflight == call {
+		c.inflight = nil
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// wait 等待请求完成，ctx取消时提前返回，不影响请求本身和其他调用方
+func (call *cacheCall[T]) wait(ctx context.Context) (T, error) {
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// reset 清空缓存，进行中的请求完成后不再写入结果，之后的调用方也不再等待它
+func (c *ttlCache[T]) reset() {
+	c.mu.Lock()
+	c.expires = time.Time{}
+	c.inflight = nil
+	c.gen++
+	c.mu.Unlock()
+}
+
+// SetCacheTTL 设置推荐费率和最新区块高度的缓存有效期，有效期内重复调用不再请求后端
+// 并发的请求会合并为一次，传入0时关闭缓存(默认)。可以在其他goroutine查询时调用
+func (w *BitcoinWallet) SetCacheTTL(d time.Duration) {
+	w.cacheTTL.Store(int64(d))
+	w.feeCache.reset()
+	w.tipCache.reset()
+}
+
+// cachedTipHeight 获取最新区块高度，启用缓存时优先使用缓存
+func (w *BitcoinWallet) cachedTipHeight(ctx context.Context) (int64, error) {
+	return w.tipCache.get(ctx, time.Duration(w.cacheTTL.Load()), w.backend.TipHeight)
+}
+
+// cachedFeeEstimates 获取推荐费率，启用缓存时优先使用缓存，返回副本避免调用方修改缓存内容
+func (w *BitcoinWallet) cachedFeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	estimates, err := w.feeCache.get(ctx, time.Duration(w.cacheTTL.Load()), w.backend.FeeEstimates)
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make(FeeEstimates, len(estimates))
+	for target, rate := range estimates {
+		copied[target] = rate
+	}
+	return copied, nil
+}
@@ -0,0 +1,162 @@
+package btc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFeeEstimatesCache(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fee-estimates" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		calls.Add(1)
+		<-release
+		rw.Write([]byte(`{"1":25,"6":10}`))
+	}))
+	defer srv.Close()
+
+	w := newTestWallet(t)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	w.SetCacheTTL(time.Minute)
+
+	// 缓存为空时并发的调用方共享同一次请求
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			estimates, err := w.FetchFeeRates()
+			if err == nil && estimates[1] != 25 {
+				t.Errorf("费率为%v，期望目标1为25", estimates)
+			}
+			errs <- err
+		}()
+	}
+	// 等第一个请求到达服务端后再放行，其余调用方此时都在等待它
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("并发获取费率发起了%d次请求，期望1次", n)
+	}
+
+	// 有效期内连续获取直接使用缓存，修改返回值不影响缓存
+	first, err := w.FetchFeeRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first[1] = 999
+	second, err := w.FetchFeeRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 || second[1] != 25 {
+		t.Fatalf("缓存有效期内发起了%d次请求，费率为%v", n, second)
+	}
+
+	// 修改有效期会清空缓存，与查询并发调用不产生数据竞争
+	var setters sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		setters.Add(2)
+		go func() {
+			defer setters.Done()
+			w.SetCacheTTL(time.Minute)
+		}()
+		go func() {
+			defer setters.Done()
+			w.FetchFeeRates()
+		}()
+	}
+	setters.Wait()
+
+	before := calls.Load()
+	w.SetCacheTTL(time.Minute)
+	if _, err := w.FetchFeeRates(); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != before+1 {
+		t.Fatal("修改有效期后应重新请求")
+	}
+}
+
+func TestCacheLeaderCancelDoesNotFailWaiters(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		rw.Write([]byte(`{"1":25,"6":10}`))
+	}))
+	defer srv.Close()
+	unblock := sync.OnceFunc(func() { close(release) })
+	defer unblock()
+
+	w := newTestWallet(t)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	w.SetCacheTTL(time.Minute)
+
+	// 发起方的请求到达服务端后，等待方加入同一次请求
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := w.FetchFeeRatesContext(ctx)
+		leaderErr <- err
+	}()
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	type result struct {
+		estimates FeeEstimates
+		err       error
+	}
+	waiter := make(chan result, 1)
+	go func() {
+		estimates, err := w.FetchFeeRates()
+		waiter <- result{estimates, err}
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// 取消发起方只让它自己返回，共享的请求继续进行
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("取消的发起方应返回context.Canceled，实际为%v", err)
+	}
+	unblock()
+
+	got := <-waiter
+	if got.err != nil {
+		t.Fatalf("发起方取消后等待方失败: %v", got.err)
+	}
+	if got.estimates[1] != 25 {
+		t.Fatalf("费率为%v，期望目标1为25", got.estimates)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("发起了%d次请求，期望1次", n)
+	}
+}
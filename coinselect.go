@@ -0,0 +1,447 @@
+package btc
+
+import (
+	"cmp"
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	mathrand "math/rand"
+	"slices"
+	"sort"
+)
+
+// CoinSelectionStrategy UTXO选择策略
+type CoinSelectionStrategy string
+
+const (
+	SmallestFirst  CoinSelectionStrategy = "smallest_first"   // 从小额UTXO开始累加(默认)
+	LargestFirst   CoinSelectionStrategy = "largest_first"    // 从大额UTXO开始累加，输入数量最少
+	BranchAndBound CoinSelectionStrategy = "branch_and_bound" // 尝试精确匹配目标金额以避免找零，失败时退回LargestFirst
+	RandomImproved CoinSelectionStrategy = "random_improved"  // 随机选择UTXO，并尽量使找零接近转账金额以隐藏支付方向
+)
+
+// bnbMaxTries 分支定界搜索的最大尝试次数
+const bnbMaxTries = 100000
+
+// SetCoinSelection 设置UTXO选择策略
+func (w *BitcoinWallet) SetCoinSelection(strategy CoinSelectionStrategy) {
+	w.coinSelection = strategy
+}
+
+// GetCoinSelection 获取UTXO选择策略
+func (w *BitcoinWallet) GetCoinSelection() CoinSelectionStrategy {
+	if w.coinSelection == "" {
+		return SmallestFirst
+	}
+	return w.coinSelection
+}
+
+// SetCoinSelectionRand 设置RandomImproved策略使用的随机数生成器，用于获得可复现的选择结果
+// 传入nil时恢复默认行为，即每次选择时从crypto/rand获取种子
+func (w *BitcoinWallet) SetCoinSelectionRand(r *mathrand.Rand) {
+	w.coinRand = r
+}
+
+// coinSelectionRand 获取随机选择使用的随机数生成器
+func (w *BitcoinWallet) coinSelectionRand() *mathrand.Rand {
+	if w.coinRand != nil {
+		return w.coinRand
+	}
+
+	var seed [8]byte
+	if _, err := cryptorand.Read(seed[:]); err != nil {
+		// crypto/rand不可用时仍需给出随机顺序，退回默认种子源
+		return mathrand.New(mathrand.NewSource(mathrand.Int63()))
+	}
+	return mathrand.New(mathrand.NewSource(int64(binary.LittleEndian.Uint64(seed[:]))))
+}
+
+// shuffleUTXOs 返回随机打乱顺序的UTXO副本
+func shuffleUTXOs(utxos []UTXO, rng *mathrand.Rand) []UTXO {
+	shuffled := append([]UTXO(nil), utxos...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	return shuffled
+}
+
+// selectRandomImprove 随机累加UTXO直到满足目标金额，再随机尝试追加UTXO使找零接近目标金额
+// 追加的UTXO必须让总额更接近2*amount且不超过3*amount，这样找零看起来与转账金额相当
+// 打乱后的副本写入dst的底层数组，选中的UTXO原地压缩到副本前部后返回
+func selectRandomImprove(dst, utxos []UTXO, amount int64, rng *mathrand.Rand) ([]UTXO, int64, error) {
+	shuffled := append(dst[:0], utxos...)
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	// 写入位置n不会超过读取位置，原地压缩不会覆盖尚未读取的UTXO
+	var total int64
+	n, next := 0, 0
+	for ; next < len(shuffled) && total < amount; next++ {
+		if shuffled[next].Value <= 0 {
+			continue
+		}
+		total += shuffled[next].Value
+		shuffled[n] = shuffled[next]
+		n++
+	}
+
+	if total < amount {
+		return nil, 0, newInsufficientFundsError(amount, total, 0)
+	}
+
+	ideal, upper := 2*amount, 3*amount
+	for ; next < len(shuffled); next++ {
+		utxo := shuffled[next]
+		if utxo.Value <= 0 {
+			continue
+		}
+
+		candidate := total + utxo.Value
+		if candidate > upper || absInt64(ideal-candidate) >= absInt64(ideal-total) {
+			continue
+		}
+		shuffled[n] = utxo
+		n++
+		total = candidate
+	}
+
+	return shuffled[:n], total, nil
+}
+
+// absInt64 返回整数的绝对值
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// SetAddressIsolation 设置选择UTXO时是否进行地址隔离
+// 启用后每笔交易尽量只花费同一地址(UTXO.Address)的UTXO，避免在链上关联不同地址，
+// 只有任何单个地址的余额都不足时才会合并多个地址的UTXO
+func (w *BitcoinWallet) SetAddressIsolation(enabled bool) {
+	w.addressIsolation = enabled
+}
+
+// groupUTXOsByAddress 按所属地址分组，返回余额足够支付amount的分组，余额小的分组在前
+// 优先花费刚好足够的地址，保留大额地址的完整性
+func groupUTXOsByAddress(utxos []UTXO, amount int64) [][]UTXO {
+	var order []string
+	groups := make(map[string][]UTXO)
+	totals := make(map[string]int64)
+	for _, utxo := range utxos {
+		if _, ok := groups[utxo.Address]; !ok {
+			order = append(order, utxo.Address)
+		}
+		groups[utxo.Address] = append(groups[utxo.Address], utxo)
+		if utxo.Value > 0 {
+			totals[utxo.Address] += utxo.Value
+		}
+	}
+
+	candidates := make([]string, 0, len(order))
+	for _, addr := range order {
+		if totals[addr] >= amount {
+			candidates = append(candidates, addr)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return totals[candidates[i]] < totals[candidates[j]]
+	})
+
+	result := make([][]UTXO, len(candidates))
+	for i, addr := range candidates {
+		result[i] = groups[addr]
+	}
+	return result
+}
+
+// selectGreedy 按金额排序后依次累加直到满足目标金额
+// 排序副本写入dst的底层数组，选中的UTXO原地压缩到副本前部后返回
+func selectGreedy(dst, utxos []UTXO, amount int64, largestFirst bool) ([]UTXO, int64, error) {
+	sorted := append(dst[:0], utxos...)
+	// slices.SortStableFunc不像sort.SliceStable那样需要反射，复用dst时排序不分配内存
+	slices.SortStableFunc(sorted, func(a, b UTXO) int {
+		if largestFirst {
+			return cmp.Compare(b.Value, a.Value)
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+
+	var total int64
+	n := 0
+	for _, utxo := range sorted {
+		if utxo.Value <= 0 {
+			continue
+		}
+
+		sorted[n] = utxo
+		n++
+		total += utxo.Value
+
+		if total >= amount {
+			return sorted[:n], total, nil
+		}
+	}
+
+	return nil, 0, newInsufficientFundsError(amount, total, 0)
+}
+
+// selectBranchAndBound 深度优先搜索总额落在[amount, amount+window]内的UTXO组合，结果追加到dst[:0]
+func selectBranchAndBound(dst, utxos []UTXO, amount, window int64) ([]UTXO, int64, bool) {
+	candidates := make([]UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxo.Value > 0 {
+			candidates = append(candidates, utxo)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Value > candidates[j].Value
+	})
+
+	// remaining[i] 为candidates[i:]的金额总和，用于剪枝
+	remaining := make([]int64, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + candidates[i].Value
+	}
+
+	var (
+		tries    int
+		included = make([]bool, len(candidates))
+		best     []bool
+		bestSum  int64
+	)
+
+	var search func(depth int, sum int64)
+	search = func(depth int, sum int64) {
+		if best != nil && bestSum == amount {
+			return
+		}
+
+		tries++
+		if tries > bnbMaxTries {
+			return
+		}
+
+		if sum > amount+window {
+			return
+		}
+
+		if sum >= amount {
+			if best == nil || sum < bestSum {
+				best = append([]bool(nil), included...)
+				bestSum = sum
+			}
+			return
+		}
+
+		if depth >= len(candidates) || sum+remaining[depth] < amount {
+			return
+		}
+
+		included[depth] = true
+		search(depth+1, sum+candidates[depth].Value)
+		included[depth] = false
+		search(depth+1, sum)
+	}
+
+	search(0, 0)
+
+	if best == nil {
+		return nil, 0, false
+	}
+
+	selected := dst[:0]
+	for i, ok := range best {
+		if ok {
+			selected = append(selected, candidates[i])
+		}
+	}
+
+	return selected, bestSum, true
+}
+
+// longTermFeeRateMilli 计算浪费分数时假设的长期费率(10 sat/vB)，与Bitcoin Core的consolidatefeerate默认值一致
+const longTermFeeRateMilli = 10000
+
+// SelectionResult UTXO选择结果的详细信息
+type SelectionResult struct {
+	Selected    []UTXO // 选中的UTXO
+	Total       int64  // 选中UTXO的金额总和
+	Change      int64  // 超出目标金额的部分，尚未扣除手续费
+	WasteMetric int64  // 浪费分数(satoshi)，越低越好，计算方式与Bitcoin Core的选币waste相同
+}
+
+// SelectUTXOsDetailed 与SelectUTXOs相同，额外返回找零和浪费分数，便于比较不同选择策略
+// 浪费分数 = 各输入按当前费率与长期费率(10 sat/vB)花费的成本差 + 找零成本(有找零时)或超出金额(无找零时)，
+// 超出金额不超过dust阈值时视为没有找零，找零按P2WPKH输出计算创建和日后花费的成本
+func (w *BitcoinWallet) SelectUTXOsDetailed(utxos []UTXO, amount int64) (*SelectionResult, error) {
+	selected, total, err := w.SelectUTXOs(utxos, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SelectionResult{
+		Selected:    selected,
+		Total:       total,
+		Change:      total - amount,
+		WasteMetric: w.selectionWaste(selected, total-amount),
+	}, nil
+}
+
+// selectionWaste 计算选中输入在超出目标金额excess时的浪费分数
+func (w *BitcoinWallet) selectionWaste(selected []UTXO, excess int64) int64 {
+	var waste int64
+	for _, addrType := range w.inputTypesFor(selected, P2WPKH) {
+		vsize := inputVSize(addrType)
+		waste += feeAtRate(vsize, w.feeRateMilli) - feeAtRate(vsize, longTermFeeRateMilli)
+	}
+
+	if excess <= dustThreshold {
+		return waste + excess
+	}
+
+	// 找零成本: 现在创建找零输出的手续费 + 以后按长期费率花费它的手续费
+	changeCost := feeAtRate(outputSize(outputScriptSize(P2WPKH)), w.feeRateMilli) +
+		feeAtRate(inputVSize(P2WPKH), longTermFeeRateMilli)
+	return waste + changeCost
+}
+
+// inputVSize 返回花费指定类型输出的输入虚拟大小(vbyte)，见证数据按1/4计入
+func inputVSize(addrType AddressType) int {
+	base, witness := inputSize(addrType)
+	return (base*4 + witness + 3) / 4
+}
+
+// SelectUTXOsForFee 选择UTXO时将每个输入带来的手续费计入目标金额
+// 假设交易包含一个与addrType同类型的转账输出，找零超过找零脚本的dust阈值时额外增加一个找零输出，
+// 多个或其他类型的转账输出请使用SelectUTXOsForOutputs
+// 返回选中的UTXO、总金额和最终手续费
+func (w *BitcoinWallet) SelectUTXOsForFee(
+	utxos []UTXO,
+	amount int64,
+	feeRate int64,
+	addrType AddressType,
+) ([]UTXO, int64, int64, error) {
+	outputSizes := []int{outputSize(outputScriptSize(addrType))}
+	return w.selectUTXOsForFee(utxos, amount, outputSizes, feeRate*1000, addrType)
+}
+
+// SelectUTXOsForOutputs 与SelectUTXOsForFee相同，但按实际的转账输出估算交易大小
+func (w *BitcoinWallet) SelectUTXOsForOutputs(
+	utxos []UTXO,
+	outputs []PaymentOutput,
+	feeRate int64,
+	addrType AddressType,
+) ([]UTXO, int64, int64, error) {
+	resolved, amount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	outputSizes := make([]int, len(resolved))
+	for i, output := range resolved {
+		outputSizes[i] = outputSize(len(output.script))
+	}
+	return w.selectUTXOsForFee(utxos, amount, outputSizes, feeRate*1000, addrType)
+}
+
+// selectUTXOsForFee 过滤冻结和确认数不足的UTXO后按输出大小选择，rateMilli为千分之一sat/vB的费率
+func (w *BitcoinWallet) selectUTXOsForFee(
+	utxos []UTXO,
+	amount int64,
+	outputSizes []int,
+	rateMilli int64,
+	addrType AddressType,
+) ([]UTXO, int64, int64, error) {
+	if len(utxos) == 0 {
+		return nil, 0, 0, ErrNoUTXOs
+	}
+
+	if amount <= 0 {
+		return nil, 0, 0, fmt.Errorf("金额必须大于0")
+	}
+
+	utxos = w.filterFrozen(utxos)
+	if len(utxos) == 0 {
+		return nil, 0, 0, fmt.Errorf("%w: 全部UTXO已被冻结", ErrNoSpendableUTXOs)
+	}
+
+	utxos = w.filterByConfirmations(utxos)
+	if len(utxos) == 0 {
+		return nil, 0, 0, fmt.Errorf("%w: 没有满足最小确认数(%d)的UTXO", ErrNoSpendableUTXOs, w.minConfirmations)
+	}
+
+	if w.addressIsolation {
+		// 优先只使用单个地址的UTXO，所有地址都不够时才跨地址选择
+		for _, group := range groupUTXOsByAddress(utxos, amount) {
+			if selected, total, fee, err := w.selectForFee(group, amount, outputSizes, rateMilli, addrType); err == nil {
+				return selected, total, fee, nil
+			}
+		}
+	}
+
+	return w.selectForFee(utxos, amount, outputSizes, rateMilli, addrType)
+}
+
+// selectForFee 按当前选择策略的顺序累加UTXO，直到覆盖金额和对应的手续费
+// 手续费按所选输入的实际类型、转账输出大小和可能的找零输出估算
+func (w *BitcoinWallet) selectForFee(
+	utxos []UTXO,
+	amount int64,
+	outputSizes []int,
+	rateMilli int64,
+	addrType AddressType,
+) ([]UTXO, int64, int64, error) {
+	var sorted []UTXO
+	if w.coinSelection == RandomImproved {
+		sorted = shuffleUTXOs(utxos, w.coinSelectionRand())
+	} else {
+		largestFirst := w.coinSelection == LargestFirst || w.coinSelection == BranchAndBound
+		sorted = append([]UTXO(nil), utxos...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			if largestFirst {
+				return sorted[i].Value > sorted[j].Value
+			}
+			return sorted[i].Value < sorted[j].Value
+		})
+	}
+
+	changeDust := w.changeDustLimit(addrType)
+	changeScriptLen := outputScriptSize(addrType)
+	if script, err := w.changeScript(addrType); err == nil {
+		changeScriptLen = len(script)
+	}
+	withChange := append(append([]int(nil), outputSizes...), outputSize(changeScriptLen))
+
+	var selected []UTXO
+	var total int64
+	var feeNoChange int64
+
+	for _, utxo := range sorted {
+		if utxo.Value <= 0 {
+			continue
+		}
+
+		selected = append(selected, utxo)
+		total += utxo.Value
+
+		inputTypes := w.inputTypesFor(selected, addrType)
+		feeNoChange = w.feeForVSize(estimateVSize(inputTypes, outputSizes), rateMilli)
+		if total-amount-feeNoChange < 0 {
+			continue
+		}
+
+		feeWithChange := w.feeForVSize(estimateVSize(inputTypes, withChange), rateMilli)
+		if total-amount-feeWithChange > changeDust {
+			return selected, total, feeWithChange, nil
+		}
+
+		// 找零过小，剩余部分全部作为手续费
+		return selected, total, total - amount, nil
+	}
+
+	return nil, 0, 0, newInsufficientFundsError(amount+feeNoChange, total, feeNoChange)
+}
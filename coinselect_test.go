@@ -0,0 +1,169 @@
+package btc
+
+import (
+	"fmt"
+	mathrand "math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestRandomImprovedReproducibleWithSeed(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetCoinSelection(RandomImproved)
+
+	var utxos []UTXO
+	for i := 1; i <= 20; i++ {
+		utxos = append(utxos, UTXO{TxID: fmt.Sprintf("%064x", i), Value: int64(i * 1000)})
+	}
+
+	selectWithSeed := func(seed int64) []UTXO {
+		w.SetCoinSelectionRand(mathrand.New(mathrand.NewSource(seed)))
+		selected, total, err := w.SelectUTXOs(utxos, 15000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total < 15000 {
+			t.Fatalf("选中金额%d不足15000", total)
+		}
+		return selected
+	}
+
+	first, second := selectWithSeed(42), selectWithSeed(42)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("相同种子的选择结果不一致: %v, %v", first, second)
+	}
+
+	w.SetCoinSelectionRand(nil)
+	if _, _, err := w.SelectUTXOs(utxos, 1e9); err == nil {
+		t.Fatal("金额超过总余额时应返回错误")
+	}
+}
+
+func TestCoinSelectionStrategies(t *testing.T) {
+	w := newTestWallet(t)
+	var utxos []UTXO
+	for i, value := range []int64{1000, 2000, 3000, 5000, 50000} {
+		utxos = append(utxos, UTXO{TxID: fmt.Sprintf("%064x", i+1), Value: value})
+	}
+
+	// 目标6000: 从小到大需要3个输入，从大到小1个，分支定界精确匹配5000+1000
+	cases := []struct {
+		strategy CoinSelectionStrategy
+		inputs   int
+		total    int64
+	}{
+		{SmallestFirst, 3, 6000},
+		{LargestFirst, 1, 50000},
+		{BranchAndBound, 2, 6000},
+	}
+	for _, c := range cases {
+		w.SetCoinSelection(c.strategy)
+		selected, total, err := w.SelectUTXOs(utxos, 6000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(selected) != c.inputs || total != c.total {
+			t.Fatalf("%s选择了%d个输入、共%d，期望%d个、共%d", c.strategy, len(selected), total, c.inputs, c.total)
+		}
+	}
+
+	// 没有落在dust窗口内的组合时分支定界退回LargestFirst
+	w.SetCoinSelection(BranchAndBound)
+	selected, total, err := w.SelectUTXOs(utxos, 11500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 1 || total != 50000 {
+		t.Fatalf("无精确匹配时选择了%v，期望退回为单个50000的输入", selected)
+	}
+}
+
+func TestAddressIsolation(t *testing.T) {
+	w := newTestWallet(t)
+	const addrA, addrB = "tb1qaddressa", "tb1qaddressb"
+	utxos := []UTXO{
+		{TxID: fmt.Sprintf("%064x", 1), Value: 10000, Address: addrA},
+		{TxID: fmt.Sprintf("%064x", 2), Value: 9000, Address: addrB},
+		{TxID: fmt.Sprintf("%064x", 3), Value: 8000, Address: addrA},
+		{TxID: fmt.Sprintf("%064x", 4), Value: 15000, Address: addrB},
+	}
+	addresses := func(selected []UTXO) map[string]bool {
+		seen := make(map[string]bool)
+		for _, utxo := range selected {
+			seen[utxo.Address] = true
+		}
+		return seen
+	}
+
+	// 未启用时从小到大选择会合并两个地址的UTXO
+	selected, _, err := w.SelectUTXOs(utxos, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses(selected)) != 2 {
+		t.Fatalf("未启用地址隔离时应跨地址选择，实际选中%v", selected)
+	}
+
+	// 地址A余额18000不足，只使用余额24000的地址B
+	w.SetAddressIsolation(true)
+	selected, total, err := w.SelectUTXOs(utxos, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen := addresses(selected); len(seen) != 1 || !seen[addrB] || total != 24000 {
+		t.Fatalf("启用地址隔离后应只选择地址B的UTXO，实际选中%v", selected)
+	}
+
+	// 计入手续费的选择同样保持在单个地址内
+	selected, _, _, err = w.selectUTXOsForFee(utxos, 20000, []int{outputSize(22)}, 1000, P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen := addresses(selected); len(seen) != 1 || !seen[addrB] {
+		t.Fatalf("计入手续费时应只选择地址B的UTXO，实际选中%v", selected)
+	}
+
+	// 任何单个地址都不够时才跨地址合并
+	selected, total, err = w.SelectUTXOs(utxos, 30000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses(selected)) != 2 || total < 30000 {
+		t.Fatalf("单个地址余额不足时应跨地址选择，实际选中%v", selected)
+	}
+}
+
+func TestSelectionWasteBnBBelowGreedy(t *testing.T) {
+	w := newTestWallet(t)
+	// 费率高于长期费率时每多一个输入都增加浪费
+	w.SetFeeRate(20)
+	var utxos []UTXO
+	for i, value := range []int64{1000, 2000, 3000, 5000, 50000} {
+		utxos = append(utxos, UTXO{TxID: fmt.Sprintf("%064x", i+1), Value: value})
+	}
+
+	// 5000+1000只超出目标100聪，不需要找零
+	const target = 5900
+	results := make(map[CoinSelectionStrategy]*SelectionResult)
+	for _, strategy := range []CoinSelectionStrategy{BranchAndBound, SmallestFirst, LargestFirst} {
+		w.SetCoinSelection(strategy)
+		result, err := w.SelectUTXOsDetailed(utxos, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Change != result.Total-target {
+			t.Fatalf("%s的找零为%d，期望%d", strategy, result.Change, result.Total-target)
+		}
+		results[strategy] = result
+	}
+
+	bnb := results[BranchAndBound]
+	if bnb.Change > dustThreshold {
+		t.Fatalf("分支定界应找到近似精确匹配，实际超出%d", bnb.Change)
+	}
+	for _, greedy := range []CoinSelectionStrategy{SmallestFirst, LargestFirst} {
+		if bnb.WasteMetric >= results[greedy].WasteMetric {
+			t.Fatalf("分支定界的浪费分数%d应低于%s的%d", bnb.WasteMetric, greedy, results[greedy].WasteMetric)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CombineSignedTransactions 合并多方各自签名了部分输入的同一笔交易，返回全部输入均已签名的交易
+// 每个输入取提供了scriptSig或witness的那一份，两份交易对同一输入给出不同的签名数据时返回错误，
+// 合并后仍有输入没有签名时同样返回错误。同一多签输入上的部分签名应使用CombineMultisigSignatures合并
+func CombineSignedTransactions(txHexes []string) (string, error) {
+	if len(txHexes) == 0 {
+		return "", fmt.Errorf("交易列表不能为空")
+	}
+
+	txs := make([]*wire.MsgTx, len(txHexes))
+	for i, txHex := range txHexes {
+		data, err := hex.DecodeString(strings.TrimSpace(txHex))
+		if err != nil {
+			return "", fmt.Errorf("解码交易%d失败: %w", i, err)
+		}
+
+		tx := wire.NewMsgTx(wire.TxVersion)
+		if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+			return "", fmt.Errorf("反序列化交易%d失败: %w", i, err)
+		}
+		txs[i] = tx
+	}
+
+	// 去掉签名数据后各份交易必须完全一致
+	unsignedHash := stripSignatures(txs[0]).TxHash()
+	for i, tx := range txs[1:] {
+		if stripSignatures(tx).TxHash() != unsignedHash {
+			return "", fmt.Errorf("交易%d与交易0不是同一笔交易", i+1)
+		}
+	}
+
+	combined := stripSignatures(txs[0])
+	for idx, txIn := range combined.TxIn {
+		source := -1
+		for i, tx := range txs {
+			signed := tx.TxIn[idx]
+			if len(signed.SignatureScript) == 0 && len(signed.Witness) == 0 {
+				continue
+			}
+
+			if source >= 0 {
+				if !sameInputSignature(txs[source].TxIn[idx], signed) {
+					return "", fmt.Errorf("交易%d和交易%d对输入%d的签名冲突", source, i, idx)
+				}
+				continue
+			}
+
+			source = i
+			txIn.SignatureScript = signed.SignatureScript
+			txIn.Witness = signed.Witness
+		}
+
+		if source < 0 {
+			return "", fmt.Errorf("输入%d没有任何签名", idx)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := combined.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// stripSignatures 返回清空了所有输入scriptSig和witness的交易副本
+func stripSignatures(tx *wire.MsgTx) *wire.MsgTx {
+	stripped := tx.Copy()
+	for _, txIn := range stripped.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+	return stripped
+}
+
+// sameInputSignature 判断两个输入的scriptSig和witness是否完全相同
+func sameInputSignature(a, b *wire.TxIn) bool {
+	if !bytes.Equal(a.SignatureScript, b.SignatureScript) || len(a.Witness) != len(b.Witness) {
+		return false
+	}
+
+	for i := range a.Witness {
+		if !bytes.Equal(a.Witness[i], b.Witness[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// partiallySignedPair 构建两个钱包各持有一个输入的交易，返回各自签名后的交易和未签名交易
+func partiallySignedPair(t *testing.T) (signedA, signedB, unsigned string, scripts [][]byte) {
+	t.Helper()
+	a := newTestWallet(t)
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	wif, _ := btcutil.NewWIF(privKey, &chaincfg.TestNet3Params, true)
+	b, err := NewWallet(wif.String(), TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scriptA, _ := a.addressScript(P2WPKH)
+	scriptB, _ := b.addressScript(P2PKH)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 3), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(15000, scriptA))
+	unsigned = serializeTx(t, tx)
+
+	prevOuts := []PrevOut{{Value: 10000, PkScript: scriptA}, {Value: 8000, PkScript: scriptB}}
+	if signedA, err = a.SignRawTransactionWithPrevouts(unsigned, prevOuts); err != nil {
+		t.Fatal(err)
+	}
+	if signedB, err = b.SignRawTransactionWithPrevouts(unsigned, prevOuts); err != nil {
+		t.Fatal(err)
+	}
+	return signedA, signedB, unsigned, [][]byte{scriptA, scriptB}
+}
+
+func TestCombineSignedTransactions(t *testing.T) {
+	signedA, signedB, unsigned, scripts := partiallySignedPair(t)
+
+	// 未签名的副本和重复的副本不影响合并结果
+	combined, err := CombineSignedTransactions([]string{signedA, signedB, unsigned, signedA})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, deserializeTx(t, combined), scripts, []int64{10000, 8000})
+}
+
+func TestCombineSignedTransactionsRejects(t *testing.T) {
+	signedA, signedB, unsigned, _ := partiallySignedPair(t)
+
+	if _, err := CombineSignedTransactions([]string{signedA}); err == nil {
+		t.Fatal("合并后仍有输入未签名时应返回错误")
+	}
+
+	conflicting := deserializeTx(t, signedA)
+	conflicting.TxIn[0].Witness[0][5] ^= 1
+	if _, err := CombineSignedTransactions([]string{signedA, serializeTx(t, conflicting), signedB}); err == nil {
+		t.Fatal("同一输入的签名不一致时应返回错误")
+	}
+
+	other := deserializeTx(t, unsigned)
+	other.TxOut[0].Value = 1
+	if _, err := CombineSignedTransactions([]string{signedA, serializeTx(t, other)}); err == nil {
+		t.Fatal("合并不同的交易时应返回错误")
+	}
+}
@@ -0,0 +1,210 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TxStatus 交易的确认状态
+type TxStatus struct {
+	Confirmed     bool  `json:"confirmed"`
+	BlockHeight   int64 `json:"block_height"`            // 未确认时为0
+	Confirmations int64 `json:"confirmations,omitempty"` // 确认数，所在区块即为最新区块时为1
+}
+
+// GetTipHeight 获取当前最新区块高度，通过SetCacheTTL启用缓存后有效期内不重复请求
+func (w *BitcoinWallet) GetTipHeight() (int64, error) {
+	return w.GetTipHeightContext(context.Background())
+}
+
+// GetTipHeightContext 获取当前最新区块高度，支持通过ctx取消请求
+func (w *BitcoinWallet) GetTipHeightContext(ctx context.Context) (int64, error) {
+	return w.cachedTipHeight(ctx)
+}
+
+// GetTxStatus 获取交易的确认状态和确认数
+func (w *BitcoinWallet) GetTxStatus(txID string) (TxStatus, error) {
+	return w.GetTxStatusContext(context.Background(), txID)
+}
+
+// GetTxStatusContext 获取交易的确认状态和确认数，支持通过ctx取消请求
+func (w *BitcoinWallet) GetTxStatusContext(ctx context.Context, txID string) (TxStatus, error) {
+	status, err := w.backend.TxStatus(ctx, txID)
+	if err != nil {
+		return TxStatus{}, err
+	}
+
+	if !status.Confirmed {
+		return status, nil
+	}
+
+	tip, err := w.cachedTipHeight(ctx)
+	if err != nil {
+		return TxStatus{}, err
+	}
+	status.Confirmations = confirmationsAt(status.BlockHeight, tip)
+
+	return status, nil
+}
+
+// confirmationsAt 计算区块在指定最新高度下的确认数
+func confirmationsAt(blockHeight, tipHeight int64) int64 {
+	if tipHeight < blockHeight {
+		return 1
+	}
+	return tipHeight - blockHeight + 1
+}
+
+// SetMinConfirmations 设置选择UTXO时要求的最小确认数，0表示允许花费未确认的UTXO
+func (w *BitcoinWallet) SetMinConfirmations(n int) {
+	if n < 0 {
+		n = 0
+	}
+	w.minConfirmations = n
+}
+
+// GetMinConfirmations 获取选择UTXO时要求的最小确认数
+func (w *BitcoinWallet) GetMinConfirmations() int {
+	return w.minConfirmations
+}
+
+// fillConfirmations 按最新区块高度填充已确认UTXO的确认数
+func (w *BitcoinWallet) fillConfirmations(ctx context.Context, utxos []UTXO) error {
+	var tip int64
+	for i := range utxos {
+		if !utxos[i].Status.Confirmed {
+			continue
+		}
+
+		if tip == 0 {
+			height, err := w.cachedTipHeight(ctx)
+			if err != nil {
+				return err
+			}
+			tip = height
+		}
+		utxos[i].Status.Confirmations = confirmationsAt(utxos[i].Status.BlockHeight, tip)
+	}
+	return nil
+}
+
+// utxoConfirmations 获取UTXO的确认数，已确认但未填充确认数时按1处理
+func utxoConfirmations(utxo UTXO) int64 {
+	if !utxo.Status.Confirmed {
+		return 0
+	}
+	if utxo.Status.Confirmations > 0 {
+		return utxo.Status.Confirmations
+	}
+	return 1
+}
+
+// SetAllowUnconfirmedChange 设置最小确认数限制是否放行本钱包创建的未确认交易中的输出(通常是找零)
+// 这类输出由钱包自己创建，不会被第三方替换，连续转账时无需等待找零确认；他人转入的未确认输出仍被排除。
+// 当前钱包实例广播成功的交易直接视为本钱包创建，其他未确认交易在转账前查询其输入，
+// 所有输入都花费本钱包地址的输出时才视为本钱包创建
+func (w *BitcoinWallet) SetAllowUnconfirmedChange(allow bool) {
+	w.allowUnconfirmedOwn = allow
+}
+
+// recordOwnTx 记录本钱包广播成功的交易ID
+func (w *BitcoinWallet) recordOwnTx(txID string) {
+	w.ownTxMu.Lock()
+	defer w.ownTxMu.Unlock()
+
+	if w.ownTxIDs == nil {
+		w.ownTxIDs = make(map[string]struct{})
+	}
+	w.ownTxIDs[strings.ToLower(txID)] = struct{}{}
+}
+
+// isOwnUnconfirmed 判断UTXO是否来自本钱包广播的未确认交易
+func (w *BitcoinWallet) isOwnUnconfirmed(utxo UTXO) bool {
+	if utxo.Status.Confirmed {
+		return false
+	}
+
+	w.ownTxMu.Lock()
+	defer w.ownTxMu.Unlock()
+	_, ok := w.ownTxIDs[strings.ToLower(utxo.TxID)]
+	return ok
+}
+
+// trustOwnUnconfirmed 检查未确认UTXO所在的交易，所有输入都花费本钱包地址输出的交易视为本钱包创建并记录
+// 用于识别其他设备或进程重启前广播的找零，查询失败的交易按第三方交易处理
+func (w *BitcoinWallet) trustOwnUnconfirmed(ctx context.Context, utxos []UTXO) error {
+	checked := make(map[string]struct{})
+	for _, utxo := range utxos {
+		if utxo.Status.Confirmed || w.isOwnUnconfirmed(utxo) {
+			continue
+		}
+
+		txID := strings.ToLower(utxo.TxID)
+		if _, ok := checked[txID]; ok {
+			continue
+		}
+		checked[txID] = struct{}{}
+
+		own, err := w.spendsOnlyOwnOutputs(ctx, txID)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			continue
+		}
+		if own {
+			w.recordOwnTx(txID)
+		}
+	}
+	return nil
+}
+
+// spendsOnlyOwnOutputs 判断交易的所有输入是否都花费本钱包地址的输出
+func (w *BitcoinWallet) spendsOnlyOwnOutputs(ctx context.Context, txID string) (bool, error) {
+	tx, err := w.fetchTransaction(ctx, txID)
+	if err != nil {
+		return false, err
+	}
+
+	prevTxs := make(map[string]*wire.MsgTx)
+	for _, txIn := range tx.TxIn {
+		prev := txIn.PreviousOutPoint
+		prevID := prev.Hash.String()
+
+		prevTx, ok := prevTxs[prevID]
+		if !ok {
+			prevTx, err = w.fetchTransaction(ctx, prevID)
+			if err != nil {
+				return false, err
+			}
+			prevTxs[prevID] = prevTx
+		}
+
+		if int(prev.Index) >= len(prevTx.TxOut) {
+			return false, fmt.Errorf("交易%s没有输出%d", prevID, prev.Index)
+		}
+		if _, ok := w.ownScriptType(prevTx.TxOut[prev.Index].PkScript); !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// filterByConfirmations 过滤掉确认数不足的UTXO，开启SetAllowUnconfirmedChange时保留本钱包的未确认找零
+func (w *BitcoinWallet) filterByConfirmations(utxos []UTXO) []UTXO {
+	if w.minConfirmations <= 0 {
+		return utxos
+	}
+
+	filtered := make([]UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxoConfirmations(utxo) >= int64(w.minConfirmations) ||
+			(w.allowUnconfirmedOwn && w.isOwnUnconfirmed(utxo)) {
+			filtered = append(filtered, utxo)
+		}
+	}
+	return filtered
+}
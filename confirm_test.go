@@ -0,0 +1,231 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// newConfirmTestServer 启动模拟的Esplora服务，最新区块高度为100，txs按txid提供交易原文
+func newConfirmTestServer(t *testing.T, w *BitcoinWallet, utxosJSON string, txs map[string]*wire.MsgTx, onBroadcast func(txHex string)) {
+	t.Helper()
+	// 处理函数运行在服务端goroutine中，不能调用t.Fatal，交易原文在启动服务前序列化
+	txHexes := make(map[string]string, len(txs))
+	for txID, tx := range txs {
+		txHexes[txID] = serializeTx(t, tx)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/utxo"):
+			rw.Write([]byte(utxosJSON))
+		case r.URL.Path == "/blocks/tip/height":
+			rw.Write([]byte("100"))
+		case len(parts) == 3 && parts[0] == "tx" && parts[2] == "hex" && txHexes[parts[1]] != "":
+			rw.Write([]byte(txHexes[parts[1]]))
+		case r.Method == http.MethodPost && r.URL.Path == "/tx":
+			body, _ := io.ReadAll(r.Body)
+			data, _ := hex.DecodeString(string(body))
+			tx := wire.NewMsgTx(wire.TxVersion)
+			if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+				t.Errorf("解析广播的交易失败: %v", err)
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if onBroadcast != nil {
+				onBroadcast(string(body))
+			}
+			rw.Write([]byte(tx.TxHash().String()))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 一个0确认的50000聪UTXO，两个6确认的UTXO
+var mixedConfirmationUTXOs = `[` +
+	`{"txid":"` + strings.Repeat("3", 64) + `","vout":0,"value":50000,"status":{"confirmed":false}},` +
+	`{"txid":"` + strings.Repeat("1", 64) + `","vout":0,"value":30000,"status":{"confirmed":true,"block_height":95}},` +
+	`{"txid":"` + strings.Repeat("2", 64) + `","vout":1,"value":20000,"status":{"confirmed":true,"block_height":95}}]`
+
+func spendsTxID(tx *wire.MsgTx, txID string) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.PreviousOutPoint.Hash.String() == txID {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMinConfirmationsSkipsUnconfirmed(t *testing.T) {
+	w := newTestWallet(t)
+	var sent []string
+	newConfirmTestServer(t, w, mixedConfirmationUTXOs, nil, func(txHex string) { sent = append(sent, txHex) })
+
+	// LargestFirst在不过滤时会优先选择0确认的50000聪UTXO
+	w.SetCoinSelection(LargestFirst)
+	w.SetMinConfirmations(1)
+	outputs := []PaymentOutput{{Address: testAddress(t, "minconf"), Amount: 25000}}
+	if _, err := w.SendMany(P2WPKH, outputs); err != nil {
+		t.Fatal(err)
+	}
+	if spendsTxID(deserializeTx(t, sent[0]), strings.Repeat("3", 64)) {
+		t.Fatal("最小确认数为1时不应选择未确认的UTXO")
+	}
+
+	if _, err := w.SendAll(P2WPKH, testAddress(t, "minconf")); err != nil {
+		t.Fatal(err)
+	}
+	tx := deserializeTx(t, sent[1])
+	if len(tx.TxIn) != 2 || spendsTxID(tx, strings.Repeat("3", 64)) {
+		t.Fatalf("SendAll应只花费2个已确认的UTXO，实际花费%d个", len(tx.TxIn))
+	}
+
+	w.SetMinConfirmations(7)
+	if _, err := w.SendMany(P2WPKH, outputs); !errors.Is(err, ErrNoSpendableUTXOs) {
+		t.Fatalf("确认数都不足7时应返回ErrNoSpendableUTXOs，实际为%v", err)
+	}
+}
+
+func TestAllowUnconfirmedChange(t *testing.T) {
+	w := newTestWallet(t)
+	var sent string
+	newConfirmTestServer(t, w, mixedConfirmationUTXOs, nil, func(txHex string) { sent = txHex })
+
+	w.SetCoinSelection(LargestFirst)
+	w.SetMinConfirmations(1)
+	w.SetAllowUnconfirmedChange(true)
+	w.recordOwnTx(strings.Repeat("3", 64))
+
+	if _, err := w.SendMany(P2WPKH, []PaymentOutput{{Address: testAddress(t, "change"), Amount: 25000}}); err != nil {
+		t.Fatal(err)
+	}
+	if !spendsTxID(deserializeTx(t, sent), strings.Repeat("3", 64)) {
+		t.Fatal("本钱包广播的未确认找零应可以花费")
+	}
+}
+
+func TestTrustOwnUnconfirmedParents(t *testing.T) {
+	w := newTestWallet(t)
+	ownScript, _ := w.addressScript(P2WPKH)
+	otherScript := append([]byte{0x00, 0x14}, make([]byte, 20)...)
+
+	newTx := func(prev chainhash.Hash, script []byte, value int64) *wire.MsgTx {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prev, 0), nil, nil))
+		tx.AddTxOut(wire.NewTxOut(value, script))
+		return tx
+	}
+	funding := newTx(chainhash.Hash{1}, ownScript, 100000)
+	foreign := newTx(chainhash.Hash{2}, otherScript, 100000)
+	selfSpend := newTx(funding.TxHash(), ownScript, 90000) // 花费本钱包输出，找零回到本钱包
+	deposit := newTx(foreign.TxHash(), ownScript, 95000)   // 第三方转入
+
+	txs := make(map[string]*wire.MsgTx)
+	for _, tx := range []*wire.MsgTx{funding, foreign, selfSpend, deposit} {
+		txs[tx.TxHash().String()] = tx
+	}
+	utxos := fmt.Sprintf(`[{"txid":"%s","vout":0,"value":90000,"status":{"confirmed":false}},`+
+		`{"txid":"%s","vout":0,"value":95000,"status":{"confirmed":false}},`+
+		`{"txid":"%s","vout":0,"value":1000,"status":{"confirmed":true,"block_height":95}}]`,
+		selfSpend.TxHash(), deposit.TxHash(), strings.Repeat("1", 64))
+	newConfirmTestServer(t, w, utxos, txs, nil)
+
+	w.SetMinConfirmations(1)
+	addr, _ := w.GetAddress(P2WPKH)
+	ctx := context.Background()
+
+	spendable, err := w.spendableUTXOs(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(w.filterByConfirmations(spendable)); n != 1 {
+		t.Fatalf("未开启SetAllowUnconfirmedChange时应只保留1个已确认UTXO，实际%d个", n)
+	}
+
+	w.SetAllowUnconfirmedChange(true)
+	spendable, err = w.spendableUTXOs(ctx, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filtered := w.filterByConfirmations(spendable)
+	if len(filtered) != 2 || filtered[0].TxID != selfSpend.TxHash().String() {
+		t.Fatalf("应保留本钱包的0确认找零并排除第三方转入: %+v", filtered)
+	}
+}
+
+func TestConfirmationCounting(t *testing.T) {
+	w := newTestWallet(t)
+	confirmedID, pendingID := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blocks/tip/height":
+			rw.Write([]byte("100"))
+		case "/tx/" + confirmedID + "/status":
+			rw.Write([]byte(`{"confirmed":true,"block_height":95}`))
+		case "/tx/" + pendingID + "/status":
+			rw.Write([]byte(`{"confirmed":false}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 高度95的区块在最新高度100时有6个确认
+	status, err := w.GetTxStatus(confirmedID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Confirmed || status.Confirmations != 6 {
+		t.Fatalf("确认状态为%+v，期望6个确认", status)
+	}
+	if status, err = w.GetTxStatus(pendingID); err != nil || status.Confirmed || status.Confirmations != 0 {
+		t.Fatalf("未确认交易的状态为%+v, %v，期望0个确认", status, err)
+	}
+
+	utxos := []UTXO{
+		{TxID: pendingID, Vout: 0, Value: 50000},
+		{TxID: confirmedID, Vout: 0, Value: 30000, Status: TxStatus{Confirmed: true, BlockHeight: 95}},
+	}
+	if err := w.fillConfirmations(context.Background(), utxos); err != nil {
+		t.Fatal(err)
+	}
+	if utxoConfirmations(utxos[0]) != 0 || utxoConfirmations(utxos[1]) != 6 {
+		t.Fatalf("UTXO确认数为%d和%d，期望0和6", utxoConfirmations(utxos[0]), utxoConfirmations(utxos[1]))
+	}
+
+	// 最小确认数为1时LargestFirst也不能选择0确认的50000聪UTXO
+	w.SetCoinSelection(LargestFirst)
+	w.SetMinConfirmations(1)
+	selected, _, err := w.SelectUTXOs(utxos, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 1 || selected[0].TxID != confirmedID {
+		t.Fatalf("最小确认数为1时选择了%v，期望只选择已确认的UTXO", selected)
+	}
+
+	w.SetMinConfirmations(0)
+	if selected, _, _ = w.SelectUTXOs(utxos, 10000); selected[0].TxID != pendingID {
+		t.Fatal("最小确认数为0时应允许选择未确认的UTXO")
+	}
+}
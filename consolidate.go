@@ -0,0 +1,104 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// ConsolidateUTXOs 将最多maxInputs个最小的UTXO合并为一个转回自身地址的输出，返回交易ID
+func (w *BitcoinWallet) ConsolidateUTXOs(fromAddrType AddressType, maxInputs int) (string, error) {
+	return w.ConsolidateUTXOsContext(context.Background(), fromAddrType, maxInputs)
+}
+
+// ConsolidateUTXOsContext 合并UTXO，支持通过ctx取消网络请求
+func (w *BitcoinWallet) ConsolidateUTXOsContext(ctx context.Context, fromAddrType AddressType, maxInputs int) (string, error) {
+	if maxInputs < 2 {
+		return "", fmt.Errorf("合并的输入数量至少为2")
+	}
+
+	fromAddr, err := w.GetAddress(fromAddrType)
+	if err != nil {
+		return "", fmt.Errorf("获取发送方地址失败: %w", err)
+	}
+
+	utxos, err := w.spendableUTXOs(ctx, fromAddr)
+	if err != nil {
+		return "", fmt.Errorf("获取UTXO失败: %w", err)
+	}
+
+	if err = w.checkFromAddrType(ctx, fromAddrType, utxos); err != nil {
+		return "", err
+	}
+
+	selected := w.filterByConfirmations(w.filterFrozen(utxos))
+	if len(selected) < 2 {
+		return "", fmt.Errorf("可用的UTXO少于2个，无需合并")
+	}
+
+	// 优先合并最小的UTXO
+	selected = append([]UTXO(nil), selected...)
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].Value < selected[j].Value
+	})
+	if len(selected) > maxInputs {
+		selected = selected[:maxInputs]
+	}
+
+	var total int64
+	for _, utxo := range selected {
+		total += utxo.Value
+	}
+
+	fee := w.estimateFee(len(selected), 1, fromAddrType)
+	if fee >= total {
+		return "", fmt.Errorf("手续费(%d)超过合并金额(%d)", fee, total)
+	}
+
+	addr, err := w.decodeAndValidateAddress(fromAddr)
+	if err != nil {
+		return "", err
+	}
+
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return "", fmt.Errorf("创建输出脚本失败: %w", err)
+	}
+
+	amount := total - fee
+	if limit := dustLimit(script); amount < limit {
+		return "", fmt.Errorf("合并后金额(%d)低于dust阈值(%d)", amount, limit)
+	}
+
+	output := resolvedOutput{address: addr, script: script, amount: amount}
+	built, err := w.buildTransaction(fromAddrType, selected, []resolvedOutput{output}, 0)
+	if err != nil {
+		return "", fmt.Errorf("创建交易失败: %w", err)
+	}
+	tx := built.Tx
+
+	if err = w.SignTransactionContext(ctx, tx, fromAddrType, selected); err != nil {
+		return "", fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	if err = w.checkMinRelayFee(tx, fee); err != nil {
+		return "", err
+	}
+
+	if w.verifyBeforeBroadcast {
+		if err = w.VerifyTransaction(tx, selected, fromAddrType); err != nil {
+			return "", fmt.Errorf("验证交易失败: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return w.BroadcastTransactionContext(ctx, hex.EncodeToString(buf.Bytes()))
+}
@@ -0,0 +1,48 @@
+package btc
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestConsolidateUTXOs(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	script, _ := w.addressScript(P2WPKH)
+
+	values := []int64{40000, 3000, 1000, 2000}
+	var entries []string
+	for i, value := range values {
+		entries = append(entries, fmt.Sprintf(`{"txid":"%s","vout":0,"value":%d}`, strings.Repeat(fmt.Sprint(i+1), 64), value))
+	}
+	var sent []string
+	newTestServer(t, w, "["+strings.Join(entries, ",")+"]", func(txHex string) { sent = append(sent, txHex) })
+
+	// maxInputs为3时只合并最小的3个UTXO
+	if _, err := w.ConsolidateUTXOs(P2WPKH, 3); err != nil {
+		t.Fatal(err)
+	}
+	tx := deserializeTx(t, sent[0])
+	if len(tx.TxIn) != 3 || spendsTxID(tx, strings.Repeat("1", 64)) {
+		t.Fatalf("应合并3个最小的UTXO，实际有%d个输入", len(tx.TxIn))
+	}
+	inputValues := map[string]int64{
+		strings.Repeat("2", 64): 3000, strings.Repeat("3", 64): 1000, strings.Repeat("4", 64): 2000,
+	}
+	prevValues := make([]int64, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		prevValues[i] = inputValues[txIn.PreviousOutPoint.Hash.String()]
+	}
+	verifyTx(t, tx, [][]byte{script, script, script}, prevValues)
+
+	fee := w.estimateFee(3, 1, P2WPKH)
+	if len(tx.TxOut) != 1 || tx.TxOut[0].Value != 6000-fee || !bytes.Equal(tx.TxOut[0].PkScript, script) {
+		t.Fatalf("合并输出应为转回自身的%d聪，实际为%v", 6000-fee, tx.TxOut)
+	}
+
+	if _, err := w.ConsolidateUTXOs(P2WPKH, 1); err == nil {
+		t.Fatal("maxInputs小于2时应返回错误")
+	}
+}
@@ -0,0 +1,304 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+// coreRPCFeeTargets 通过estimatesmartfee查询的确认目标区块数，与mempool.space的费率档位一致
+var coreRPCFeeTargets = []int{
+	mempoolFastestTarget,
+	mempoolHalfHourTarget,
+	mempoolHourTarget,
+	mempoolEconomyTarget,
+	mempoolMinimumTarget,
+}
+
+// CoreRPCBackend 直接通过JSON-RPC连接自建Bitcoin Core节点的后端，不依赖公共区块浏览器
+// 地址余额和UTXO通过scantxoutset扫描UTXO集合获得，只包含已确认的输出，节点无需导入地址
+// 查询任意交易需要节点开启txindex，钱包的HTTP客户端、重试策略和API地址设置不作用于此后端
+type CoreRPCBackend struct {
+	httpClient *http.Client
+	url        string
+	user       string
+	password   string
+	nextID     atomic.Int64 // 请求ID计数器
+	observer   Observer     // 请求观察者，为nil时不回调
+}
+
+// NewCoreRPCBackend 创建Bitcoin Core RPC后端，host和port为节点的RPC地址，user和password为rpcuser/rpcpassword
+// 使用cookie认证时user为__cookie__，password为.cookie文件中冒号后的内容
+func NewCoreRPCBackend(host string, port int, user, password string) *CoreRPCBackend {
+	return &CoreRPCBackend{
+		httpClient: newDefaultHTTPClient(),
+		url:        "http://" + net.JoinHostPort(host, strconv.Itoa(port)),
+		user:       user,
+		password:   password,
+	}
+}
+
+// SetHTTPClient 设置HTTP客户端，传入nil时恢复默认客户端
+// scantxoutset在主网上可能需要数分钟，默认10秒超时不够时可传入超时更长的客户端
+func (b *CoreRPCBackend) SetHTTPClient(c *http.Client) {
+	if c == nil {
+		c = newDefaultHTTPClient()
+	}
+	b.httpClient = c
+}
+
+// SetObserver 设置请求观察者，传入nil时取消回调，每次RPC调用回调一次
+func (b *CoreRPCBackend) SetObserver(o Observer) {
+	b.observer = o
+}
+
+// rpcRequest JSON-RPC 1.0请求
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// rpcResponse JSON-RPC响应，Error不为空时表示调用失败
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// call 调用RPC方法并将结果解析到result，节点返回的RPC错误转换为APIError，Body为错误信息
+func (b *CoreRPCBackend) call(ctx context.Context, method string, params []interface{}, result interface{}, action string) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "1.0",
+		ID:      b.nextID.Add(1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("%s失败: %w", action, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s失败: %w", action, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(b.user, b.password)
+
+	start := time.Now()
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		b.observe(start, 0, err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("%s失败: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	b.observe(start, resp.StatusCode, err)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	// 节点对RPC错误同样返回JSON响应(状态码为500或404)，认证失败时返回401且响应为空
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(data, &rpcResp); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return &APIError{Action: action, StatusCode: resp.StatusCode, Endpoint: b.url, Body: string(data)}
+		}
+		return fmt.Errorf("解析RPC响应失败: %w", err)
+	}
+	if rpcResp.Error != nil {
+		return &APIError{
+			Action:     action,
+			StatusCode: resp.StatusCode,
+			Endpoint:   b.url,
+			Body:       fmt.Sprintf("%s (code %d)", rpcResp.Error.Message, rpcResp.Error.Code),
+		}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("解析RPC结果失败: %w", err)
+	}
+	return nil
+}
+
+// observe 向观察者报告一次RPC请求，RPC错误不视为请求失败，由状态码反映
+func (b *CoreRPCBackend) observe(start time.Time, statusCode int, err error) {
+	if b.observer != nil {
+		b.observer.OnRequest(b.url, time.Since(start), statusCode, err)
+	}
+}
+
+// scanResult scantxoutset的返回结果
+type scanResult struct {
+	Unspents []struct {
+		TxID         string  `json:"txid"`
+		Vout         uint32  `json:"vout"`
+		ScriptPubKey string  `json:"scriptPubKey"`
+		Amount       float64 `json:"amount"`
+		Height       int64   `json:"height"`
+	} `json:"unspents"`
+	TotalAmount float64 `json:"total_amount"`
+}
+
+// scanAddress 扫描UTXO集合中属于地址的输出
+func (b *CoreRPCBackend) scanAddress(ctx context.Context, address, action string) (*scanResult, error) {
+	params := []interface{}{"start", []string{"addr(" + address + ")"}}
+
+	var result scanResult
+	if err := b.call(ctx, "scantxoutset", params, &result, action); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Balance 获取地址的已确认余额
+func (b *CoreRPCBackend) Balance(ctx context.Context, address string) (int64, error) {
+	result, err := b.scanAddress(ctx, address, "请求余额")
+	if err != nil {
+		return 0, err
+	}
+
+	amount, err := btcutil.NewAmount(result.TotalAmount)
+	if err != nil {
+		return 0, fmt.Errorf("解析余额失败: %w", err)
+	}
+	return int64(amount), nil
+}
+
+// UTXOs 获取地址已确认的UTXO，内存池中未确认的输出不会返回
+func (b *CoreRPCBackend) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	result, err := b.scanAddress(ctx, address, "请求UTXO")
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]UTXO, 0, len(result.Unspents))
+	for _, u := range result.Unspents {
+		amount, err := btcutil.NewAmount(u.Amount)
+		if err != nil {
+			return nil, fmt.Errorf("解析UTXO失败: %w", err)
+		}
+
+		utxo := UTXO{
+			TxID:    u.TxID,
+			Vout:    u.Vout,
+			Value:   int64(amount),
+			Address: address,
+			Status:  TxStatus{Confirmed: true, BlockHeight: u.Height},
+		}
+		if script, err := hex.DecodeString(u.ScriptPubKey); err == nil {
+			utxo.PkScript = script
+		}
+		utxos = append(utxos, utxo)
+	}
+
+	return utxos, nil
+}
+
+// TxHex 获取交易的原始十六进制数据
+func (b *CoreRPCBackend) TxHex(ctx context.Context, txID string) (string, error) {
+	var txHex string
+	if err := b.call(ctx, "getrawtransaction", []interface{}{txID, false}, &txHex, "请求交易数据"); err != nil {
+		return "", err
+	}
+	return txHex, nil
+}
+
+// Broadcast 通过sendrawtransaction广播交易并返回交易ID
+func (b *CoreRPCBackend) Broadcast(ctx context.Context, txHex string) (string, error) {
+	var txID string
+	if err := b.call(ctx, "sendrawtransaction", []interface{}{txHex}, &txID, "广播交易"); err != nil {
+		return "", err
+	}
+	return txID, nil
+}
+
+// TxStatus 获取交易的确认状态，不含确认数
+func (b *CoreRPCBackend) TxStatus(ctx context.Context, txID string) (TxStatus, error) {
+	var tx struct {
+		BlockHash string `json:"blockhash"`
+	}
+	if err := b.call(ctx, "getrawtransaction", []interface{}{txID, true}, &tx, "请求交易状态"); err != nil {
+		return TxStatus{}, err
+	}
+
+	// 内存池中的交易没有blockhash
+	if tx.BlockHash == "" {
+		return TxStatus{}, nil
+	}
+
+	var header struct {
+		Height int64 `json:"height"`
+	}
+	if err := b.call(ctx, "getblockheader", []interface{}{tx.BlockHash, true}, &header, "请求交易状态"); err != nil {
+		return TxStatus{}, err
+	}
+
+	return TxStatus{Confirmed: true, BlockHeight: header.Height}, nil
+}
+
+// TipHeight 获取当前最新区块高度
+func (b *CoreRPCBackend) TipHeight(ctx context.Context) (int64, error) {
+	var height int64
+	if err := b.call(ctx, "getblockcount", nil, &height, "请求区块高度"); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// FeeEstimates 通过estimatesmartfee获取各确认目标的推荐费率
+// 节点数据不足以估算的目标会被跳过，全部目标都无法估算时返回错误
+func (b *CoreRPCBackend) FeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	estimates := make(FeeEstimates, len(coreRPCFeeTargets))
+	for _, target := range coreRPCFeeTargets {
+		var result struct {
+			FeeRate float64 `json:"feerate"` // BTC/kvB，数据不足时不返回
+		}
+		if err := b.call(ctx, "estimatesmartfee", []interface{}{target}, &result, "请求费率"); err != nil {
+			return nil, err
+		}
+		if result.FeeRate <= 0 {
+			continue
+		}
+
+		// BTC/kvB换算为sat/vB: ×1e8 ÷1000
+		estimates[target] = result.FeeRate * 1e5
+	}
+
+	if len(estimates) == 0 {
+		return nil, fmt.Errorf("请求费率失败: 节点数据不足，无法估算费率")
+	}
+	return estimates, nil
+}
+
+// TransactionHistory Bitcoin Core不提供按地址查询交易的接口，总是返回错误
+func (b *CoreRPCBackend) TransactionHistory(ctx context.Context, address string) ([]TxSummary, error) {
+	return nil, fmt.Errorf("请求交易历史失败: Bitcoin Core RPC后端不支持按地址查询交易历史")
+}
@@ -0,0 +1,110 @@
+package btc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// newTestRPCBackend 启动模拟的bitcoind JSON-RPC服务，responses按方法名返回result部分
+func newTestRPCBackend(t *testing.T, responses map[string]string) *CoreRPCBackend {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			ID     int64             `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Method == "sendrawtransaction" && string(req.Params[0]) == `"dup"` {
+			// bitcoind对RPC错误返回HTTP 500，错误详情在响应体中
+			rw.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(rw, `{"result":null,"error":{"code":-27,"message":"Transaction already in block chain"},"id":%d}`, req.ID)
+			return
+		}
+		result, ok := responses[req.Method]
+		if !ok {
+			rw.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprintf(rw, `{"result":null,"error":{"code":-32601,"message":"Method not found"},"id":%d}`, req.ID)
+			return
+		}
+		fmt.Fprintf(rw, `{"result":%s,"error":null,"id":%d}`, result, req.ID)
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	return NewCoreRPCBackend(u.Hostname(), port, "user", "pass")
+}
+
+func TestCoreRPCBroadcast(t *testing.T) {
+	b := newTestRPCBackend(t, map[string]string{
+		"sendrawtransaction": `"ab"`,
+	})
+	ctx := context.Background()
+
+	txID, err := b.Broadcast(ctx, "0200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txID != "ab" {
+		t.Fatalf("广播返回的txid为%q，期望ab", txID)
+	}
+
+	_, err = b.Broadcast(ctx, "dup")
+	if err == nil {
+		t.Fatal("RPC错误应返回error")
+	}
+	if !isAlreadyBroadcast(err) {
+		t.Fatalf("已上链的交易应被识别为重复广播: %v", err)
+	}
+}
+
+func TestCoreRPCUTXOs(t *testing.T) {
+	b := newTestRPCBackend(t, map[string]string{
+		"scantxoutset": `{"success":true,"unspents":[{"txid":"aa","vout":1,"scriptPubKey":"0014ff","amount":0.00012345,"height":100}],"total_amount":0.00012345}`,
+	})
+	ctx := context.Background()
+
+	utxos, err := b.UTXOs(ctx, testAddress(t, "rpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxos) != 1 || utxos[0].Value != 12345 || utxos[0].Vout != 1 || utxos[0].Status.BlockHeight != 100 {
+		t.Fatalf("UTXO转换错误: %+v", utxos)
+	}
+
+	balance, err := b.Balance(ctx, testAddress(t, "rpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 12345 {
+		t.Fatalf("余额为%d，期望12345", balance)
+	}
+}
+
+func TestCoreRPCAuthFailure(t *testing.T) {
+	b := newTestRPCBackend(t, map[string]string{"getblockcount": "800000"})
+	u, _ := url.Parse(b.url)
+	port, _ := strconv.Atoi(u.Port())
+
+	bad := NewCoreRPCBackend(u.Hostname(), port, "user", "wrong")
+	if _, err := bad.TipHeight(context.Background()); err == nil {
+		t.Fatal("认证失败时应返回错误")
+	}
+	if height, err := b.TipHeight(context.Background()); err != nil || height != 800000 {
+		t.Fatalf("TipHeight返回%d, %v", height, err)
+	}
+}
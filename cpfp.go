@@ -0,0 +1,121 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CreateCPFP 构建花费未确认父交易输出的子交易(CPFP)，返回已签名子交易的十六进制
+// 子交易将该输出转回本钱包，手续费使父子交易合计的费率达到targetPackageFeeRate(sat/vB)
+func (w *BitcoinWallet) CreateCPFP(
+	parentTxID string,
+	vout uint32,
+	value int64,
+	fromAddrType AddressType,
+	targetPackageFeeRate int64,
+) (string, error) {
+	return w.CreateCPFPContext(context.Background(), parentTxID, vout, value, fromAddrType, targetPackageFeeRate)
+}
+
+// CreateCPFPContext 构建CPFP子交易，支持通过ctx取消获取父交易的网络请求
+func (w *BitcoinWallet) CreateCPFPContext(
+	ctx context.Context,
+	parentTxID string,
+	vout uint32,
+	value int64,
+	fromAddrType AddressType,
+	targetPackageFeeRate int64,
+) (string, error) {
+	if targetPackageFeeRate <= 0 {
+		return "", fmt.Errorf("目标费率必须大于0")
+	}
+
+	parent, err := w.fetchTransaction(ctx, parentTxID)
+	if err != nil {
+		return "", fmt.Errorf("获取父交易失败: %w", err)
+	}
+
+	if int(vout) >= len(parent.TxOut) {
+		return "", fmt.Errorf("父交易没有输出%d", vout)
+	}
+
+	prevOut := parent.TxOut[vout]
+	if prevOut.Value != value {
+		return "", fmt.Errorf("输出金额不匹配: 父交易为%d, 传入%d", prevOut.Value, value)
+	}
+
+	inputType, ok := w.ownScriptType(prevOut.PkScript)
+	if !ok {
+		return "", fmt.Errorf("父交易输出%d不属于本钱包", vout)
+	}
+
+	parentFee, err := w.parentFee(ctx, parent)
+	if err != nil {
+		return "", err
+	}
+
+	script, err := w.changeScript(fromAddrType)
+	if err != nil {
+		return "", err
+	}
+
+	// 子交易手续费需补足父交易相对目标费率的差额，且自身费率不低于最低转发费率
+	parentVSize := int64(TxVSize(parent))
+	childVSize := int64(estimateVSize([]AddressType{inputType}, []int{outputSize(len(script))}))
+	childFee := targetPackageFeeRate*(parentVSize+childVSize) - parentFee
+	if minFee := w.feeForVSize(int(childVSize), 0); childFee < minFee {
+		childFee = minFee
+	}
+
+	amount := value - childFee
+	if limit := dustLimit(script); amount < limit {
+		return "", newInsufficientFundsError(childFee+limit, value, childFee)
+	}
+
+	utxo := UTXO{TxID: parentTxID, Vout: vout, Value: value, PkScript: prevOut.PkScript}
+	output := resolvedOutput{script: script, amount: amount}
+	built, err := w.buildTransaction(fromAddrType, []UTXO{utxo}, []resolvedOutput{output}, 0)
+	if err != nil {
+		return "", fmt.Errorf("创建交易失败: %w", err)
+	}
+	tx := built.Tx
+
+	if err = w.SignTransactionContext(ctx, tx, fromAddrType, []UTXO{utxo}); err != nil {
+		return "", fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// parentFee 获取父交易各输入的前序交易并计算其手续费
+func (w *BitcoinWallet) parentFee(ctx context.Context, parent *wire.MsgTx) (int64, error) {
+	var inputTotal int64
+	for i, txIn := range parent.TxIn {
+		prevTx, err := w.fetchTransaction(ctx, txIn.PreviousOutPoint.Hash.String())
+		if err != nil {
+			return 0, fmt.Errorf("获取父交易输入%d的前序交易失败: %w", i, err)
+		}
+
+		index := txIn.PreviousOutPoint.Index
+		if int(index) >= len(prevTx.TxOut) {
+			return 0, fmt.Errorf("父交易输入%d引用的输出不存在", i)
+		}
+		inputTotal += prevTx.TxOut[index].Value
+	}
+
+	var outputTotal int64
+	for _, txOut := range parent.TxOut {
+		outputTotal += txOut.Value
+	}
+
+	return inputTotal - outputTotal, nil
+}
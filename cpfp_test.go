@@ -0,0 +1,65 @@
+package btc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestCreateCPFPCoversParentDeficit(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+
+	// 父交易花费100000，输出共99800，只支付了200聪手续费
+	grandparent := wire.NewMsgTx(2)
+	grandparent.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{9}, 0), nil, nil))
+	grandparent.AddTxOut(wire.NewTxOut(100000, script))
+
+	grandparentHash := grandparent.TxHash()
+	parent := wire.NewMsgTx(2)
+	parent.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&grandparentHash, 0), nil, nil))
+	parent.AddTxOut(wire.NewTxOut(50000, script))
+	parent.AddTxOut(wire.NewTxOut(49800, script))
+	if err := w.SignP2WPKHTransaction(parent, 0, 100000, script); err != nil {
+		t.Fatal(err)
+	}
+	const parentFee = 200
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tx/" + grandparent.TxHash().String() + "/hex":
+			rw.Write([]byte(serializeTx(t, grandparent)))
+		case "/tx/" + parent.TxHash().String() + "/hex":
+			rw.Write([]byte(serializeTx(t, parent)))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	const targetRate = 10
+	childHex, err := w.CreateCPFP(parent.TxHash().String(), 1, 49800, P2WPKH, targetRate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child := deserializeTx(t, childHex)
+	verifyTx(t, child, [][]byte{script}, []int64{49800})
+
+	childFee := 49800 - txOutputTotal(child)
+	packageRate := float64(parentFee+childFee) / float64(TxVSize(parent)+TxVSize(child))
+	if packageRate < targetRate || packageRate > targetRate+0.5 {
+		t.Fatalf("父子交易的整体费率为%.2f sat/vB，目标为%d", packageRate, targetRate)
+	}
+
+	// 子交易需要补足父交易的费率缺口，因此单独的费率高于目标
+	if childRate := float64(childFee) / float64(TxVSize(child)); childRate <= targetRate {
+		t.Fatalf("子交易费率%.2f sat/vB应高于目标%d", childRate, targetRate)
+	}
+}
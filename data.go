@@ -0,0 +1,51 @@
+package btc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// resolveDataOutput 创建OP_RETURN数据输出，金额固定为0且不受dust限制
+func resolveDataOutput(data []byte) (resolvedOutput, error) {
+	if len(data) == 0 {
+		return resolvedOutput{}, fmt.Errorf("OP_RETURN数据不能为空")
+	}
+
+	if len(data) > txscript.MaxDataCarrierSize {
+		return resolvedOutput{}, fmt.Errorf("OP_RETURN数据长度%d超过标准限制(%d)", len(data), txscript.MaxDataCarrierSize)
+	}
+
+	script, err := txscript.NullDataScript(data)
+	if err != nil {
+		return resolvedOutput{}, fmt.Errorf("创建OP_RETURN脚本失败: %w", err)
+	}
+
+	return resolvedOutput{script: script}, nil
+}
+
+// CreateTransactionWithData 创建附带OP_RETURN数据输出的未签名交易，自动计算手续费和找零
+func (w *BitcoinWallet) CreateTransactionWithData(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	data []byte,
+	utxos []UTXO,
+) (*wire.MsgTx, error) {
+	dataOutput, err := resolveDataOutput(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var resolved []resolvedOutput
+	var totalAmount int64
+	if len(outputs) > 0 {
+		resolved, totalAmount, err = w.resolvePaymentOutputs(outputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resolved = append(resolved, dataOutput)
+	return w.buildUnsignedFromResolved(fromAddrType, resolved, totalAmount, utxos)
+}
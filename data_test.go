@@ -0,0 +1,53 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestCreateTransactionWithData(t *testing.T) {
+	w := newTestWallet(t)
+	data := []byte("hello transactor")
+
+	tx, err := w.CreateTransactionWithData(P2WPKH, []PaymentOutput{{Address: testAddress(t, "data"), Amount: 10000}}, data, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dataOutputs := 0
+	for _, out := range tx.TxOut {
+		if txscript.GetScriptClass(out.PkScript) != txscript.NullDataTy {
+			continue
+		}
+		dataOutputs++
+
+		if out.Value != 0 {
+			t.Fatalf("OP_RETURN输出金额应为0，实际为%d", out.Value)
+		}
+
+		tokenizer := txscript.MakeScriptTokenizer(0, out.PkScript)
+		if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_RETURN {
+			t.Fatalf("OP_RETURN脚本格式错误: %x", out.PkScript)
+		}
+		if !tokenizer.Next() || !bytes.Equal(tokenizer.Data(), data) {
+			t.Fatalf("OP_RETURN推送的数据为%x，期望%x", tokenizer.Data(), data)
+		}
+		if tokenizer.Next() || tokenizer.Err() != nil {
+			t.Fatalf("OP_RETURN脚本包含多余内容: %x", out.PkScript)
+		}
+	}
+	if dataOutputs != 1 {
+		t.Fatalf("期望恰好1个OP_RETURN输出，实际为%d", dataOutputs)
+	}
+}
+
+func TestCreateTransactionWithDataTooLarge(t *testing.T) {
+	w := newTestWallet(t)
+	data := bytes.Repeat([]byte{0xab}, txscript.MaxDataCarrierSize+1)
+
+	if _, err := w.CreateTransactionWithData(P2WPKH, nil, data, testUTXOs()); err == nil {
+		t.Fatalf("%d字节的OP_RETURN数据应被拒绝", len(data))
+	}
+}
@@ -0,0 +1,461 @@
+package btc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// BIP380描述符校验和使用的字符集
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// descriptorGenerator BIP380校验和多项式的生成元
+var descriptorGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+// descriptorPolymod 计算BIP380校验和多项式
+func descriptorPolymod(symbols []uint64) uint64 {
+	chk := uint64(1)
+	for _, value := range symbols {
+		top := chk >> 35
+		chk = (chk&0x7ffffffff)<<5 ^ value
+		for i, gen := range descriptorGenerator {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen
+			}
+		}
+	}
+	return chk
+}
+
+// DescriptorChecksum 计算输出描述符(不含#部分)的BIP380校验和
+func DescriptorChecksum(desc string) (string, error) {
+	var symbols, groups []uint64
+	for _, c := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, c)
+		if pos < 0 {
+			return "", fmt.Errorf("描述符包含无效字符: %q", c)
+		}
+		symbols = append(symbols, uint64(pos&31))
+		groups = append(groups, uint64(pos>>5))
+		if len(groups) == 3 {
+			symbols = append(symbols, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+	switch len(groups) {
+	case 1:
+		symbols = append(symbols, groups[0])
+	case 2:
+		symbols = append(symbols, groups[0]*3+groups[1])
+	}
+
+	symbols = append(symbols, 0, 0, 0, 0, 0, 0, 0, 0)
+	checksum := descriptorPolymod(symbols) ^ 1
+
+	result := make([]byte, 8)
+	for i := range result {
+		result[i] = descriptorChecksumCharset[(checksum>>(5*(7-uint(i))))&31]
+	}
+	return string(result), nil
+}
+
+// descriptorWithChecksum 为描述符追加#校验和
+func descriptorWithChecksum(desc string) (string, error) {
+	checksum, err := DescriptorChecksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + checksum, nil
+}
+
+// wrapDescriptor 按地址类型为密钥表达式套上脚本函数
+func wrapDescriptor(addrType AddressType, key string) (string, error) {
+	switch addrType {
+	case P2PKH:
+		return "pkh(" + key + ")", nil
+	case P2WPKH:
+		return "wpkh(" + key + ")", nil
+	case P2SH:
+		return "sh(wpkh(" + key + "))", nil
+	case P2TR:
+		return "tr(" + key + ")", nil
+	default:
+		return "", fmt.Errorf("不支持的地址类型: %s", addrType)
+	}
+}
+
+// Descriptor 导出钱包指定地址类型的输出描述符(BIP380)
+// 由HD钱包按标准路径派生时返回钱包所在账户接收链的范围描述符，如 wpkh([指纹/84h/0h/1h]xpub.../0/*)#校验和，
+// 其余钱包返回单个公钥的描述符
+func (w *BitcoinWallet) Descriptor(addrType AddressType) (string, error) {
+	var key string
+	if w.hdChange() {
+		purpose, err := purposeForAddressType(addrType)
+		if err != nil {
+			return "", err
+		}
+		coin, account, err := w.hdAccountIndexes()
+		if err != nil {
+			return "", err
+		}
+		key, err = w.hd.accountKeyExpression(purpose, coin, account)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		key = hex.EncodeToString(w.publicKey.SerializeCompressed())
+	}
+
+	desc, err := wrapDescriptor(addrType, key)
+	if err != nil {
+		return "", err
+	}
+	return descriptorWithChecksum(desc)
+}
+
+// accountKeyExpression 生成账户m/purpose'/coin'/account'接收链的密钥表达式，包含主密钥指纹和派生路径
+func (h *HDWallet) accountKeyExpression(purpose, coin, accountIndex uint32) (string, error) {
+	account, err := h.deriveKey(fmt.Sprintf("m/%d'/%d'/%d'", purpose, coin, accountIndex))
+	if err != nil {
+		return "", err
+	}
+
+	pub, err := account.Neuter()
+	if err != nil {
+		return "", fmt.Errorf("生成扩展公钥失败: %w", err)
+	}
+
+	masterPub, err := h.master.ECPubKey()
+	if err != nil {
+		return "", fmt.Errorf("获取主公钥失败: %w", err)
+	}
+	fingerprint := btcutil.Hash160(masterPub.SerializeCompressed())[:4]
+
+	return fmt.Sprintf("[%x/%dh/%dh/%dh]%s/0/*", fingerprint, purpose, coin, accountIndex, pub.String()), nil
+}
+
+// parsedDescriptor 解析后的单密钥描述符
+type parsedDescriptor struct {
+	addrType AddressType
+	key      string   // 去掉来源信息和派生路径后的密钥
+	path     []string // 扩展密钥之后的派生路径，可能以*结尾
+}
+
+// parseDescriptor 解析pkh/wpkh/sh(wpkh)/tr形式的单密钥描述符，存在校验和时进行校验
+func parseDescriptor(descriptor string) (*parsedDescriptor, error) {
+	desc := strings.TrimSpace(descriptor)
+	if i := strings.LastIndex(desc, "#"); i >= 0 {
+		expected, err := DescriptorChecksum(desc[:i])
+		if err != nil {
+			return nil, err
+		}
+		if desc[i+1:] != expected {
+			return nil, fmt.Errorf("描述符校验和错误")
+		}
+		desc = desc[:i]
+	}
+
+	forms := []struct {
+		prefix, suffix string
+		addrType       AddressType
+	}{
+		{"sh(wpkh(", "))", P2SH},
+		{"wpkh(", ")", P2WPKH},
+		{"pkh(", ")", P2PKH},
+		{"tr(", ")", P2TR},
+	}
+
+	for _, form := range forms {
+		if !strings.HasPrefix(desc, form.prefix) || !strings.HasSuffix(desc, form.suffix) {
+			continue
+		}
+
+		keyExpr := desc[len(form.prefix) : len(desc)-len(form.suffix)]
+		if strings.ContainsAny(keyExpr, "(),") {
+			return nil, fmt.Errorf("只支持单密钥描述符")
+		}
+
+		// 来源信息[指纹/路径]只用于说明密钥来历，不影响地址
+		if strings.HasPrefix(keyExpr, "[") {
+			end := strings.Index(keyExpr, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("描述符来源信息格式错误")
+			}
+			keyExpr = keyExpr[end+1:]
+		}
+
+		parts := strings.Split(keyExpr, "/")
+		if parts[0] == "" {
+			return nil, fmt.Errorf("描述符缺少密钥")
+		}
+		return &parsedDescriptor{addrType: form.addrType, key: parts[0], path: parts[1:]}, nil
+	}
+
+	return nil, fmt.Errorf("不支持的描述符: %s", descriptor)
+}
+
+// ranged 判断描述符是否以*结尾表示一组地址
+func (d *parsedDescriptor) ranged() bool {
+	return len(d.path) > 0 && d.path[len(d.path)-1] == "*"
+}
+
+// deriveExtended 按描述符路径派生扩展密钥，*替换为index
+func (d *parsedDescriptor) deriveExtended(key *hdkeychain.ExtendedKey, index uint32) (*hdkeychain.ExtendedKey, error) {
+	for _, segment := range d.path {
+		var child uint32
+		if segment == "*" {
+			child = index
+		} else {
+			hardened := strings.HasSuffix(segment, "'") || strings.HasSuffix(segment, "h")
+			if hardened {
+				segment = segment[:len(segment)-1]
+			}
+			value, err := strconv.ParseUint(segment, 10, 32)
+			if err != nil || value >= hdkeychain.HardenedKeyStart {
+				return nil, fmt.Errorf("描述符派生路径无效: %s", segment)
+			}
+			child = uint32(value)
+			if hardened {
+				child += hdkeychain.HardenedKeyStart
+			}
+		}
+
+		var err error
+		key, err = key.Derive(child)
+		if err != nil {
+			return nil, fmt.Errorf("派生密钥失败: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// deriveAddress 按描述符路径派生索引index的扩展密钥，生成描述符类型对应的地址
+func (d *parsedDescriptor) deriveAddress(
+	key *hdkeychain.ExtendedKey,
+	index uint32,
+	netParams *chaincfg.Params,
+) (string, error) {
+	child, err := d.deriveExtended(key, index)
+	if err != nil {
+		return "", err
+	}
+	pubKey, err := child.ECPubKey()
+	if err != nil {
+		return "", fmt.Errorf("获取公钥失败: %w", err)
+	}
+	return d.keyAddress(pubKey, netParams)
+}
+
+// keyAddress 生成公钥在描述符类型下的地址
+func (d *parsedDescriptor) keyAddress(pubKey *btcec.PublicKey, netParams *chaincfg.Params) (string, error) {
+	// 只用于生成地址，不持有私钥
+	keyWallet := &BitcoinWallet{publicKey: pubKey, compressed: true, network: netParams}
+	return keyWallet.GetAddress(d.addrType)
+}
+
+// extendedKey 将描述符密钥解析为扩展密钥，不是扩展密钥时返回nil
+func (d *parsedDescriptor) extendedKey(netParams *chaincfg.Params) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewKeyFromString(d.key)
+	if err != nil {
+		return nil, nil
+	}
+	if !key.IsForNet(netParams) {
+		return nil, fmt.Errorf("扩展密钥网络不匹配")
+	}
+	return key, nil
+}
+
+// NewWalletFromDescriptor 使用包含私钥(WIF或xprv)的单密钥描述符创建钱包
+// 范围描述符(以*结尾)使用索引0的密钥，只含公钥的描述符请使用NewWatchOnlyWalletFromDescriptor
+func NewWalletFromDescriptor(descriptor string, network Network) (*BitcoinWallet, error) {
+	netParams, apiURL, err := networkParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := parseDescriptor(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	extended, err := desc.extendedKey(netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if extended == nil {
+		if len(desc.path) > 0 {
+			return nil, fmt.Errorf("只有扩展密钥可以指定派生路径")
+		}
+		return NewWallet(desc.key, network)
+	}
+
+	if !extended.IsPrivate() {
+		return nil, fmt.Errorf("描述符不包含私钥，请使用观察钱包")
+	}
+
+	child, err := desc.deriveExtended(extended, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	privKey, err := child.ECPrivKey()
+	if err != nil {
+		return nil, fmt.Errorf("获取私钥失败: %w", err)
+	}
+
+	return newWallet(privKey, true, netParams, apiURL), nil
+}
+
+// NewWatchOnlyWalletFromDescriptor 使用单密钥描述符创建观察钱包
+// 范围描述符(以*结尾)派生索引0到count-1的地址，否则只观察描述符对应的单个地址
+func NewWatchOnlyWalletFromDescriptor(descriptor string, count int, network Network) (*WatchOnlyWallet, error) {
+	netParams, _, err := networkParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := parseDescriptor(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	extended, err := desc.extendedKey(netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	switch {
+	case extended != nil:
+		total := 1
+		if desc.ranged() {
+			if count <= 0 {
+				return nil, fmt.Errorf("地址数量必须大于0")
+			}
+			total = count
+		}
+
+		for i := 0; i < total; i++ {
+			address, err := desc.deriveAddress(extended, uint32(i), netParams)
+			if err != nil {
+				return nil, err
+			}
+			addresses = append(addresses, address)
+		}
+	case len(desc.path) > 0:
+		return nil, fmt.Errorf("只有扩展密钥可以指定派生路径")
+	default:
+		pubKey, err := descriptorPubKey(desc.key, netParams)
+		if err != nil {
+			return nil, err
+		}
+		address, err := desc.keyAddress(pubKey, netParams)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return NewWatchOnlyWallet(addresses, network)
+}
+
+// GetUTXOsForDescriptor 按范围描述符派生索引start到start+count-1的地址，逐个查询UTXO
+// 返回的UTXO通过DerivationIndex标记所属地址的派生索引，描述符钱包以此方式扫描资金
+func (w *BitcoinWallet) GetUTXOsForDescriptor(descriptor string, start, count int) ([]UTXO, error) {
+	return w.GetUTXOsForDescriptorContext(context.Background(), descriptor, start, count)
+}
+
+// GetUTXOsForDescriptorContext 按描述符派生范围查询UTXO，支持通过ctx取消请求
+func (w *BitcoinWallet) GetUTXOsForDescriptorContext(ctx context.Context, descriptor string, start, count int) ([]UTXO, error) {
+	if start < 0 {
+		return nil, fmt.Errorf("起始索引不能为负数")
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("地址数量必须大于0")
+	}
+	// 范围内只能使用非硬化索引
+	if int64(start)+int64(count) > hdkeychain.HardenedKeyStart {
+		return nil, fmt.Errorf("派生索引超出范围")
+	}
+
+	desc, err := parseDescriptor(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	if !desc.ranged() {
+		return nil, fmt.Errorf("只有范围描述符(以*结尾)可以按索引派生地址，单个地址请使用GetUTXOs")
+	}
+
+	extended, err := desc.extendedKey(w.network)
+	if err != nil {
+		return nil, err
+	}
+	if extended == nil {
+		return nil, fmt.Errorf("范围描述符必须使用扩展密钥")
+	}
+
+	var all []UTXO
+	for i := start; i < start+count; i++ {
+		index := uint32(i)
+		address, err := desc.deriveAddress(extended, index, w.network)
+		if err != nil {
+			return nil, err
+		}
+
+		utxos, err := w.GetUTXOsContext(ctx, address)
+		if err != nil {
+			return nil, fmt.Errorf("查询索引%d的地址%s失败: %w", index, address, err)
+		}
+		for j := range utxos {
+			derivationIndex := index
+			utxos[j].DerivationIndex = &derivationIndex
+		}
+		all = append(all, utxos...)
+	}
+
+	if all == nil {
+		all = []UTXO{}
+	}
+	return all, nil
+}
+
+// descriptorPubKey 解析描述符中的十六进制公钥或WIF私钥对应的公钥
+func descriptorPubKey(key string, netParams *chaincfg.Params) (*btcec.PublicKey, error) {
+	if data, err := hex.DecodeString(key); err == nil {
+		// tr()中的公钥可以是32字节x-only格式
+		if len(data) == schnorr.PubKeyBytesLen {
+			pubKey, err := schnorr.ParsePubKey(data)
+			if err != nil {
+				return nil, fmt.Errorf("解析公钥失败: %w", err)
+			}
+			return pubKey, nil
+		}
+
+		pubKey, err := btcec.ParsePubKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("解析公钥失败: %w", err)
+		}
+		return pubKey, nil
+	}
+
+	wif, err := btcutil.DecodeWIF(key)
+	if err != nil {
+		return nil, fmt.Errorf("无法识别描述符中的密钥")
+	}
+	if !wif.IsForNet(netParams) {
+		return nil, fmt.Errorf("私钥网络不匹配")
+	}
+	return wif.PrivKey.PubKey(), nil
+}
@@ -0,0 +1,162 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestDescriptorChecksum(t *testing.T) {
+	// BIP380中的校验和示例
+	cases := map[string]string{
+		"raw(deadbeef)": "89f8spxm",
+		"pkh([d34db33f/44'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/1/*)": "ml40v0wf",
+	}
+	for descriptor, want := range cases {
+		got, err := DescriptorChecksum(descriptor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("%s的校验和为%s，期望%s", descriptor, got, want)
+		}
+	}
+}
+
+func TestDescriptorRoundTrip(t *testing.T) {
+	w, err := NewWalletFromMnemonic(testMnemonic, "", MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hd, _ := NewHDWalletFromMnemonic(testMnemonic, "", MainNet)
+
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		t.Run(string(addrType), func(t *testing.T) {
+			descriptor, err := w.Descriptor(addrType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			watchOnly, err := NewWatchOnlyWalletFromDescriptor(descriptor, 2, MainNet)
+			if err != nil {
+				t.Fatalf("解析%s失败: %v", descriptor, err)
+			}
+
+			// 描述符的第二个地址与按BIP44/49/84/86路径派生的外部链索引1一致
+			purpose, _ := purposeForAddressType(addrType)
+			child, _ := hd.DeriveChild(fmt.Sprintf("m/%d'/0'/0'/0/1", purpose))
+			want, _ := child.GetAddress(addrType)
+			if got := watchOnly.Addresses()[1]; got != want {
+				t.Fatalf("描述符派生的地址为%s，期望%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDescriptorUsesWalletAccount(t *testing.T) {
+	hd, _ := NewHDWalletFromMnemonic(testMnemonic, "", MainNet)
+	w, err := hd.DeriveChild("m/84'/0'/1'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptor, err := w.Descriptor(P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(descriptor, "/84h/0h/1h]") {
+		t.Fatalf("描述符%s应使用钱包所在的账户1'", descriptor)
+	}
+
+	watchOnly, err := NewWatchOnlyWalletFromDescriptor(descriptor, 1, MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := w.GetAddress(P2WPKH)
+	if got := watchOnly.Addresses()[0]; got != want {
+		t.Fatalf("描述符派生的地址为%s，期望%s", got, want)
+	}
+}
+
+func TestNewWalletFromDescriptor(t *testing.T) {
+	w := newTestWallet(t)
+	wif, _ := w.WIF()
+
+	descriptor, err := descriptorWithChecksum("sh(wpkh(" + wif + "))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := NewWalletFromDescriptor(descriptor, TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.PublicKeyHex() != w.PublicKeyHex() {
+		t.Fatal("导入的钱包公钥不一致")
+	}
+
+	// 校验和错误时拒绝导入
+	corrupted := descriptor[:len(descriptor)-1] + "q"
+	if descriptor[len(descriptor)-1] == 'q' {
+		corrupted = descriptor[:len(descriptor)-1] + "p"
+	}
+	if _, err := NewWalletFromDescriptor(corrupted, TestNet); err == nil {
+		t.Fatal("校验和错误的描述符应被拒绝")
+	}
+}
+
+// descriptorBackend 记录被查询的地址，每个地址返回一个UTXO
+type descriptorBackend struct {
+	Backend
+	queried []string
+}
+
+func (b *descriptorBackend) UTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	b.queried = append(b.queried, address)
+	return []UTXO{{TxID: strings.Repeat("a", 64), Vout: uint32(len(b.queried)), Value: 1000}}, nil
+}
+
+func TestGetUTXOsForDescriptorRange(t *testing.T) {
+	master, _ := hdkeychain.NewMaster(bytes.Repeat([]byte{7}, 32), &chaincfg.TestNet3Params)
+	xpub, _ := master.Neuter()
+	descriptor := "wpkh(" + xpub.String() + "/0/*)"
+
+	// 独立于描述符解析按BIP32派生/0/3到/0/5的地址
+	external, _ := xpub.Derive(0)
+	var want []string
+	for i := uint32(3); i < 6; i++ {
+		child, _ := external.Derive(i)
+		pubKey, _ := child.ECPubKey()
+		addr, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKey.SerializeCompressed()), &chaincfg.TestNet3Params)
+		want = append(want, addr.EncodeAddress())
+	}
+
+	w := newTestWallet(t)
+	backend := &descriptorBackend{}
+	w.SetBackend(backend)
+	utxos, err := w.GetUTXOsForDescriptor(descriptor, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(backend.queried) != fmt.Sprint(want) {
+		t.Fatalf("查询的地址为%v，期望%v", backend.queried, want)
+	}
+	if len(utxos) != len(want) {
+		t.Fatalf("返回%d个UTXO，期望%d个", len(utxos), len(want))
+	}
+	for i, utxo := range utxos {
+		if utxo.Address != want[i] || utxo.DerivationIndex == nil || *utxo.DerivationIndex != uint32(3+i) {
+			t.Fatalf("第%d个UTXO的地址或派生索引错误: %+v", i, utxo)
+		}
+	}
+
+	if _, err := w.GetUTXOsForDescriptor("wpkh("+xpub.String()+"/0/1)", 0, 2); err == nil {
+		t.Fatal("不带通配符的描述符应返回错误")
+	}
+}
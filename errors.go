@@ -0,0 +1,181 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrInsufficientFunds 余额不足以支付转账金额和手续费
+var ErrInsufficientFunds = errors.New("余额不足")
+
+// InsufficientFundsError 余额不足的详细信息，可通过errors.Is(err, ErrInsufficientFunds)判断
+type InsufficientFundsError struct {
+	Balance   int64 // 可用余额
+	Fee       int64 // 估算的手续费，未知时为0
+	Shortfall int64 // 缺口金额
+}
+
+// Error 实现error接口
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("余额不足: 需要 %d, 可用 %d", e.Balance+e.Shortfall, e.Balance)
+}
+
+// Is 使errors.Is(err, ErrInsufficientFunds)返回true
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// newInsufficientFundsError 根据需要金额和可用余额创建余额不足错误
+func newInsufficientFundsError(required, balance, fee int64) *InsufficientFundsError {
+	return &InsufficientFundsError{
+		Balance:   balance,
+		Fee:       fee,
+		Shortfall: required - balance,
+	}
+}
+
+// ErrNoUTXOs 地址上确实没有UTXO，区块浏览器返回了空列表
+// 网络错误和非200响应返回APIError等其他错误，可通过errors.Is(err, ErrNoUTXOs)区分
+var ErrNoUTXOs = errors.New("没有可用的UTXO")
+
+// ErrNoSpendableUTXOs 地址上有UTXO，但全部被冻结或确认数不足，没有可以花费的UTXO
+var ErrNoSpendableUTXOs = errors.New("没有可花费的UTXO")
+
+// ErrRateLimited 区块浏览器返回HTTP 429，请求过于频繁
+var ErrRateLimited = errors.New("请求过于频繁")
+
+// RateLimitError 限流错误的详细信息，可通过errors.As获取服务端要求的等待时间
+type RateLimitError struct {
+	Action     string        // 被限流的操作
+	RetryAfter time.Duration // Retry-After指定的等待时间，未指定时为0
+	apiErr     *APIError     // 原始响应，可通过errors.As获取
+}
+
+// Error 实现error接口
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s失败: 请求过于频繁, 请在%s后重试", e.Action, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s失败: 请求过于频繁", e.Action)
+}
+
+// Is 使errors.Is(err, ErrRateLimited)返回true
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Unwrap 返回原始的APIError
+func (e *RateLimitError) Unwrap() error {
+	if e.apiErr == nil {
+		return nil
+	}
+	return e.apiErr
+}
+
+// APIError 区块浏览器返回的非200响应
+type APIError struct {
+	Action     string // 失败的操作
+	StatusCode int    // HTTP状态码
+	Endpoint   string // 请求的完整URL
+	Body       string // 响应内容
+}
+
+// Error 实现error接口，响应内容为空时使用状态码描述
+func (e *APIError) Error() string {
+	msg := strings.TrimSpace(e.Body)
+	if msg == "" {
+		msg = fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("%s失败: %s", e.Action, msg)
+}
+
+// ErrTxIDMismatch 广播后服务端返回的交易ID与本地计算的不一致
+var ErrTxIDMismatch = errors.New("交易ID不一致")
+
+// TxIDMismatchError 交易ID不一致的详细信息，可通过errors.Is(err, ErrTxIDMismatch)判断
+type TxIDMismatchError struct {
+	Local  string // 根据交易内容计算的交易ID
+	Remote string // 服务端返回的交易ID
+}
+
+// Error 实现error接口
+func (e *TxIDMismatchError) Error() string {
+	return fmt.Sprintf("交易ID不一致: 本地 %s, 服务端返回 %s", e.Local, e.Remote)
+}
+
+// Is 使errors.Is(err, ErrTxIDMismatch)返回true
+func (e *TxIDMismatchError) Is(target error) bool {
+	return target == ErrTxIDMismatch
+}
+
+// ErrWatchOnly 观察钱包不持有私钥，无法签名
+var ErrWatchOnly = errors.New("观察钱包不持有私钥，无法签名")
+
+// ErrMixedCaseBech32 bech32地址同时包含大写和小写字母，按BIP173无效
+var ErrMixedCaseBech32 = errors.New("bech32地址不能混用大小写")
+
+// ErrSigHashSingleNoOutput 使用SIGHASH_SINGLE签名的输入没有同索引的输出
+var ErrSigHashSingleNoOutput = errors.New("SIGHASH_SINGLE要求存在同索引的输出")
+
+// ErrFeeTooHigh 手续费超过设置的上限
+var ErrFeeTooHigh = errors.New("手续费过高")
+
+// FeeTooHighError 手续费超限的详细信息，可通过errors.Is(err, ErrFeeTooHigh)判断
+type FeeTooHighError struct {
+	Fee    int64 // 交易的手续费
+	Amount int64 // 转账金额
+	Limit  int64 // 触发的手续费上限
+}
+
+// Error 实现error接口
+func (e *FeeTooHighError) Error() string {
+	return fmt.Sprintf("手续费过高: 手续费 %d, 转账金额 %d, 上限 %d", e.Fee, e.Amount, e.Limit)
+}
+
+// Is 使errors.Is(err, ErrFeeTooHigh)返回true
+func (e *FeeTooHighError) Is(target error) bool {
+	return target == ErrFeeTooHigh
+}
+
+// ErrForeignUTXO 输入的UTXO不属于本钱包，签名后无法通过验证
+var ErrForeignUTXO = errors.New("UTXO不属于本钱包")
+
+// ForeignUTXOError 不属于本钱包的输入的详细信息，可通过errors.Is(err, ErrForeignUTXO)判断
+type ForeignUTXOError struct {
+	Index    int    // 输入索引
+	TxID     string // UTXO所在交易ID
+	Vout     uint32 // UTXO的输出索引
+	PkScript []byte // UTXO的输出脚本
+}
+
+// Error 实现error接口
+func (e *ForeignUTXOError) Error() string {
+	return fmt.Sprintf("输入%d(%s:%d)的脚本%x不属于本钱包", e.Index, e.TxID, e.Vout, e.PkScript)
+}
+
+// Is 使errors.Is(err, ErrForeignUTXO)返回true
+func (e *ForeignUTXOError) Is(target error) bool {
+	return target == ErrForeignUTXO
+}
+
+// ErrAddressTypeMismatch 指定的发送方地址类型与UTXO实际所在的地址类型不一致
+var ErrAddressTypeMismatch = errors.New("地址类型不匹配")
+
+// AddressTypeMismatchError 地址类型不匹配的详细信息，可通过errors.Is(err, ErrAddressTypeMismatch)判断
+type AddressTypeMismatchError struct {
+	Claimed AddressType // 调用方指定的地址类型
+	Actual  AddressType // UTXO实际所在的地址类型
+}
+
+// Error 实现error接口
+func (e *AddressTypeMismatchError) Error() string {
+	return fmt.Sprintf("地址类型不匹配: 指定了%s, 但资金位于%s地址, 请使用%s", e.Claimed, e.Actual, e.Actual)
+}
+
+// Is 使errors.Is(err, ErrAddressTypeMismatch)返回true
+func (e *AddressTypeMismatchError) Is(target error) bool {
+	return target == ErrAddressTypeMismatch
+}
@@ -0,0 +1,136 @@
+package btc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ExternalSig 由硬件钱包、HSM或离线设备对SigHashes返回的签名哈希生成的签名
+type ExternalSig struct {
+	Index       int         // 输入索引
+	AddressType AddressType // 输入的地址类型，决定scriptSig和witness的组装方式
+	Signature   []byte      // P2PKH/P2WPKH/P2SH为DER编码的ECDSA签名，不含sighash类型字节；P2TR为64字节Schnorr签名
+	PubKey      []byte      // 签名公钥(压缩格式)，P2TR不需要
+}
+
+// SigHashes 计算交易各输入的签名哈希，供外部设备签名，inputs按交易输入顺序一一对应
+// P2PKH/P2WPKH/P2SH使用SIGHASH_ALL，P2TR为key-path的SIGHASH_DEFAULT，与钱包内签名一致；
+// P2TR签名时需使用按BIP86调整后的私钥。AddressType为空的输入不需要签名，对应位置返回nil
+func SigHashes(tx *wire.MsgTx, inputs []InputInfo) ([][]byte, error) {
+	// 每个输入都要提供前序输出，Taproot签名哈希覆盖全部输入
+	if len(inputs) != len(tx.TxIn) {
+		return nil, fmt.Errorf("输入信息数量(%d)与交易输入数量(%d)不一致", len(inputs), len(tx.TxIn))
+	}
+
+	prevFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, input := range inputs {
+		if len(input.PkScript) == 0 {
+			return nil, fmt.Errorf("输入%d缺少前序输出脚本", i)
+		}
+		prevFetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, wire.NewTxOut(input.Value, input.PkScript))
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevFetcher)
+
+	hashes := make([][]byte, len(inputs))
+	for i, input := range inputs {
+		var hash []byte
+		var err error
+
+		switch input.AddressType {
+		case P2PKH:
+			hash, err = txscript.CalcSignatureHash(input.PkScript, txscript.SigHashAll, tx, i)
+		case P2WPKH:
+			hash, err = txscript.CalcWitnessSigHash(input.PkScript, sigHashes, txscript.SigHashAll, tx, i, input.Value)
+		case P2SH:
+			if len(input.PubKey) == 0 {
+				return nil, fmt.Errorf("输入%d为P2SH，需要提供公钥以还原赎回脚本", i)
+			}
+			var redeemScript []byte
+			redeemScript, err = nestedP2WPKHRedeemScript(input.PubKey)
+			if err == nil {
+				hash, err = txscript.CalcWitnessSigHash(redeemScript, sigHashes, txscript.SigHashAll, tx, i, input.Value)
+			}
+		case P2TR:
+			hash, err = txscript.CalcTaprootSignatureHash(sigHashes, txscript.SigHashDefault, tx, i, prevFetcher)
+		case "":
+			continue
+		default:
+			return nil, fmt.Errorf("不支持的地址类型: %s", input.AddressType)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("计算输入%d的签名哈希失败: %w", i, err)
+		}
+		hashes[i] = hash
+	}
+
+	return hashes, nil
+}
+
+// ApplySignatures 将外部生成的签名组装为对应输入的scriptSig和witness
+// 只检查签名和公钥的编码格式，不验证签名，组装完成后可用VerifyTransaction验证
+func ApplySignatures(tx *wire.MsgTx, sigs []ExternalSig) error {
+	for _, sig := range sigs {
+		if sig.Index < 0 || sig.Index >= len(tx.TxIn) {
+			return fmt.Errorf("输入索引%d超出范围", sig.Index)
+		}
+
+		if err := applySignature(tx.TxIn[sig.Index], sig); err != nil {
+			return fmt.Errorf("应用输入%d的签名失败: %w", sig.Index, err)
+		}
+	}
+	return nil
+}
+
+// applySignature 按地址类型组装单个输入的scriptSig和witness
+func applySignature(txIn *wire.TxIn, sig ExternalSig) error {
+	if sig.AddressType == P2TR {
+		if _, err := schnorr.ParseSignature(sig.Signature); err != nil {
+			return fmt.Errorf("解析Schnorr签名失败: %w", err)
+		}
+		txIn.Witness = wire.TxWitness{sig.Signature}
+		return nil
+	}
+
+	if _, err := ecdsa.ParseDERSignature(sig.Signature); err != nil {
+		return fmt.Errorf("解析ECDSA签名失败: %w", err)
+	}
+	if len(sig.PubKey) != btcec.PubKeyBytesLenCompressed {
+		return fmt.Errorf("公钥必须为33字节的压缩格式")
+	}
+	if _, err := btcec.ParsePubKey(sig.PubKey); err != nil {
+		return fmt.Errorf("解析公钥失败: %w", err)
+	}
+
+	sigWithHashType := append(append([]byte(nil), sig.Signature...), byte(txscript.SigHashAll))
+
+	switch sig.AddressType {
+	case P2PKH:
+		script, err := txscript.NewScriptBuilder().AddData(sigWithHashType).AddData(sig.PubKey).Script()
+		if err != nil {
+			return fmt.Errorf("构建签名脚本失败: %w", err)
+		}
+		txIn.SignatureScript = script
+	case P2WPKH:
+		txIn.Witness = wire.TxWitness{sigWithHashType, sig.PubKey}
+	case P2SH:
+		redeemScript, err := nestedP2WPKHRedeemScript(sig.PubKey)
+		if err != nil {
+			return fmt.Errorf("创建赎回脚本失败: %w", err)
+		}
+		script, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+		if err != nil {
+			return fmt.Errorf("构建签名脚本失败: %w", err)
+		}
+		txIn.Witness = wire.TxWitness{sigWithHashType, sig.PubKey}
+		txIn.SignatureScript = script
+	default:
+		return fmt.Errorf("不支持的地址类型: %s", sig.AddressType)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// externalSignTx 构建每种地址类型各一个输入的交易，返回交易、输入信息和对应的签名私钥
+func externalSignTx(t *testing.T) (*wire.MsgTx, []InputInfo, *btcec.PrivateKey) {
+	t.Helper()
+	w := newTestWallet(t)
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	pubKey := privKey.PubKey().SerializeCompressed()
+
+	tx := wire.NewMsgTx(2)
+	var inputs []InputInfo
+	for i, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, uint32(i)), nil, nil))
+		script, _ := w.addressScript(addrType)
+		inputs = append(inputs, InputInfo{Value: int64(10000 * (i + 1)), PkScript: script, AddressType: addrType, PubKey: pubKey})
+	}
+	tx.AddTxOut(wire.NewTxOut(90000, inputs[1].PkScript))
+	return tx, inputs, privKey
+}
+
+func TestExternalSignatures(t *testing.T) {
+	tx, inputs, privKey := externalSignTx(t)
+
+	hashes, err := SigHashes(tx, inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟外部签名器: 只拿到签名哈希，用btcec直接签名
+	sigs := make([]ExternalSig, len(inputs))
+	for i, input := range inputs {
+		if input.AddressType == P2TR {
+			sig, err := schnorr.Sign(txscript.TweakTaprootPrivKey(*privKey, nil), hashes[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			sigs[i] = ExternalSig{Index: i, AddressType: P2TR, Signature: sig.Serialize()}
+			continue
+		}
+		sig := ecdsa.Sign(privKey, hashes[i])
+		sigs[i] = ExternalSig{Index: i, AddressType: input.AddressType, Signature: sig.Serialize(), PubKey: input.PubKey}
+	}
+	if err := ApplySignatures(tx, sigs); err != nil {
+		t.Fatal(err)
+	}
+
+	scripts := make([][]byte, len(inputs))
+	values := make([]int64, len(inputs))
+	for i, input := range inputs {
+		scripts[i], values[i] = input.PkScript, input.Value
+	}
+	verifyTx(t, tx, scripts, values)
+
+	// ECDSA使用RFC6979，Schnorr使用默认随机数派生，与钱包自己签名的结果逐字节一致
+	internal := tx.Copy()
+	for _, txIn := range internal.TxIn {
+		txIn.SignatureScript, txIn.Witness = nil, nil
+	}
+	if err := newTestWallet(t).SignTransactionMixed(internal, inputs); err != nil {
+		t.Fatal(err)
+	}
+	if internal.WitnessHash() != tx.WitnessHash() {
+		t.Fatal("外部签名组装的交易与钱包签名的交易不一致")
+	}
+}
+
+func TestExternalSignaturesRejects(t *testing.T) {
+	tx, inputs, _ := externalSignTx(t)
+
+	inputs[2].PubKey = nil
+	if _, err := SigHashes(tx, inputs); err == nil {
+		t.Fatal("P2SH-P2WPKH输入缺少公钥时应返回错误")
+	}
+
+	invalid := []ExternalSig{{Index: 0, AddressType: P2PKH, Signature: []byte{1}, PubKey: inputs[0].PubKey}}
+	if err := ApplySignatures(tx, invalid); err == nil {
+		t.Fatal("无效的DER签名应返回错误")
+	}
+}
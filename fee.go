@@ -0,0 +1,180 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// defaultMinRelayFeeRateMilli 默认最低转发费率1 sat/vB，单位为千分之一sat/vB
+const defaultMinRelayFeeRateMilli = 1000
+
+// SetMinRelayFeeRate 设置最低转发费率(sat/vB)，所有手续费计算都不低于该费率，传入0时恢复默认的1 sat/vB
+func (w *BitcoinWallet) SetMinRelayFeeRate(satPerVByte float64) {
+	if math.IsNaN(satPerVByte) || satPerVByte <= 0 {
+		w.minRelayFeeMilli = 0
+		return
+	}
+	w.minRelayFeeMilli = int64(math.Round(satPerVByte * 1000))
+}
+
+// GetMinRelayFeeRate 获取最低转发费率(sat/vB)
+func (w *BitcoinWallet) GetMinRelayFeeRate() float64 {
+	return float64(w.minRelayFeeRateMilli()) / 1000
+}
+
+// minRelayFeeRateMilli 获取以千分之一sat/vB为单位的最低转发费率
+func (w *BitcoinWallet) minRelayFeeRateMilli() int64 {
+	if w.minRelayFeeMilli <= 0 {
+		return defaultMinRelayFeeRateMilli
+	}
+	return w.minRelayFeeMilli
+}
+
+// feeAtRate 按费率(千分之一sat/vB)计算vsize的手续费，向上取整，不受最低转发费率限制
+func feeAtRate(vsize int, rateMilli int64) int64 {
+	return (int64(vsize)*rateMilli + 999) / 1000
+}
+
+// feeForVSize 按费率(千分之一sat/vB)计算虚拟大小为vsize的交易手续费，向上取整到satoshi
+// 费率低于最低转发费率时按最低转发费率计算
+func (w *BitcoinWallet) feeForVSize(vsize int, rateMilli int64) int64 {
+	if minRate := w.minRelayFeeRateMilli(); rateMilli < minRate {
+		rateMilli = minRate
+	}
+	return feeAtRate(vsize, rateMilli)
+}
+
+// checkMinRelayFee 检查已签名交易的手续费不低于按实际虚拟大小计算的最低转发费用
+func (w *BitcoinWallet) checkMinRelayFee(tx *wire.MsgTx, fee int64) error {
+	if minFee := w.feeForVSize(TxVSize(tx), 0); fee < minFee {
+		return fmt.Errorf("手续费(%d)低于最低转发费用(%d)", fee, minFee)
+	}
+	return nil
+}
+
+// defaultMaxFeeRatio 默认允许的手续费占转账金额的最大比例
+const defaultMaxFeeRatio = 0.1
+
+// SetMaxFeeRate 设置允许的最高费率(sat/vB)，SendMany和SendAll的实际费率超过该值时返回ErrFeeTooHigh，传入0时不限制
+func (w *BitcoinWallet) SetMaxFeeRate(satPerVByte float64) {
+	if math.IsNaN(satPerVByte) || satPerVByte <= 0 {
+		w.maxFeeRateMilli = 0
+		return
+	}
+	w.maxFeeRateMilli = int64(math.Round(satPerVByte * 1000))
+}
+
+// SetFeeLimit 设置手续费上限: 不超过转账金额的ratio倍(默认0.1)且不超过absolute聪，
+// 两者传入0时表示不做对应的限制
+func (w *BitcoinWallet) SetFeeLimit(ratio float64, absolute int64) {
+	if math.IsNaN(ratio) || ratio < 0 {
+		ratio = 0
+	}
+	if absolute < 0 {
+		absolute = 0
+	}
+	w.maxFeeRatio = ratio
+	w.maxFee = absolute
+}
+
+// SetAllowHighFee 设置是否跳过手续费上限检查，用于明确需要支付高额手续费的场景
+func (w *BitcoinWallet) SetAllowHighFee(allow bool) {
+	w.allowHighFee = allow
+}
+
+// checkFeeLimits 检查手续费是否超过最高费率、金额比例或绝对上限
+func (w *BitcoinWallet) checkFeeLimits(fee, amount int64, vsize int) error {
+	if w.allowHighFee {
+		return nil
+	}
+
+	if w.maxFeeRateMilli > 0 && fee*1000 > w.maxFeeRateMilli*int64(vsize) {
+		return &FeeTooHighError{Fee: fee, Amount: amount, Limit: w.maxFeeRateMilli * int64(vsize) / 1000}
+	}
+
+	if w.maxFeeRatio > 0 {
+		if limit := int64(float64(amount) * w.maxFeeRatio); fee > limit {
+			return &FeeTooHighError{Fee: fee, Amount: amount, Limit: limit}
+		}
+	}
+
+	if w.maxFee > 0 && fee > w.maxFee {
+		return &FeeTooHighError{Fee: fee, Amount: amount, Limit: w.maxFee}
+	}
+
+	return nil
+}
+
+// txOutputTotal 计算交易全部输出的金额
+func txOutputTotal(tx *wire.MsgTx) int64 {
+	var total int64
+	for _, txOut := range tx.TxOut {
+		total += txOut.Value
+	}
+	return total
+}
+
+// FeeEstimates 确认目标区块数到费率(sat/vB)的映射
+type FeeEstimates map[int]float64
+
+// ForTarget 获取指定确认目标的费率，目标不存在时使用不超过该目标的最近一档，
+// 若没有更快的档位则使用最快可用的档位
+func (f FeeEstimates) ForTarget(blocks int) (float64, bool) {
+	if len(f) == 0 {
+		return 0, false
+	}
+
+	if rate, ok := f[blocks]; ok {
+		return rate, true
+	}
+
+	targets := make([]int, 0, len(f))
+	for target := range f {
+		targets = append(targets, target)
+	}
+	sort.Ints(targets)
+
+	chosen := targets[0]
+	for _, target := range targets {
+		if target > blocks {
+			break
+		}
+		chosen = target
+	}
+
+	return f[chosen], true
+}
+
+// FetchFeeRates 从区块链数据后端获取推荐费率，通过SetCacheTTL启用缓存后有效期内不重复请求
+func (w *BitcoinWallet) FetchFeeRates() (FeeEstimates, error) {
+	return w.FetchFeeRatesContext(context.Background())
+}
+
+// FetchFeeRatesContext 获取推荐费率，支持通过ctx取消请求
+func (w *BitcoinWallet) FetchFeeRatesContext(ctx context.Context) (FeeEstimates, error) {
+	return w.cachedFeeEstimates(ctx)
+}
+
+// SetFeeRateFromTarget 按确认目标区块数从浏览器获取费率并设置，保留小数精度
+func (w *BitcoinWallet) SetFeeRateFromTarget(blocks int) error {
+	if blocks <= 0 {
+		return fmt.Errorf("确认目标必须大于0")
+	}
+
+	estimates, err := w.FetchFeeRates()
+	if err != nil {
+		return err
+	}
+
+	rate, ok := estimates.ForTarget(blocks)
+	if !ok {
+		return fmt.Errorf("浏览器未返回任何费率")
+	}
+
+	w.SetFeeRateFloat(rate)
+	return nil
+}
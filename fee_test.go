@@ -0,0 +1,175 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFractionalFeeRate(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRateFloat(1.5)
+
+	if fee := w.feeForVSize(140, w.feeRateMilli); fee != 210 {
+		t.Fatalf("1.5 sat/vB、140 vB的手续费为%d，期望210", fee)
+	}
+	// 向上取整到整聪
+	if fee := w.feeForVSize(141, w.feeRateMilli); fee != 212 {
+		t.Fatalf("1.5 sat/vB、141 vB的手续费为%d，期望212", fee)
+	}
+	if w.GetFeeRateFloat() != 1.5 || w.GetFeeRate() != 2 {
+		t.Fatalf("费率为%f/%d，期望1.5和向上取整的2", w.GetFeeRateFloat(), w.GetFeeRate())
+	}
+}
+
+func TestFeeRateNeverBelowMinRelay(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRateFloat(0.2)
+
+	if fee := w.feeForVSize(140, w.feeRateMilli); fee != 140 {
+		t.Fatalf("低于最低转发费率时应按1 sat/vB计算，实际手续费为%d", fee)
+	}
+
+	utxos := []UTXO{{TxID: strings.Repeat("1", 64), Value: 100000}}
+	rawTx, err := w.CreateRawTransactionWithOutputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "fee"), Amount: 10000}}, utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := deserializeTx(t, rawTx)
+	fee := 100000 - txOutputTotal(tx)
+
+	var outputSizes []int
+	for _, txOut := range tx.TxOut {
+		outputSizes = append(outputSizes, outputSize(len(txOut.PkScript)))
+	}
+	if vsize := estimateVSize([]AddressType{P2WPKH}, outputSizes); fee != int64(vsize) {
+		t.Fatalf("手续费为%d，期望按最低转发费率计算的%d", fee, vsize)
+	}
+}
+
+func TestFetchFeeRates(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fee-estimates":
+			// Esplora: 目标区块数(字符串)到sat/vB的映射
+			rw.Write([]byte(`{"1":25.3,"3":12.1,"144":1.02}`))
+		case "/v1/fees/recommended":
+			rw.Write([]byte(`{"fastestFee":30,"halfHourFee":20,"hourFee":12,"economyFee":4,"minimumFee":1}`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	w := newTestWallet(t)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	estimates, err := w.FetchFeeRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (FeeEstimates{1: 25.3, 3: 12.1, 144: 1.02}); !reflect.DeepEqual(estimates, want) {
+		t.Fatalf("Esplora费率为%v，期望%v", estimates, want)
+	}
+
+	// 目标2不存在时使用更快的目标1，保留小数精度
+	if err := w.SetFeeRateFromTarget(2); err != nil {
+		t.Fatal(err)
+	}
+	if rate := w.GetFeeRateFloat(); rate != 25.3 {
+		t.Fatalf("目标2的费率为%v，期望25.3", rate)
+	}
+
+	w.SetBackend(NewMempoolSpaceBackend(srv.URL))
+	estimates, err = w.FetchFeeRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (FeeEstimates{1: 30, 3: 20, 6: 12, 144: 4, 1008: 1}); !reflect.DeepEqual(estimates, want) {
+		t.Fatalf("mempool.space费率为%v，期望%v", estimates, want)
+	}
+
+	if err := w.SetFeeRateFromTarget(10); err != nil {
+		t.Fatal(err)
+	}
+	if rate := w.GetFeeRateFloat(); rate != 12 {
+		t.Fatalf("目标10的费率为%v，期望hourFee的12", rate)
+	}
+}
+
+func TestZeroFeeRateStillRelayable(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(0)
+	script, _ := w.addressScript(P2WPKH)
+	utxos := testUTXOs()
+	for i := range utxos {
+		utxos[i].PkScript = script
+	}
+	outputs := []PaymentOutput{{Address: testAddress(t, "relay"), Amount: 25000}}
+
+	for _, minRelay := range []float64{0, 2.5} {
+		// 0表示使用默认的1 sat/vB
+		w.SetMinRelayFeeRate(minRelay)
+		floor := w.GetMinRelayFeeRate()
+
+		prepared, err := w.PrepareTransactionWithInputs(P2WPKH, outputs, utxos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vsize := TxVSize(prepared.Tx)
+		if float64(prepared.Fee) < floor*float64(vsize) {
+			t.Fatalf("费率为0时手续费为%d，低于%.1f sat/vB x %d vB", prepared.Fee, floor, vsize)
+		}
+		if err := w.checkMinRelayFee(prepared.Tx, prepared.Fee); err != nil {
+			t.Fatal(err)
+		}
+
+		// SendAll的单输出交易同样不低于最低转发费用
+		var sent string
+		newTestServer(t, w, fmt.Sprintf(`[{"txid":"%s","vout":0,"value":30000}]`, strings.Repeat("1", 64)),
+			func(txHex string) { sent = txHex })
+		if _, err := w.SendAll(P2WPKH, outputs[0].Address); err != nil {
+			t.Fatal(err)
+		}
+		tx := deserializeTx(t, sent)
+		if fee := 30000 - txOutputTotal(tx); float64(fee) < floor*float64(TxVSize(tx)) {
+			t.Fatalf("费率为0时SendAll手续费为%d，低于%.1f sat/vB x %d vB", fee, floor, TxVSize(tx))
+		}
+	}
+}
+
+func TestAbsurdFeeRateRejected(t *testing.T) {
+	w := newTestWallet(t)
+	// 误将sat/kB的数值当作sat/vB填写
+	w.SetFeeRate(100000)
+	var sent int
+	newTestServer(t, w, fmt.Sprintf(`[{"txid":"%s","vout":0,"value":100000000}]`, strings.Repeat("1", 64)),
+		func(string) { sent++ })
+	outputs := []PaymentOutput{{Address: testAddress(t, "absurd"), Amount: 20000}}
+
+	var tooHigh *FeeTooHighError
+	if _, err := w.SendMany(P2WPKH, outputs); !errors.As(err, &tooHigh) || !errors.Is(err, ErrFeeTooHigh) {
+		t.Fatalf("100000 sat/vB的小额转账应返回FeeTooHighError，实际为%v", err)
+	}
+	if tooHigh.Amount != 20000 || tooHigh.Limit != 2000 || tooHigh.Fee <= tooHigh.Limit {
+		t.Fatalf("错误详情为%+v，期望按转账金额的10%%限制", tooHigh)
+	}
+	if sent != 0 {
+		t.Fatal("手续费过高时不应广播交易")
+	}
+
+	// 明确允许后可以发送
+	w.SetAllowHighFee(true)
+	if _, err := w.SendMany(P2WPKH, outputs); err != nil {
+		t.Fatalf("允许高手续费后应能发送，实际为%v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("应广播1笔交易，实际为%d笔", sent)
+	}
+}
@@ -0,0 +1,79 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+)
+
+// FeeRateLevel 设置的费率相对当前内存池推荐费率所处的档位
+type FeeRateLevel string
+
+const (
+	FeeRateFast         FeeRateLevel = "fast"          // 不低于最快档，预计下一个区块确认
+	FeeRateMedium       FeeRateLevel = "medium"        // 不低于一小时档，预计数个区块内确认
+	FeeRateSlow         FeeRateLevel = "slow"          // 低于一小时档但不低于最低费率，可能需要等待较长时间
+	FeeRateBelowMinimum FeeRateLevel = "below_minimum" // 低于内存池最低费率或最低转发费率，交易可能无法广播或长期不确认
+)
+
+// 费率检查参考的确认目标区块数
+const (
+	feeCheckFastTarget   = mempoolFastestTarget
+	feeCheckMediumTarget = mempoolHourTarget
+	feeCheckSlowTarget   = mempoolEconomyTarget
+)
+
+// FeeSanity 费率检查结果，费率单位均为sat/vB
+type FeeSanity struct {
+	Level   FeeRateLevel // 设置的费率所处档位
+	FeeRate float64      // 钱包设置的费率
+	Fast    float64      // 最快档推荐费率
+	Medium  float64      // 一小时档推荐费率
+	Slow    float64      // 经济档推荐费率
+	Minimum float64      // 推荐费率中的最低值，不低于最低转发费率
+}
+
+// CheckFeeRate 将钱包设置的费率与浏览器当前的推荐费率比较，判断交易大致的确认速度
+// 结果仅供参考，不影响交易构建；费率过低时由调用方决定是否提醒用户或改用SetFeeRateFromTarget
+func (w *BitcoinWallet) CheckFeeRate() (FeeSanity, error) {
+	return w.CheckFeeRateContext(context.Background())
+}
+
+// CheckFeeRateContext 检查设置的费率，支持通过ctx取消请求
+func (w *BitcoinWallet) CheckFeeRateContext(ctx context.Context) (FeeSanity, error) {
+	estimates, err := w.cachedFeeEstimates(ctx)
+	if err != nil {
+		return FeeSanity{}, err
+	}
+	if len(estimates) == 0 {
+		return FeeSanity{}, fmt.Errorf("浏览器未返回任何费率")
+	}
+
+	sanity := FeeSanity{FeeRate: w.GetFeeRateFloat()}
+	sanity.Fast, _ = estimates.ForTarget(feeCheckFastTarget)
+	sanity.Medium, _ = estimates.ForTarget(feeCheckMediumTarget)
+	sanity.Slow, _ = estimates.ForTarget(feeCheckSlowTarget)
+
+	sanity.Minimum = w.GetMinRelayFeeRate()
+	lowest := -1.0
+	for _, rate := range estimates {
+		if lowest < 0 || rate < lowest {
+			lowest = rate
+		}
+	}
+	if lowest > sanity.Minimum {
+		sanity.Minimum = lowest
+	}
+
+	switch {
+	case sanity.FeeRate >= sanity.Fast:
+		sanity.Level = FeeRateFast
+	case sanity.FeeRate >= sanity.Medium:
+		sanity.Level = FeeRateMedium
+	case sanity.FeeRate >= sanity.Minimum:
+		sanity.Level = FeeRateSlow
+	default:
+		sanity.Level = FeeRateBelowMinimum
+	}
+
+	return sanity, nil
+}
@@ -0,0 +1,40 @@
+package btc
+
+import (
+	"context"
+	"testing"
+)
+
+// feeEstimatesBackend 返回固定费率估算的后端
+type feeEstimatesBackend struct{ Backend }
+
+func (feeEstimatesBackend) FeeEstimates(ctx context.Context) (FeeEstimates, error) {
+	return FeeEstimates{1: 20, 3: 15, 6: 10, 144: 3, 1008: 1}, nil
+}
+
+func TestCheckFeeRate(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetBackend(feeEstimatesBackend{})
+
+	tests := []struct {
+		rate float64
+		want FeeRateLevel
+	}{
+		{25, FeeRateFast},
+		{20, FeeRateFast},
+		{10, FeeRateMedium},
+		{9.9, FeeRateSlow},
+		{1, FeeRateSlow},
+		{0.5, FeeRateBelowMinimum},
+	}
+	for _, tt := range tests {
+		w.SetFeeRateFloat(tt.rate)
+		sanity, err := w.CheckFeeRate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sanity.Level != tt.want {
+			t.Errorf("%v sat/vB 判定为%s，期望%s", tt.rate, sanity.Level, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFrozenUTXONeverSelected(t *testing.T) {
+	w := newTestWallet(t)
+	bigTxID := strings.Repeat("3", 64)
+	utxos := []UTXO{
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 5000},
+		{TxID: strings.Repeat("2", 64), Vout: 0, Value: 3000},
+		{TxID: bigTxID, Vout: 2, Value: 90000},
+	}
+	// 冻结时txid大小写不影响匹配
+	if err := w.SetFrozenUTXOs([]string{strings.ToUpper(bigTxID) + ":2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只有被冻结的UTXO足够支付，选择失败而不是花费冻结的UTXO
+	var insufficient *InsufficientFundsError
+	if _, _, err := w.SelectUTXOs(utxos, 20000); !errors.As(err, &insufficient) {
+		t.Fatalf("应返回InsufficientFundsError，实际为%v", err)
+	}
+	if insufficient.Balance != 8000 {
+		t.Fatalf("可用金额为%d，不应计入冻结的UTXO", insufficient.Balance)
+	}
+
+	// SendMany的选币同样跳过冻结的UTXO，不会广播任何交易
+	entries := make([]string, len(utxos))
+	for i, utxo := range utxos {
+		entries[i] = fmt.Sprintf(`{"txid":"%s","vout":%d,"value":%d}`, utxo.TxID, utxo.Vout, utxo.Value)
+	}
+	newTestServer(t, w, "["+strings.Join(entries, ",")+"]", func(string) {
+		t.Fatal("资金不足时不应广播交易")
+	})
+	if _, err := w.SendMany(P2WPKH, []PaymentOutput{{Address: testAddress(t, "frozen"), Amount: 20000}}); !errors.As(err, &insufficient) {
+		t.Fatalf("SendMany应返回InsufficientFundsError，实际为%v", err)
+	}
+
+	// 解除冻结后可以选中
+	if err := w.SetFrozenUTXOs(nil); err != nil {
+		t.Fatal(err)
+	}
+	selected, _, err := w.SelectUTXOs(utxos, 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected[len(selected)-1].TxID != bigTxID {
+		t.Fatalf("解除冻结后应选中大额UTXO，实际选中%v", selected)
+	}
+}
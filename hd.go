@@ -0,0 +1,386 @@
+package btc
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// HDWallet BIP32分层确定性钱包
+type HDWallet struct {
+	master  *hdkeychain.ExtendedKey
+	network *chaincfg.Params
+	apiURL  string
+
+	mu          sync.Mutex
+	changeIndex map[string]uint32 // 各账户内部链(.../1/i)下一个未使用的找零索引，键为账户路径
+}
+
+// NewHDWallet 通过种子创建HD钱包
+func NewHDWallet(seed []byte, network Network) (*HDWallet, error) {
+	netParams, apiURL, err := networkParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	master, err := hdkeychain.NewMaster(seed, netParams)
+	if err != nil {
+		return nil, fmt.Errorf("创建主密钥失败: %w", err)
+	}
+
+	return &HDWallet{
+		master:  master,
+		network: netParams,
+		apiURL:  apiURL,
+	}, nil
+}
+
+// NewHDWalletFromMnemonic 通过BIP39助记词创建HD钱包
+func NewHDWalletFromMnemonic(mnemonic, passphrase string, network Network) (*HDWallet, error) {
+	// 统一空白字符，校验词表和校验和
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(normalized, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("助记词无效: %w", err)
+	}
+
+	return NewHDWallet(seed, network)
+}
+
+// DeriveChild 按BIP32路径(如 m/84'/0'/0'/0/5)派生子密钥并返回绑定该密钥的钱包
+func (h *HDWallet) DeriveChild(path string) (*BitcoinWallet, error) {
+	key, err := h.deriveKey(path)
+	if err != nil {
+		return nil, err
+	}
+
+	privKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, fmt.Errorf("获取私钥失败: %w", err)
+	}
+
+	wallet := newWallet(privKey, true, h.network, h.apiURL)
+	wallet.hd = h
+	wallet.hdAccount = accountPath(path)
+	return wallet, nil
+}
+
+// accountPath 从 m/purpose'/coin'/account'/chain/index 形式的路径中取出账户路径，
+// 非标准路径返回空字符串
+func accountPath(path string) string {
+	indexes, err := parseDerivationPath(path)
+	if err != nil || len(indexes) != 5 {
+		return ""
+	}
+	return formatAccountPath(indexes[:3])
+}
+
+// formatAccountPath 由解析后的三级索引生成规范写法的账户路径 m/purpose'/coin'/account'，
+// 使 m/84h/0h/0h 与 m/84'/0'/0' 对应同一个找零索引；含非硬化索引时返回空字符串
+func formatAccountPath(indexes []uint32) string {
+	for _, index := range indexes {
+		if index < hdkeychain.HardenedKeyStart {
+			return ""
+		}
+	}
+	return fmt.Sprintf("m/%d'/%d'/%d'",
+		indexes[0]-hdkeychain.HardenedKeyStart,
+		indexes[1]-hdkeychain.HardenedKeyStart,
+		indexes[2]-hdkeychain.HardenedKeyStart)
+}
+
+// hdAccountIndexes 返回钱包所在账户路径中的币种和账户索引(不含硬化标记)
+func (w *BitcoinWallet) hdAccountIndexes() (uint32, uint32, error) {
+	indexes, err := parseDerivationPath(w.hdAccount)
+	if err != nil || len(indexes) != 3 {
+		return 0, 0, fmt.Errorf("钱包不是由标准账户路径派生")
+	}
+	return indexes[1] - hdkeychain.HardenedKeyStart, indexes[2] - hdkeychain.HardenedKeyStart, nil
+}
+
+// changePath 返回账户内部链上第index个找零地址的派生路径
+func changePath(account string, index uint32) string {
+	return fmt.Sprintf("%s/1/%d", account, index)
+}
+
+// nextChangeIndex 获取账户下一个未使用的找零索引
+func (h *HDWallet) nextChangeIndex(account string) uint32 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.changeIndex[account]
+}
+
+// markChangeUsed 交易输出使用了账户当前的找零地址时将找零索引前移
+// 读取和前移在同一把锁内完成，并发构建的交易使用了同一个找零地址时只前移一次
+func (h *HDWallet) markChangeUsed(account string, tx *wire.MsgTx) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	index := h.changeIndex[account]
+	key, err := h.changeKey(account, index)
+	if err != nil {
+		return
+	}
+
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		script, err := key.addressScript(addrType)
+		if err != nil {
+			continue
+		}
+		for _, txOut := range tx.TxOut {
+			if bytes.Equal(txOut.PkScript, script) {
+				if h.changeIndex == nil {
+					h.changeIndex = make(map[string]uint32)
+				}
+				h.changeIndex[account] = index + 1
+				return
+			}
+		}
+	}
+}
+
+// SetNextChangeIndex 设置账户(如 m/84'/0'/0')下一个未使用的找零索引，用于恢复钱包后跳过已使用的找零地址
+// 硬化标记可以写作'、h或H
+func (h *HDWallet) SetNextChangeIndex(account string, index uint32) {
+	if indexes, err := parseDerivationPath(account); err == nil && len(indexes) == 3 {
+		if canonical := formatAccountPath(indexes); canonical != "" {
+			account = canonical
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.changeIndex == nil {
+		h.changeIndex = make(map[string]uint32)
+	}
+	h.changeIndex[account] = index
+}
+
+// changeKey 派生账户内部链上第index个地址的公钥，返回只用于生成地址脚本、不持有私钥的钱包
+func (h *HDWallet) changeKey(account string, index uint32) (*BitcoinWallet, error) {
+	key, err := h.deriveKey(changePath(account, index))
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return nil, fmt.Errorf("获取公钥失败: %w", err)
+	}
+
+	return &BitcoinWallet{publicKey: pubKey, compressed: true, network: h.network}, nil
+}
+
+// changeScript 派生账户内部链上第index个地址的输出脚本
+func (h *HDWallet) changeScript(account string, index uint32, addrType AddressType) ([]byte, error) {
+	key, err := h.changeKey(account, index)
+	if err != nil {
+		return nil, err
+	}
+	return key.addressScript(addrType)
+}
+
+// findChangeScript 在账户内部链上已使用的找零地址中查找脚本，返回其找零索引和地址类型
+func (h *HDWallet) findChangeScript(account string, script []byte) (uint32, AddressType, bool) {
+	next := h.nextChangeIndex(account)
+	for index := uint32(0); index < next; index++ {
+		key, err := h.changeKey(account, index)
+		if err != nil {
+			continue
+		}
+		for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+			changeScript, err := key.addressScript(addrType)
+			if err == nil && bytes.Equal(changeScript, script) {
+				return index, addrType, true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// isChangeScript 判断脚本是否为账户内部链上已使用的指定类型找零地址
+func (h *HDWallet) isChangeScript(account string, script []byte, addrType AddressType) bool {
+	_, changeType, ok := h.findChangeScript(account, script)
+	return ok && changeType == addrType
+}
+
+// changeSigner 脚本为HD账户内部链上已使用的找零地址时，返回持有对应私钥的钱包和地址类型
+// 子钱包沿用本钱包的签名设置(low-R、辅助随机数)
+func (w *BitcoinWallet) changeSigner(pkScript []byte) (*BitcoinWallet, AddressType, bool) {
+	if !w.hdChange() || w.privateKey == nil {
+		return nil, "", false
+	}
+
+	index, addrType, ok := w.hd.findChangeScript(w.hdAccount, pkScript)
+	if !ok {
+		return nil, "", false
+	}
+
+	child, err := w.hd.DeriveChild(changePath(w.hdAccount, index))
+	if err != nil {
+		return nil, "", false
+	}
+	child.lowR = w.lowR
+	child.auxRand = w.auxRand
+	return child, addrType, true
+}
+
+// inputSigner 选择签名输入的钱包，花费HD找零输出时使用内部链上对应的子密钥
+func (w *BitcoinWallet) inputSigner(pkScript []byte, addrType AddressType) *BitcoinWallet {
+	if own, err := w.addressScript(addrType); err == nil && bytes.Equal(own, pkScript) {
+		return w
+	}
+	if child, _, ok := w.changeSigner(pkScript); ok {
+		return child
+	}
+	return w
+}
+
+// 扩展公钥版本字节(SLIP-0132)
+var (
+	xpubVersion = [4]byte{0x04, 0x88, 0xb2, 0x1e}
+	ypubVersion = [4]byte{0x04, 0x9d, 0x7c, 0xb2}
+	zpubVersion = [4]byte{0x04, 0xb2, 0x47, 0x46}
+	tpubVersion = [4]byte{0x04, 0x35, 0x87, 0xcf}
+	upubVersion = [4]byte{0x04, 0x4a, 0x52, 0x62}
+	vpubVersion = [4]byte{0x04, 0x5f, 0x1c, 0x0c}
+)
+
+// xpubVersionFor 获取地址类型和网络对应的扩展公钥版本字节
+func xpubVersionFor(addrType AddressType, netParams *chaincfg.Params) ([4]byte, error) {
+	mainnet := coinType(netParams) == 0
+
+	switch addrType {
+	case P2PKH, P2TR:
+		if mainnet {
+			return xpubVersion, nil
+		}
+		return tpubVersion, nil
+	case P2SH:
+		if mainnet {
+			return ypubVersion, nil
+		}
+		return upubVersion, nil
+	case P2WPKH:
+		if mainnet {
+			return zpubVersion, nil
+		}
+		return vpubVersion, nil
+	default:
+		return [4]byte{}, fmt.Errorf("不支持的地址类型: %s", addrType)
+	}
+}
+
+// AccountXPub 导出账户层级(m/purpose'/coin'/0')的扩展公钥，按地址类型使用xpub/ypub/zpub编码
+func (h *HDWallet) AccountXPub(addrType AddressType) (string, error) {
+	return h.accountXPub(addrType, coinType(h.network), 0)
+}
+
+// accountXPub 导出账户m/purpose'/coin'/account'的扩展公钥
+func (h *HDWallet) accountXPub(addrType AddressType, coin, accountIndex uint32) (string, error) {
+	purpose, err := purposeForAddressType(addrType)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := xpubVersionFor(addrType, h.network)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := h.deriveKey(fmt.Sprintf("m/%d'/%d'/%d'", purpose, coin, accountIndex))
+	if err != nil {
+		return "", err
+	}
+
+	pub, err := account.Neuter()
+	if err != nil {
+		return "", fmt.Errorf("生成扩展公钥失败: %w", err)
+	}
+
+	encoded, err := pub.CloneWithVersion(version[:])
+	if err != nil {
+		return "", fmt.Errorf("编码扩展公钥失败: %w", err)
+	}
+
+	return encoded.String(), nil
+}
+
+// AccountXPub 导出钱包所属HD账户的扩展公钥，仅支持由HD钱包派生的钱包
+// 按标准路径派生时使用钱包所在的账户，否则使用账户0
+func (w *BitcoinWallet) AccountXPub(addrType AddressType) (string, error) {
+	if w.hd == nil {
+		return "", fmt.Errorf("钱包不是由HD种子派生，无法导出扩展公钥")
+	}
+	if !w.hdChange() {
+		return w.hd.AccountXPub(addrType)
+	}
+
+	coin, account, err := w.hdAccountIndexes()
+	if err != nil {
+		return "", err
+	}
+	return w.hd.accountXPub(addrType, coin, account)
+}
+
+// deriveKey 按路径派生扩展密钥
+func (h *HDWallet) deriveKey(path string) (*hdkeychain.ExtendedKey, error) {
+	indexes, err := parseDerivationPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := h.master
+	for _, index := range indexes {
+		key, err = key.Derive(index)
+		if err != nil {
+			return nil, fmt.Errorf("派生密钥失败: %w", err)
+		}
+	}
+
+	return key, nil
+}
+
+// parseDerivationPath 解析BIP32路径，支持 ' 、h 和 H 作为硬化标记
+func parseDerivationPath(path string) ([]uint32, error) {
+	segments := strings.Split(strings.TrimSpace(path), "/")
+	if len(segments) == 0 || segments[0] != "m" {
+		return nil, fmt.Errorf("派生路径必须以m开头: %s", path)
+	}
+
+	indexes := make([]uint32, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		hardened := false
+		if strings.HasSuffix(segment, "'") || strings.HasSuffix(segment, "h") || strings.HasSuffix(segment, "H") {
+			hardened = true
+			segment = segment[:len(segment)-1]
+		}
+
+		if segment == "" || strings.HasPrefix(segment, "+") {
+			return nil, fmt.Errorf("派生路径格式错误: %s", path)
+		}
+
+		index, err := strconv.ParseUint(segment, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("派生路径索引无效(%s): %w", segment, err)
+		}
+
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("派生路径索引超出范围: %s", segment)
+		}
+
+		if hardened {
+			index += hdkeychain.HardenedKeyStart
+		}
+		indexes = append(indexes, uint32(index))
+	}
+
+	return indexes, nil
+}
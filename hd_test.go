@@ -0,0 +1,137 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveBIP84Vectors(t *testing.T) {
+	// BIP84测试向量中测试助记词在主网上的前几个地址
+	cases := map[string]string{
+		"m/84'/0'/0'/0/0": "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu",
+		"m/84'/0'/0'/0/1": "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g",
+		"m/84h/0h/0h/1/0": "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el",
+	}
+
+	h, err := NewHDWalletFromMnemonic(testMnemonic, "", MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range cases {
+		w, err := h.DeriveChild(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, _ := w.GetAddress(P2WPKH)
+		if addr != want {
+			t.Fatalf("%s的地址为%s，期望%s", path, addr, want)
+		}
+	}
+
+	w, err := NewWalletFromMnemonic(testMnemonic, "", MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr, _ := w.GetAddress(P2WPKH); addr != cases["m/84'/0'/0'/0/0"] {
+		t.Fatalf("NewWalletFromMnemonic的地址为%s，期望%s", addr, cases["m/84'/0'/0'/0/0"])
+	}
+
+	for _, path := range []string{"84'/0'/0'", "m/84'/x", "m/2147483648", "m/84'//0"} {
+		if _, err := h.DeriveChild(path); err == nil {
+			t.Fatalf("无效路径%s应返回错误", path)
+		}
+	}
+}
+
+func TestAccountXPubVectors(t *testing.T) {
+	// BIP84和BIP44测试向量中测试助记词账户0的扩展公钥
+	cases := map[AddressType]string{
+		P2WPKH: "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs",
+		P2PKH:  "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj",
+	}
+
+	h, err := NewHDWalletFromMnemonic(testMnemonic, "", MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := h.DeriveChild("m/84'/0'/0'/0/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for addrType, want := range cases {
+		xpub, err := h.AccountXPub(addrType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if xpub != want {
+			t.Fatalf("%s账户扩展公钥为%s，期望%s", addrType, xpub, want)
+		}
+
+		// 派生钱包导出所在账户的扩展公钥
+		if xpub, _ = w.AccountXPub(addrType); xpub != want {
+			t.Fatalf("派生钱包的%s账户扩展公钥为%s，期望%s", addrType, xpub, want)
+		}
+	}
+
+	if _, err := newTestWallet(t).AccountXPub(P2WPKH); err == nil {
+		t.Fatal("非HD钱包导出扩展公钥应返回错误")
+	}
+}
+
+func TestHDChangeUsesInternalChain(t *testing.T) {
+	h, err := NewHDWalletFromMnemonic(testMnemonic, "", TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 两种硬化标记写法派生的钱包属于同一账户
+	w, err := h.DeriveChild("m/84h/1h/0h/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := h.DeriveChild("m/84'/1'/0'/0/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.hdAccount != "m/84'/1'/0'" || other.hdAccount != w.hdAccount {
+		t.Fatalf("账户路径为%s和%s，期望都为m/84'/1'/0'", w.hdAccount, other.hdAccount)
+	}
+
+	changeScript := func(w *BitcoinWallet) []byte {
+		t.Helper()
+		prepared, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "hd"), Amount: 25000}}, testUTXOs())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prepared.ChangeIndex < 0 {
+			t.Fatal("交易应包含找零输出")
+		}
+		return prepared.Tx.TxOut[prepared.ChangeIndex].PkScript
+	}
+	internalScript := func(path string) []byte {
+		t.Helper()
+		child, err := h.DeriveChild(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		script, _ := child.addressScript(P2WPKH)
+		return script
+	}
+
+	receive, _ := w.addressScript(P2WPKH)
+	got := changeScript(w)
+	if bytes.Equal(got, receive) {
+		t.Fatal("找零不应复用接收地址")
+	}
+	if !bytes.Equal(got, internalScript("m/84'/1'/0'/1/0")) {
+		t.Fatal("找零应使用内部链上的第一个地址m/84'/1'/0'/1/0")
+	}
+
+	// 以另一种写法设置的找零索引对同一账户的所有钱包生效
+	h.SetNextChangeIndex("m/84H/1H/0H", 3)
+	for _, wallet := range []*BitcoinWallet{w, other} {
+		if !bytes.Equal(changeScript(wallet), internalScript("m/84'/1'/0'/1/3")) {
+			t.Fatal("设置找零索引后应使用m/84'/1'/0'/1/3")
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testMnemonic BIP39标准测试向量中的助记词
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// newTestWallet 创建私钥固定为0x01...01的测试网钱包
+func newTestWallet(t *testing.T) *BitcoinWallet {
+	t.Helper()
+
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	wif, err := btcutil.NewWIF(privKey, &chaincfg.TestNet3Params, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWallet(wif.String(), TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// testUTXOs 返回两个金额分别为30000和20000的UTXO，不携带脚本
+func testUTXOs() []UTXO {
+	return []UTXO{
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000},
+	}
+}
+
+// testAddress 返回由seed派生的测试网P2WPKH地址，用作收款方
+func testAddress(t *testing.T, seed string) string {
+	t.Helper()
+
+	addr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160([]byte(seed)), &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr.EncodeAddress()
+}
+
+// verifyTx 使用脚本引擎逐个验证交易输入，scripts和values为各输入的前序输出
+func verifyTx(t *testing.T, tx *wire.MsgTx, scripts [][]byte, values []int64) {
+	t.Helper()
+
+	prevFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range tx.TxIn {
+		prevFetcher.AddPrevOut(txIn.PreviousOutPoint, wire.NewTxOut(values[i], scripts[i]))
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevFetcher)
+
+	for i := range tx.TxIn {
+		vm, err := txscript.NewEngine(
+			scripts[i], tx, i, txscript.StandardVerifyFlags, nil, sigHashes, values[i], prevFetcher,
+		)
+		if err != nil {
+			t.Fatalf("创建输入%d的脚本引擎失败: %v", i, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Fatalf("输入%d验证失败: %v", i, err)
+		}
+	}
+}
+
+// serializeTx 将交易序列化为十六进制
+func serializeTx(t *testing.T, tx *wire.MsgTx) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(buf.Bytes())
+}
+
+// deserializeTx 解析十六进制交易
+func deserializeTx(t *testing.T, txHex string) *wire.MsgTx {
+	t.Helper()
+
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	return tx
+}
+
+// newTestServer 启动模拟的Esplora服务并设为钱包的API地址
+// 所有地址的UTXO查询都返回utxosJSON，广播的交易十六进制传给onBroadcast并返回其交易ID
+func newTestServer(t *testing.T, w *BitcoinWallet, utxosJSON string, onBroadcast func(txHex string)) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/utxo"):
+			rw.Write([]byte(utxosJSON))
+		case r.Method == http.MethodPost && r.URL.Path == "/tx":
+			body, _ := io.ReadAll(r.Body)
+			// 处理函数运行在服务端goroutine中，不能调用t.Fatal，解析失败时报告错误并返回400
+			data, err := hex.DecodeString(string(body))
+			if err != nil {
+				t.Errorf("广播的交易不是有效的十六进制: %v", err)
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			tx := wire.NewMsgTx(wire.TxVersion)
+			if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+				t.Errorf("解析广播的交易失败: %v", err)
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if onBroadcast != nil {
+				onBroadcast(string(body))
+			}
+			rw.Write([]byte(tx.TxHash().String()))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
@@ -0,0 +1,106 @@
+package btc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TxSummary 地址相关交易的摘要
+type TxSummary struct {
+	TxID        string `json:"txid"`
+	Confirmed   bool   `json:"confirmed"`
+	BlockHeight int64  `json:"block_height"` // 未确认时为0
+	NetValue    int64  `json:"net_value"`    // 该地址的净收支，收入为正，支出为负
+}
+
+// esploraTx Esplora返回的交易数据中计算摘要所需的字段
+type esploraTx struct {
+	TxID string `json:"txid"`
+	Vin  []struct {
+		Prevout *struct {
+			ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+			Value               int64  `json:"value"`
+		} `json:"prevout"`
+	} `json:"vin"`
+	Vout []struct {
+		ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+		Value               int64  `json:"value"`
+	} `json:"vout"`
+	Status struct {
+		Confirmed   bool  `json:"confirmed"`
+		BlockHeight int64 `json:"block_height"`
+	} `json:"status"`
+}
+
+// summary 计算交易对指定地址的摘要
+func (tx *esploraTx) summary(address string) TxSummary {
+	var net int64
+	for _, in := range tx.Vin {
+		if in.Prevout != nil && in.Prevout.ScriptPubKeyAddress == address {
+			net -= in.Prevout.Value
+		}
+	}
+	for _, out := range tx.Vout {
+		if out.ScriptPubKeyAddress == address {
+			net += out.Value
+		}
+	}
+
+	return TxSummary{
+		TxID:        tx.TxID,
+		Confirmed:   tx.Status.Confirmed,
+		BlockHeight: tx.Status.BlockHeight,
+		NetValue:    net,
+	}
+}
+
+// TransactionHistory 获取地址的交易历史，按Esplora的last-seen txid方式翻页直到取完已确认交易
+func (b *EsploraBackend) TransactionHistory(ctx context.Context, address string) ([]TxSummary, error) {
+	var history []TxSummary
+	seen := make(map[string]bool)
+
+	// 第一页包含未确认交易和最新的已确认交易，之后按最后一个已确认交易ID继续获取
+	path := fmt.Sprintf("/address/%s/txs", address)
+	for {
+		data, err := b.client.doRequest(ctx, http.MethodGet, path, nil, "请求交易历史")
+		if err != nil {
+			return nil, err
+		}
+
+		var page []esploraTx
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("解析交易历史失败: %w", err)
+		}
+
+		lastConfirmed := ""
+		for i := range page {
+			tx := &page[i]
+			if seen[tx.TxID] {
+				continue
+			}
+			seen[tx.TxID] = true
+			if tx.Status.Confirmed {
+				lastConfirmed = tx.TxID
+			}
+			history = append(history, tx.summary(address))
+		}
+
+		// 没有新的已确认交易说明已经取完
+		if lastConfirmed == "" {
+			return history, nil
+		}
+		path = fmt.Sprintf("/address/%s/txs/chain/%s", address, lastConfirmed)
+	}
+}
+
+// GetTransactionHistory 获取地址的交易历史，未确认交易在前，已确认交易按区块从新到旧排列
+func (w *BitcoinWallet) GetTransactionHistory(address string) ([]TxSummary, error) {
+	return w.GetTransactionHistoryContext(context.Background(), address)
+}
+
+// GetTransactionHistoryContext 获取地址的交易历史，支持通过ctx取消请求
+func (w *BitcoinWallet) GetTransactionHistoryContext(ctx context.Context, address string) ([]TxSummary, error) {
+	return w.backend.TransactionHistory(ctx, address)
+}
@@ -0,0 +1,66 @@
+package btc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestTransactionHistoryPagination(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	// esploraTxJSON 生成向addr支付received、并从addr花费spent的交易
+	esploraTxJSON := func(txID string, height int64, received, spent int64) string {
+		status := `{"confirmed":false}`
+		if height > 0 {
+			status = fmt.Sprintf(`{"confirmed":true,"block_height":%d}`, height)
+		}
+		return fmt.Sprintf(`{"txid":"%s","vin":[{"prevout":{"scriptpubkey_address":"%s","value":%d}}],`+
+			`"vout":[{"scriptpubkey_address":"%s","value":%d},{"scriptpubkey_address":"other","value":700}],"status":%s}`,
+			txID, addr, spent, addr, received, status)
+	}
+
+	pages := map[string]string{
+		"/address/" + addr + "/txs": "[" + esploraTxJSON("u1", 0, 5000, 0) + "," +
+			esploraTxJSON("c1", 300, 0, 3000) + "," + esploraTxJSON("c2", 200, 1000, 0) + "]",
+		"/address/" + addr + "/txs/chain/c2": "[" + esploraTxJSON("c3", 150, 2000, 500) + "," +
+			esploraTxJSON("c4", 100, 4000, 0) + "]",
+		"/address/" + addr + "/txs/chain/c4": "[]",
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Write([]byte(page))
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := w.GetTransactionHistory(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []TxSummary{
+		{TxID: "u1", NetValue: 5000},
+		{TxID: "c1", Confirmed: true, BlockHeight: 300, NetValue: -3000},
+		{TxID: "c2", Confirmed: true, BlockHeight: 200, NetValue: 1000},
+		{TxID: "c3", Confirmed: true, BlockHeight: 150, NetValue: 1500},
+		{TxID: "c4", Confirmed: true, BlockHeight: 100, NetValue: 4000},
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Fatalf("交易历史为%+v，期望%+v", history, want)
+	}
+	if len(requested) != 3 {
+		t.Fatalf("应请求3页，实际请求了%v", requested)
+	}
+}
@@ -0,0 +1,300 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxRetryDelay 单次重试的最大等待时间
+const maxRetryDelay = 30 * time.Second
+
+// newDefaultHTTPClient 创建默认的HTTP客户端
+func newDefaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+// apiClient 区块浏览器HTTP客户端，负责重试和多地址切换
+type apiClient struct {
+	httpClient     *http.Client
+	endpoints      []string      // 按优先级排列的API地址
+	maxRetries     int           // 请求失败时的最大重试次数
+	retryBaseDelay time.Duration // 首次重试前的等待时间
+	limiter        *rateLimiter  // 请求限速器，为nil时不限速
+	observer       Observer      // 请求观察者，为nil时不回调
+	lastEndpoint   string        // 最近一次成功响应请求的API地址
+	mu             sync.Mutex    // 保护lastEndpoint的并发访问
+}
+
+// newAPIClient 使用默认HTTP客户端创建API客户端
+func newAPIClient(apiURL string) *apiClient {
+	return &apiClient{
+		httpClient: newDefaultHTTPClient(),
+		endpoints:  []string{strings.TrimRight(apiURL, "/")},
+	}
+}
+
+// inheritSettings 沿用另一个客户端的HTTP客户端、重试策略、限速和观察者，API地址不变
+func (c *apiClient) inheritSettings(from *apiClient) {
+	if c == from {
+		return
+	}
+	c.httpClient = from.httpClient
+	c.maxRetries = from.maxRetries
+	c.retryBaseDelay = from.retryBaseDelay
+	c.limiter = from.limiter
+	c.observer = from.observer
+}
+
+// SetHTTPClient 设置HTTP客户端，传入nil时恢复默认客户端
+func (w *BitcoinWallet) SetHTTPClient(c *http.Client) {
+	if c == nil {
+		c = newDefaultHTTPClient()
+	}
+	w.client.httpClient = c
+}
+
+// SetRetryPolicy 设置请求失败时的重试策略，仅对网络错误和5xx响应重试
+// maxRetries为0时不重试，重试间隔以baseDelay为基数指数增长并附加随机抖动
+func (w *BitcoinWallet) SetRetryPolicy(maxRetries int, baseDelay time.Duration) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if baseDelay < 0 {
+		baseDelay = 0
+	}
+	w.client.maxRetries = maxRetries
+	w.client.retryBaseDelay = baseDelay
+}
+
+// retryDelay 计算第attempt次重试前的等待时间
+func (c *apiClient) retryDelay(attempt int) time.Duration {
+	if c.retryBaseDelay <= 0 {
+		return 0
+	}
+
+	delay := c.retryBaseDelay << uint(attempt)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	// 附加最多50%的随机抖动，避免多个客户端同时重试
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay + jitter
+}
+
+// sleepContext 等待指定时间，ctx取消时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetAPIEndpoints 设置当前内置后端按优先级排列的API地址列表，请求失败时依次尝试后续地址
+// API地址属于后端，通过SetBackend切换后端后需要重新设置
+func (w *BitcoinWallet) SetAPIEndpoints(endpoints []string) error {
+	if len(endpoints) == 0 {
+		return fmt.Errorf("API地址列表不能为空")
+	}
+
+	cleaned := make([]string, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+		if endpoint == "" {
+			return fmt.Errorf("API地址不能为空")
+		}
+		cleaned = append(cleaned, endpoint)
+	}
+
+	w.client.endpoints = cleaned
+	return nil
+}
+
+// LastEndpoint 返回最近一次成功响应请求的API地址
+func (w *BitcoinWallet) LastEndpoint() string {
+	w.client.mu.Lock()
+	defer w.client.mu.Unlock()
+	return w.client.lastEndpoint
+}
+
+// setLastEndpoint 记录成功响应请求的API地址
+func (c *apiClient) setLastEndpoint(endpoint string) {
+	c.mu.Lock()
+	c.lastEndpoint = endpoint
+	c.mu.Unlock()
+}
+
+// doRequest 依次向各个API地址发送请求，直到某个地址返回非临时性失败的结果
+func (c *apiClient) doRequest(ctx context.Context, method, path string, body []byte, action string) ([]byte, error) {
+	var lastErr error
+	for _, endpoint := range c.endpoints {
+		data, retryable, err := c.doRequestWithRetry(ctx, method, endpoint, path, body, action)
+		if err == nil {
+			c.setLastEndpoint(endpoint)
+			return data, nil
+		}
+
+		// 4xx等确定性错误或ctx取消时不再尝试其他地址
+		if !retryable || ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// doBroadcast 向所有API地址并发发送广播请求以加快传播，返回优先级最高的成功结果
+func (c *apiClient) doBroadcast(ctx context.Context, path string, body []byte, action string) ([]byte, error) {
+	endpoints := c.endpoints
+	if len(endpoints) == 1 {
+		return c.doRequest(ctx, http.MethodPost, path, body, action)
+	}
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	results := make([]result, len(endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			data, _, err := c.doRequestWithRetry(ctx, http.MethodPost, endpoint, path, body, action)
+			results[i] = result{data: data, err: err}
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r.err == nil {
+			c.setLastEndpoint(endpoints[i])
+			return r.data, nil
+		}
+	}
+	return nil, results[0].err
+}
+
+// doRequestWithRetry 向单个地址发送HTTP请求，返回状态码为200时的响应内容，按重试策略重试临时性失败
+func (c *apiClient) doRequestWithRetry(
+	ctx context.Context,
+	method, endpoint, path string,
+	body []byte,
+	action string,
+) ([]byte, bool, error) {
+	for attempt := 0; ; attempt++ {
+		data, retryable, err := c.doRequestOnce(ctx, method, endpoint, path, body, action)
+		if err == nil {
+			return data, false, nil
+		}
+
+		if !retryable || attempt >= c.maxRetries {
+			return nil, retryable, err
+		}
+
+		// 服务端通过Retry-After指定了等待时间时优先遵循
+		delay := c.retryDelay(attempt)
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) && rateErr.RetryAfter > 0 {
+			delay = rateErr.RetryAfter
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// doRequestOnce 向endpoint+path发送一次HTTP请求，并返回失败是否可以重试
+func (c *apiClient) doRequestOnce(
+	ctx context.Context,
+	method, endpoint, path string,
+	body []byte,
+	action string,
+) ([]byte, bool, error) {
+	if c.limiter != nil {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// 耗时不包含限速等待，只统计请求本身
+	start := time.Now()
+	data, statusCode, retryable, err := c.send(ctx, method, endpoint+path, body, action)
+	if c.observer != nil {
+		// 只报告API地址，路径中的地址和交易ID会让监控标签无限增长
+		c.observer.OnRequest(endpoint, time.Since(start), statusCode, err)
+	}
+	return data, retryable, err
+}
+
+// send 发送HTTP请求并读取响应，返回状态码(未收到响应时为0)和失败是否可以重试
+func (c *apiClient) send(ctx context.Context, method, url string, body []byte, action string) ([]byte, int, bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("%s失败: %w", action, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/plain")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// 上下文被取消或超时时直接返回ctx.Err()，便于调用方判断
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, 0, false, ctxErr
+		}
+		return nil, 0, true, fmt.Errorf("%s失败: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, resp.StatusCode, false, ctxErr
+		}
+		return nil, resp.StatusCode, true, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{
+			Action:     action,
+			StatusCode: resp.StatusCode,
+			Endpoint:   url,
+			Body:       string(data),
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return nil, resp.StatusCode, true, &RateLimitError{
+				Action:     action,
+				RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+				apiErr:     apiErr,
+			}
+		}
+		retryable := resp.StatusCode >= http.StatusInternalServerError
+		return nil, resp.StatusCode, retryable, apiErr
+	}
+
+	return data, resp.StatusCode, false, nil
+}
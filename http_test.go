@@ -0,0 +1,203 @@
+package btc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingTransport 记录经过的每个请求URL
+type recordingTransport struct {
+	mu   sync.Mutex
+	urls []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.urls = append(rt.urls, req.URL.String())
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"chain_stats":{"funded_txo_sum":5000,"spent_txo_sum":1200}}`))
+	}))
+	defer srv.Close()
+
+	w := newTestWallet(t)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &recordingTransport{}
+	w.SetHTTPClient(&http.Client{Transport: transport})
+
+	addr, _ := w.GetAddress(P2WPKH)
+	balance, err := w.GetBalance(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 3800 {
+		t.Fatalf("余额应为3800，实际为%d", balance)
+	}
+
+	want := srv.URL + "/address/" + addr
+	if len(transport.urls) != 1 || transport.urls[0] != want {
+		t.Fatalf("记录的请求为%v，期望[%s]", transport.urls, want)
+	}
+
+	// 传入nil时恢复默认客户端
+	w.SetHTTPClient(nil)
+	if w.client.httpClient == nil || w.client.httpClient.Transport == transport {
+		t.Fatal("传入nil后应恢复默认HTTP客户端")
+	}
+}
+
+// balanceBody 余额为3800的Esplora地址响应
+const balanceBody = `{"chain_stats":{"funded_txo_sum":5000,"spent_txo_sum":1200}}`
+
+// statusSequenceServer 依次以codes中的状态码响应请求，之后总是返回200，attempts记录收到的请求数
+func statusSequenceServer(t *testing.T, codes ...int) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		if int(n) <= len(codes) {
+			rw.WriteHeader(codes[n-1])
+			return
+		}
+		rw.Write([]byte(balanceBody))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &attempts
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetRetryPolicy(3, time.Millisecond)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	// 503后重试成功
+	srv, attempts := statusSequenceServer(t, http.StatusServiceUnavailable)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := w.GetBalance(addr)
+	if err != nil || balance != 3800 {
+		t.Fatalf("重试后应获取到余额3800，实际为%d, %v", balance, err)
+	}
+	if *attempts != 2 {
+		t.Fatalf("应请求2次，实际为%d次", *attempts)
+	}
+
+	// 4xx不是临时性失败，不重试
+	srv, attempts = statusSequenceServer(t, http.StatusBadRequest)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	var apiErr *APIError
+	if _, err := w.GetBalance(addr); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("400应返回APIError，实际为%v", err)
+	}
+	if *attempts != 1 {
+		t.Fatalf("400不应重试，实际请求了%d次", *attempts)
+	}
+
+	// 超过最大重试次数后返回最后一次的错误
+	srv, attempts = statusSequenceServer(t, 502, 502, 502, 502, 502)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.GetBalance(addr); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("重试耗尽后应返回502的APIError，实际为%v", err)
+	}
+	if *attempts != 4 {
+		t.Fatalf("最多重试3次应共请求4次，实际为%d次", *attempts)
+	}
+}
+
+func TestEndpointFallback(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetRetryPolicy(0, 0)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	failing, failingAttempts := statusSequenceServer(t, http.StatusInternalServerError)
+	healthy, healthyAttempts := statusSequenceServer(t)
+	if err := w.SetAPIEndpoints([]string{failing.URL, healthy.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	balance, err := w.GetBalance(addr)
+	if err != nil || balance != 3800 {
+		t.Fatalf("第一个地址返回500时应由第二个地址响应，实际为%d, %v", balance, err)
+	}
+	if *failingAttempts != 1 || *healthyAttempts != 1 {
+		t.Fatalf("两个地址应各请求1次，实际为%d和%d", *failingAttempts, *healthyAttempts)
+	}
+	if w.LastEndpoint() != healthy.URL {
+		t.Fatalf("最近成功的地址为%s，期望%s", w.LastEndpoint(), healthy.URL)
+	}
+
+	// 4xx是确定性错误，不切换到其他地址
+	rejecting, _ := statusSequenceServer(t, http.StatusNotFound)
+	healthy, healthyAttempts = statusSequenceServer(t)
+	if err := w.SetAPIEndpoints([]string{rejecting.URL, healthy.URL}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.GetBalance(addr); err == nil {
+		t.Fatal("第一个地址返回404时应直接返回错误")
+	}
+	if *healthyAttempts != 0 {
+		t.Fatal("404不应切换到后续地址")
+	}
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	const rejection = "sendrawtransaction RPC error: {\"code\":-26,\"message\":\"min relay fee not met\"}"
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tx":
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte(rejection))
+		default:
+			rw.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	w := newTestWallet(t)
+	w.SetRetryPolicy(0, 0)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	prepared, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "apierr"), Amount: 25000}}, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = w.Commit(prepared)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("广播被拒绝时应返回APIError，实际为%v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Body != rejection || apiErr.Endpoint != srv.URL+"/tx" {
+		t.Fatalf("APIError为%+v，期望400、原始响应内容和请求地址", apiErr)
+	}
+
+	// 响应内容为空时错误信息使用状态码描述
+	addr, _ := w.GetAddress(P2WPKH)
+	_, err = w.GetBalance(addr)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Body != "" {
+		t.Fatalf("503应返回空响应内容的APIError，实际为%v", err)
+	}
+	if !strings.Contains(err.Error(), "503 Service Unavailable") {
+		t.Fatalf("错误信息应包含状态码描述，实际为%s", err)
+	}
+}
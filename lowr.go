@@ -0,0 +1,73 @@
+package btc
+
+import (
+	"encoding/binary"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// SetLowR 设置ECDSA签名是否进行low-R grinding
+// 启用后与Bitcoin Core一致，不断追加递增的额外熵重新签名直到R值小于2^255，
+// 使DER编码的R不需要补零字节，每个输入的签名缩短约1字节
+func (w *BitcoinWallet) SetLowR(enabled bool) {
+	w.lowR = enabled
+}
+
+// ecdsaSign 使用RFC6979确定性随机数签名，签名始终为low-S
+func (w *BitcoinWallet) ecdsaSign(hash []byte) *ecdsa.Signature {
+	sig := ecdsa.Sign(w.privateKey, hash)
+	if !w.lowR {
+		return sig
+	}
+
+	// 额外熵为32字节小端计数器，与Bitcoin Core的实现相同
+	var extra [32]byte
+	for counter := uint32(1); !isLowR(sig); counter++ {
+		binary.LittleEndian.PutUint32(extra[:], counter)
+		sig = signWithExtraEntropy(w.privateKey, hash, extra[:])
+	}
+	return sig
+}
+
+// isLowR 判断签名的R值最高位是否为0
+func isLowR(sig *ecdsa.Signature) bool {
+	r := sig.R()
+	return r.Bytes()[0] < 0x80
+}
+
+// signWithExtraEntropy 使用附加额外熵的RFC6979随机数生成low-S签名
+func signWithExtraEntropy(privKey *btcec.PrivateKey, hash, extra []byte) *ecdsa.Signature {
+	privKeyBytes := privKey.Key.Bytes()
+
+	var e btcec.ModNScalar
+	e.SetByteSlice(hash)
+
+	for iteration := uint32(0); ; iteration++ {
+		k := btcec.NonceRFC6979(privKeyBytes[:], hash, extra, nil, iteration)
+
+		// R = k*G, r = R.x mod N
+		var point btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(k, &point)
+		point.ToAffine()
+
+		var r btcec.ModNScalar
+		r.SetBytes(point.X.Bytes())
+		if r.IsZero() {
+			continue
+		}
+
+		// s = k^-1 * (e + d*r) mod N
+		var kInv btcec.ModNScalar
+		kInv.InverseValNonConst(k)
+		s := new(btcec.ModNScalar).Mul2(&privKey.Key, &r).Add(&e).Mul(&kInv)
+		if s.IsZero() {
+			continue
+		}
+
+		if s.IsOverHalfOrder() {
+			s.Negate()
+		}
+		return ecdsa.NewSignature(&r, s)
+	}
+}
@@ -0,0 +1,47 @@
+package btc
+
+import (
+	"testing"
+)
+
+func TestLowRSignatureSize(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+	utxos := testUTXOs()
+	for i := range utxos {
+		utxos[i].PkScript = script
+	}
+
+	// 不同的支付金额产生不同的签名哈希，约一半的普通签名R值需要补零
+	maxSigLen := func() int {
+		longest := 0
+		for amount := int64(25000); amount < 25040; amount++ {
+			prepared, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "lowr"), Amount: amount}}, utxos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, txIn := range prepared.Tx.TxIn {
+				longest = max(longest, len(txIn.Witness[0]))
+			}
+		}
+		return longest
+	}
+	if maxSigLen() != 72 {
+		t.Fatal("未启用low-R时应出现72字节的签名")
+	}
+
+	w.SetLowR(true)
+	for amount := int64(25000); amount < 25040; amount++ {
+		prepared, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "lowr"), Amount: amount}}, utxos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, txIn := range prepared.Tx.TxIn {
+			// DER签名加1字节签名哈希类型
+			if sig := txIn.Witness[0]; len(sig) > 71 {
+				t.Fatalf("金额%d的输入%d签名长度为%d字节，超过71", amount, i, len(sig))
+			}
+		}
+		verifyTx(t, prepared.Tx, [][]byte{script, script}, []int64{30000, 20000})
+	}
+}
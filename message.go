@@ -0,0 +1,112 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// messageMagic Bitcoin签名消息的前缀
+const messageMagic = "Bitcoin Signed Message:\n"
+
+// messageHash 计算带前缀消息的双SHA256哈希
+func messageHash(message string) []byte {
+	var buf bytes.Buffer
+	_ = wire.WriteVarString(&buf, 0, messageMagic)
+	_ = wire.WriteVarString(&buf, 0, message)
+	return chainhash.DoubleHashB(buf.Bytes())
+}
+
+// SignMessage 使用钱包私钥签名消息，返回base64编码的compact签名(对应P2PKH地址)
+func (w *BitcoinWallet) SignMessage(message string) (string, error) {
+	// 钱包地址总是由压缩公钥生成，签名头也必须标记为压缩，否则验证方恢复出的是未压缩公钥的地址
+	sig := ecdsa.SignCompact(w.privateKey, messageHash(message), true)
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyMessage 验证消息签名是否由指定地址的私钥生成
+// 支持P2PKH地址，以及BIP137格式的P2SH-P2WPKH和P2WPKH地址签名
+func (w *BitcoinWallet) VerifyMessage(address, message, signature string) (bool, error) {
+	addr, err := w.decodeAndValidateAddress(address)
+	if err != nil {
+		return false, err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false, fmt.Errorf("解码签名失败: %w", err)
+	}
+
+	if len(sig) != 65 {
+		return false, fmt.Errorf("签名长度无效: %d", len(sig))
+	}
+
+	// BIP137: 27-30未压缩P2PKH，31-34压缩P2PKH，35-38 P2SH-P2WPKH，39-42 P2WPKH
+	header := sig[0]
+	if header < 27 || header > 42 {
+		return false, fmt.Errorf("签名头无效: %d", header)
+	}
+
+	compact := append([]byte(nil), sig...)
+	if header >= 35 {
+		compact[0] = 27 + (header-27)%4 + 4
+	}
+
+	pubKey, compressed, err := ecdsa.RecoverCompact(compact, messageHash(message))
+	if err != nil {
+		return false, fmt.Errorf("恢复公钥失败: %w", err)
+	}
+
+	recovered, err := w.messageSignerAddress(pubKey, compressed, addr)
+	if err != nil {
+		return false, err
+	}
+
+	return recovered == addr.EncodeAddress(), nil
+}
+
+// messageSignerAddress 按目标地址的类型从恢复出的公钥生成地址
+func (w *BitcoinWallet) messageSignerAddress(pubKey *btcec.PublicKey, compressed bool, target btcutil.Address) (string, error) {
+	serialized := pubKey.SerializeUncompressed()
+	if compressed {
+		serialized = pubKey.SerializeCompressed()
+	}
+	pubKeyHash := btcutil.Hash160(serialized)
+
+	switch target.(type) {
+	case *btcutil.AddressPubKeyHash:
+		addr, err := btcutil.NewAddressPubKeyHash(pubKeyHash, w.network)
+		if err != nil {
+			return "", err
+		}
+		return addr.EncodeAddress(), nil
+	case *btcutil.AddressWitnessPubKeyHash:
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, w.network)
+		if err != nil {
+			return "", err
+		}
+		return addr.EncodeAddress(), nil
+	case *btcutil.AddressScriptHash:
+		redeemScript, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_0).
+			AddData(pubKeyHash).
+			Script()
+		if err != nil {
+			return "", err
+		}
+		addr, err := btcutil.NewAddressScriptHash(redeemScript, w.network)
+		if err != nil {
+			return "", err
+		}
+		return addr.EncodeAddress(), nil
+	default:
+		return "", fmt.Errorf("不支持验证该类型地址的消息签名")
+	}
+}
@@ -0,0 +1,63 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// 已知的签名消息向量，签名与Bitcoin Core的signmessagewithprivkey结果一致
+const (
+	knownMessageWIF       = "L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1"
+	knownMessageAddress   = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+	knownMessage          = "This is an example of a signed message."
+	knownMessageSignature = "H9L5yLFjti0QTHhPyFrZCT1V/MMnBtXKmoiKDZ78NDBjERki6ZTQZdSMCtkgoNmp17By9ItJr8o7ChX0XxY91nk="
+)
+
+func TestSignMessageKnownVector(t *testing.T) {
+	w, err := NewWallet(knownMessageWIF, MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := w.SignMessage(knownMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig != knownMessageSignature {
+		t.Fatalf("签名为%s，期望%s", sig, knownMessageSignature)
+	}
+
+	ok, err := w.VerifyMessage(knownMessageAddress, knownMessage, knownMessageSignature)
+	if err != nil || !ok {
+		t.Fatalf("已知签名验证失败: %v", err)
+	}
+
+	ok, err = w.VerifyMessage(knownMessageAddress, knownMessage+"!", knownMessageSignature)
+	if err != nil || ok {
+		t.Fatal("修改后的消息不应通过验证")
+	}
+}
+
+func TestSignMessageUncompressedWIF(t *testing.T) {
+	privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	wif, _ := btcutil.NewWIF(privKey, &chaincfg.TestNet3Params, false)
+	w, err := NewWallet(wif.String(), TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 地址总是使用压缩公钥，签名必须与之一致
+	sig, err := w.SignMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := w.GetAddress(P2PKH)
+	ok, err := w.VerifyMessage(addr, "hello", sig)
+	if err != nil || !ok {
+		t.Fatalf("非压缩WIF的钱包签名应能用自身地址验证: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package btc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// purposeForAddressType 获取地址类型对应的BIP44/49/84/86 purpose
+func purposeForAddressType(addrType AddressType) (uint32, error) {
+	switch addrType {
+	case P2PKH:
+		return 44, nil
+	case P2SH:
+		return 49, nil
+	case P2WPKH:
+		return 84, nil
+	case P2TR:
+		return 86, nil
+	default:
+		return 0, fmt.Errorf("不支持的地址类型: %s", addrType)
+	}
+}
+
+// coinType 获取网络对应的BIP44 coin type
+func coinType(netParams *chaincfg.Params) uint32 {
+	if netParams.Net == chaincfg.MainNetParams.Net {
+		return 0
+	}
+	return 1
+}
+
+// NewWalletFromMnemonic 通过BIP39助记词创建钱包，使用BIP84路径 m/84'/coin'/0'/0/0 派生私钥
+func NewWalletFromMnemonic(mnemonic, passphrase string, network Network) (*BitcoinWallet, error) {
+	return NewWalletFromMnemonicWithType(mnemonic, passphrase, network, P2WPKH)
+}
+
+// NewWalletFromMnemonicWithType 通过BIP39助记词创建钱包，按地址类型选择BIP44/49/84/86路径派生首个接收地址的私钥
+func NewWalletFromMnemonicWithType(mnemonic, passphrase string, network Network, addrType AddressType) (*BitcoinWallet, error) {
+	purpose, err := purposeForAddressType(addrType)
+	if err != nil {
+		return nil, err
+	}
+
+	hd, err := NewHDWalletFromMnemonic(mnemonic, passphrase, network)
+	if err != nil {
+		return nil, err
+	}
+
+	return hd.DeriveChild(fmt.Sprintf("m/%d'/%d'/0'/0/0", purpose, coinType(hd.network)))
+}
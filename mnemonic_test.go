@@ -0,0 +1,38 @@
+package btc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMnemonicSeedVector(t *testing.T) {
+	// BIP39测试向量：口令为TREZOR时的种子对应的BIP32主私钥
+	const wantMaster = "xprv9s21ZrQH143K3h3fDYiay8mocZ3afhfULfb5GX8kCBdno77K4HiA15Tg23wpbeF1pLfs1c5SPmYHrEpTuuRhxMwvKDwqdKiGJS9XFKzUsAF"
+
+	h, err := NewHDWalletFromMnemonic(testMnemonic, "TREZOR", MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := h.master.String(); got != wantMaster {
+		t.Fatalf("主私钥为%s，期望%s", got, wantMaster)
+	}
+
+	// 多余的空白不影响种子
+	spaced, err := NewHDWalletFromMnemonic("  "+strings.ReplaceAll(testMnemonic, " ", "\n  ")+"\t", "TREZOR", MainNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spaced.master.String(); got != wantMaster {
+		t.Fatalf("含多余空白时主私钥为%s，期望%s", got, wantMaster)
+	}
+}
+
+func TestMnemonicInvalidChecksum(t *testing.T) {
+	invalid := strings.Repeat("abandon ", 11) + "abandon"
+	if _, err := NewHDWalletFromMnemonic(invalid, "", MainNet); err == nil {
+		t.Fatal("校验和错误的助记词应返回错误")
+	}
+	if _, err := NewWalletFromMnemonic("abandon abandon notaword", "", MainNet); err == nil {
+		t.Fatal("不在词表中的助记词应返回错误")
+	}
+}
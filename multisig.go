@@ -0,0 +1,353 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// maxMultisigKeys 标准多签脚本允许的最大公钥数
+const maxMultisigKeys = 16
+
+// MultisigRedeemScript 按BIP67对压缩公钥排序后生成m-of-n多签赎回脚本
+func MultisigRedeemScript(pubKeys [][]byte, m int) ([]byte, error) {
+	n := len(pubKeys)
+	if n == 0 || n > maxMultisigKeys {
+		return nil, fmt.Errorf("公钥数量必须在1到%d之间", maxMultisigKeys)
+	}
+	if m <= 0 || m > n {
+		return nil, fmt.Errorf("所需签名数%d无效，应在1到%d之间", m, n)
+	}
+
+	sorted := make([][]byte, n)
+	for i, pubKey := range pubKeys {
+		key, err := btcec.ParsePubKey(pubKey)
+		if err != nil {
+			return nil, fmt.Errorf("解析公钥%d失败: %w", i, err)
+		}
+		sorted[i] = key.SerializeCompressed()
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
+
+	for i := 1; i < n; i++ {
+		if bytes.Equal(sorted[i-1], sorted[i]) {
+			return nil, fmt.Errorf("存在重复的公钥")
+		}
+	}
+
+	builder := txscript.NewScriptBuilder().AddInt64(int64(m))
+	for _, pubKey := range sorted {
+		builder.AddData(pubKey)
+	}
+	script, err := builder.AddInt64(int64(n)).AddOp(txscript.OP_CHECKMULTISIG).Script()
+	if err != nil {
+		return nil, fmt.Errorf("构建多签脚本失败: %w", err)
+	}
+
+	return script, nil
+}
+
+// p2wshScript 生成赎回脚本对应的P2WSH输出脚本
+func p2wshScript(redeemScript []byte) ([]byte, error) {
+	hash := sha256.Sum256(redeemScript)
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(hash[:]).Script()
+}
+
+// MultisigWallet m-of-n多签钱包，持有全部或部分参与方的私钥
+type MultisigWallet struct {
+	signers      []*BitcoinWallet // 持有私钥的参与方
+	required     int              // 所需签名数
+	redeemScript []byte
+	network      *chaincfg.Params
+}
+
+// NewMultisigWallet 使用参与方的WIF私钥创建m-of-n多签钱包，公钥按BIP67排序
+func NewMultisigWallet(wifs []string, m int, network Network) (*MultisigWallet, error) {
+	signers := make([]*BitcoinWallet, len(wifs))
+	pubKeys := make([][]byte, len(wifs))
+
+	for i, wif := range wifs {
+		signer, err := NewWallet(wif, network)
+		if err != nil {
+			return nil, fmt.Errorf("私钥%d无效: %w", i, err)
+		}
+		if !signer.compressed {
+			return nil, fmt.Errorf("私钥%d必须使用压缩公钥", i)
+		}
+		signers[i] = signer
+		pubKeys[i] = signer.publicKey.SerializeCompressed()
+	}
+
+	redeemScript, err := MultisigRedeemScript(pubKeys, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultisigWallet{
+		signers:      signers,
+		required:     m,
+		redeemScript: redeemScript,
+		network:      signers[0].network,
+	}, nil
+}
+
+// RedeemScript 返回多签赎回脚本(见证脚本)
+func (mw *MultisigWallet) RedeemScript() []byte {
+	return mw.redeemScript
+}
+
+// GetAddress 获取原生P2WSH多签地址
+func (mw *MultisigWallet) GetAddress() (string, error) {
+	hash := sha256.Sum256(mw.redeemScript)
+	addr, err := btcutil.NewAddressWitnessScriptHash(hash[:], mw.network)
+	if err != nil {
+		return "", fmt.Errorf("生成P2WSH地址失败: %w", err)
+	}
+	return addr.String(), nil
+}
+
+// GetNestedAddress 获取嵌套在P2SH中的P2SH-P2WSH多签地址
+func (mw *MultisigWallet) GetNestedAddress() (string, error) {
+	witnessProgram, err := p2wshScript(mw.redeemScript)
+	if err != nil {
+		return "", fmt.Errorf("创建见证程序失败: %w", err)
+	}
+
+	addr, err := btcutil.NewAddressScriptHash(witnessProgram, mw.network)
+	if err != nil {
+		return "", fmt.Errorf("生成P2SH-P2WSH地址失败: %w", err)
+	}
+	return addr.String(), nil
+}
+
+// Sign 使用持有的私钥为输入添加签名，最多添加到所需签名数为止
+func (mw *MultisigWallet) Sign(tx *wire.MsgTx, idx int, value int64, nested bool) error {
+	for _, signer := range mw.signers {
+		sigs, err := multisigWitnessSignatures(tx, idx, mw.redeemScript)
+		if err != nil {
+			return err
+		}
+		if len(sigs) >= mw.required {
+			return nil
+		}
+
+		if nested {
+			err = signer.SignMultisigNested(tx, idx, value, mw.redeemScript)
+		} else {
+			err = signer.SignMultisig(tx, idx, value, mw.redeemScript)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SignMultisig 为P2WSH多签输入添加本钱包的签名
+// 已有的签名会被保留并按公钥顺序排列，其余参与方可以继续在同一交易上签名
+func (w *BitcoinWallet) SignMultisig(tx *wire.MsgTx, idx int, value int64, redeemScript []byte) error {
+	return w.signMultisig(tx, idx, value, redeemScript, false)
+}
+
+// SignMultisigNested 为P2SH-P2WSH多签输入添加本钱包的签名并设置SignatureScript
+func (w *BitcoinWallet) SignMultisigNested(tx *wire.MsgTx, idx int, value int64, redeemScript []byte) error {
+	return w.signMultisig(tx, idx, value, redeemScript, true)
+}
+
+// signMultisig 生成多签签名并与输入中已有的签名合并
+func (w *BitcoinWallet) signMultisig(tx *wire.MsgTx, idx int, value int64, redeemScript []byte, nested bool) error {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return fmt.Errorf("输入索引%d超出范围", idx)
+	}
+
+	pubKeys, _, err := multisigPubKeys(redeemScript)
+	if err != nil {
+		return err
+	}
+
+	own := w.publicKey.SerializeCompressed()
+	found := false
+	for _, pubKey := range pubKeys {
+		if bytes.Equal(pubKey, own) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("本钱包公钥不在多签脚本中")
+	}
+
+	witnessProgram, err := p2wshScript(redeemScript)
+	if err != nil {
+		return fmt.Errorf("创建见证程序失败: %w", err)
+	}
+
+	sig, err := w.witnessV0Signature(tx, idx, value, witnessProgram, redeemScript, txscript.SigHashAll)
+	if err != nil {
+		return err
+	}
+
+	sigs, err := multisigWitnessSignatures(tx, idx, redeemScript)
+	if err != nil {
+		return err
+	}
+
+	if err := setMultisigWitness(tx, idx, value, redeemScript, append(sigs, sig)); err != nil {
+		return err
+	}
+
+	if nested {
+		tx.TxIn[idx].SignatureScript, err = txscript.NewScriptBuilder().AddData(witnessProgram).Script()
+		if err != nil {
+			return fmt.Errorf("构建签名脚本失败: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CombineMultisigSignatures 将多个参与方分别签名的交易中的多签签名合并到tx的第idx个输入
+func CombineMultisigSignatures(tx *wire.MsgTx, idx int, value int64, redeemScript []byte, partials ...*wire.MsgTx) error {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return fmt.Errorf("输入索引%d超出范围", idx)
+	}
+
+	sigs, err := multisigWitnessSignatures(tx, idx, redeemScript)
+	if err != nil {
+		return err
+	}
+
+	for i, partial := range partials {
+		if idx >= len(partial.TxIn) || partial.TxIn[idx].PreviousOutPoint != tx.TxIn[idx].PreviousOutPoint {
+			return fmt.Errorf("部分签名交易%d与目标交易的输入不一致", i)
+		}
+
+		partialSigs, err := multisigWitnessSignatures(partial, idx, redeemScript)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, partialSigs...)
+
+		if len(tx.TxIn[idx].SignatureScript) == 0 {
+			tx.TxIn[idx].SignatureScript = partial.TxIn[idx].SignatureScript
+		}
+	}
+
+	return setMultisigWitness(tx, idx, value, redeemScript, sigs)
+}
+
+// multisigPubKeys 解析多签赎回脚本中的公钥和所需签名数
+func multisigPubKeys(redeemScript []byte) ([][]byte, int, error) {
+	if txscript.GetScriptClass(redeemScript) != txscript.MultiSigTy {
+		return nil, 0, fmt.Errorf("不是多签赎回脚本")
+	}
+
+	// 公钥地址与网络无关，使用主网参数解析即可
+	_, addrs, required, err := txscript.ExtractPkScriptAddrs(redeemScript, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解析多签脚本失败: %w", err)
+	}
+
+	pubKeys := make([][]byte, len(addrs))
+	for i, addr := range addrs {
+		pubKeyAddr, ok := addr.(*btcutil.AddressPubKey)
+		if !ok {
+			return nil, 0, fmt.Errorf("解析多签脚本公钥%d失败", i)
+		}
+		pubKeys[i] = pubKeyAddr.PubKey().SerializeCompressed()
+	}
+
+	return pubKeys, required, nil
+}
+
+// multisigWitnessSignatures 提取输入witness中已有的多签签名
+func multisigWitnessSignatures(tx *wire.MsgTx, idx int, redeemScript []byte) ([][]byte, error) {
+	witness := tx.TxIn[idx].Witness
+	if len(witness) == 0 {
+		return nil, nil
+	}
+
+	// 多签witness格式: [空元素, 签名..., 赎回脚本]
+	if len(witness) < 2 || !bytes.Equal(witness[len(witness)-1], redeemScript) {
+		return nil, fmt.Errorf("输入%d的witness不是该多签脚本的签名", idx)
+	}
+
+	var sigs [][]byte
+	for _, item := range witness[1 : len(witness)-1] {
+		if len(item) > 0 {
+			sigs = append(sigs, item)
+		}
+	}
+	return sigs, nil
+}
+
+// setMultisigWitness 按公钥顺序排列签名并写入witness，去除重复和无效签名，最多保留所需签名数
+func setMultisigWitness(tx *wire.MsgTx, idx int, value int64, redeemScript []byte, sigs [][]byte) error {
+	pubKeys, required, err := multisigPubKeys(redeemScript)
+	if err != nil {
+		return err
+	}
+
+	witnessProgram, err := p2wshScript(redeemScript)
+	if err != nil {
+		return fmt.Errorf("创建见证程序失败: %w", err)
+	}
+
+	prevFetcher := txscript.NewCannedPrevOutputFetcher(witnessProgram, value)
+	sigHashes := txscript.NewTxSigHashes(tx, prevFetcher)
+
+	// OP_CHECKMULTISIG要求签名顺序与公钥顺序一致
+	byKey := make([][]byte, len(pubKeys))
+	for _, sig := range sigs {
+		if len(sig) < 2 {
+			continue
+		}
+
+		// 对方提供的签名同样按本钱包签名的规则检查sighash类型，不承诺输出的SINGLE签名视为无效
+		hashType := txscript.SigHashType(sig[len(sig)-1])
+		if validateECDSASigHash(tx, idx, hashType) != nil {
+			continue
+		}
+		sigHash, err := txscript.CalcWitnessSigHash(redeemScript, sigHashes, hashType, tx, idx, value)
+		if err != nil {
+			return fmt.Errorf("计算witness签名哈希失败: %w", err)
+		}
+
+		parsed, err := ecdsa.ParseDERSignature(sig[:len(sig)-1])
+		if err != nil {
+			continue
+		}
+
+		for i, pubKey := range pubKeys {
+			key, err := btcec.ParsePubKey(pubKey)
+			if err != nil {
+				return fmt.Errorf("解析公钥失败: %w", err)
+			}
+			if parsed.Verify(sigHash, key) {
+				byKey[i] = sig
+				break
+			}
+		}
+	}
+
+	witness := wire.TxWitness{nil}
+	for _, sig := range byKey {
+		if sig != nil && len(witness)-1 < required {
+			witness = append(witness, sig)
+		}
+	}
+	witness = append(witness, redeemScript)
+
+	tx.TxIn[idx].Witness = witness
+	return nil
+}
@@ -0,0 +1,121 @@
+package btc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testMultisigWIFs 返回私钥分别为0x01...01、0x02...02、0x03...03的测试网WIF
+func testMultisigWIFs(t *testing.T) []string {
+	t.Helper()
+
+	wifs := make([]string, 3)
+	for i := range wifs {
+		privKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{byte(i + 1)}, 32))
+		wif, err := btcutil.NewWIF(privKey, &chaincfg.TestNet3Params, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wifs[i] = wif.String()
+	}
+	return wifs
+}
+
+func TestMultisig2of3Spend(t *testing.T) {
+	wifs := testMultisigWIFs(t)
+	mw, err := NewMultisigWallet(wifs, 2, TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 各参与方单独持有自己的私钥
+	signers := make([]*BitcoinWallet, len(wifs))
+	for i, wif := range wifs {
+		if signers[i], err = NewWallet(wif, TestNet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const value = 50000
+	for _, nested := range []bool{false, true} {
+		name := "P2WSH"
+		address := mw.GetAddress
+		if nested {
+			name, address = "P2SH-P2WSH", mw.GetNestedAddress
+		}
+		t.Run(name, func(t *testing.T) {
+			addr, err := address()
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := btcutil.DecodeAddress(addr, &chaincfg.TestNet3Params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			prevScript, _ := txscript.PayToAddrScript(decoded)
+
+			tx := wire.NewMsgTx(2)
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{5}, 0), nil, nil))
+			tx.AddTxOut(wire.NewTxOut(value-1000, prevScript))
+
+			sign := func(signer *BitcoinWallet) {
+				t.Helper()
+				var err error
+				if nested {
+					err = signer.SignMultisigNested(tx, 0, value, mw.RedeemScript())
+				} else {
+					err = signer.SignMultisig(tx, 0, value, mw.RedeemScript())
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// 只有一个签名时脚本验证失败
+			sign(signers[2])
+			utxos := []UTXO{{TxID: tx.TxIn[0].PreviousOutPoint.Hash.String(), Value: value, PkScript: prevScript}}
+			if err := signers[2].VerifyTransaction(tx, utxos, P2WPKH); err == nil {
+				t.Fatal("只有1个签名的2-of-3输入不应通过验证")
+			}
+
+			// 第二个参与方在同一交易上追加签名后通过验证，签名顺序与公钥顺序无关
+			sign(signers[0])
+			verifyTx(t, tx, [][]byte{prevScript}, []int64{value})
+
+			// 分别签名的两份交易合并后同样有效
+			partial1, partial2 := tx.Copy(), tx.Copy()
+			for _, partial := range []*wire.MsgTx{partial1, partial2} {
+				partial.TxIn[0].Witness = nil
+				partial.TxIn[0].SignatureScript = nil
+			}
+			tx = partial1
+			sign(signers[1])
+			tx = partial2
+			sign(signers[2])
+
+			combined := partial1.Copy()
+			combined.TxIn[0].Witness = nil
+			combined.TxIn[0].SignatureScript = nil
+			if err := CombineMultisigSignatures(combined, 0, value, mw.RedeemScript(), partial1, partial2); err != nil {
+				t.Fatal(err)
+			}
+			verifyTx(t, combined, [][]byte{prevScript}, []int64{value})
+
+			// 持有全部私钥的多签钱包只添加所需数量的签名
+			tx = combined.Copy()
+			tx.TxIn[0].Witness = nil
+			tx.TxIn[0].SignatureScript = nil
+			if err := mw.Sign(tx, 0, value, nested); err != nil {
+				t.Fatal(err)
+			}
+			verifyTx(t, tx, [][]byte{prevScript}, []int64{value})
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package btc
+
+import (
+	"fmt"
+	"time"
+)
+
+// Observer 请求观察者，用于接入日志或监控指标(如Prometheus)
+// 每次HTTP请求完成后回调一次，重试时每次尝试分别回调；endpoint为发出请求的API地址，不含请求路径，
+// 可直接作为监控标签。未收到响应(如网络错误、超时)时statusCode为0。回调在请求所在的goroutine中同步执行，应尽快返回
+type Observer interface {
+	OnRequest(endpoint string, dur time.Duration, statusCode int, err error)
+}
+
+// observableBackend 自行发送请求并支持设置观察者的后端，如CoreRPCBackend
+type observableBackend interface {
+	SetObserver(o Observer)
+}
+
+// SetObserver 设置请求观察者，传入nil时取消回调
+// 内置后端的观察者在SetBackend切换内置后端时保留；实现了SetObserver的自定义后端(如CoreRPCBackend)
+// 会转交给后端，其他自定义后端不经过钱包发送请求，无法观察，此时返回错误
+func (w *BitcoinWallet) SetObserver(o Observer) error {
+	if _, ok := w.backend.(httpBackend); !ok {
+		ob, ok := w.backend.(observableBackend)
+		if !ok {
+			return fmt.Errorf("当前后端%T不支持请求观察者", w.backend)
+		}
+		ob.SetObserver(o)
+	}
+
+	w.client.observer = o
+	return nil
+}
@@ -0,0 +1,68 @@
+package btc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	endpoints []string
+	codes     []int
+}
+
+func (o *recordingObserver) OnRequest(endpoint string, dur time.Duration, statusCode int, err error) {
+	o.endpoints = append(o.endpoints, endpoint)
+	o.codes = append(o.codes, statusCode)
+}
+
+// customBackend 不支持设置观察者的自定义后端
+type customBackend struct{ Backend }
+
+func TestObserverGetBalance(t *testing.T) {
+	w := newTestWallet(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"chain_stats":{"funded_txo_sum":5000,"spent_txo_sum":1000},"mempool_stats":{"funded_txo_sum":0,"spent_txo_sum":0}}`))
+	}))
+	t.Cleanup(srv.Close)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	observer := &recordingObserver{}
+	if err := w.SetObserver(observer); err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := w.GetAddress(P2WPKH)
+	if _, err := w.GetBalance(addr); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(observer.endpoints) != 1 {
+		t.Fatalf("GetBalance应回调1次，实际%d次", len(observer.endpoints))
+	}
+	// endpoint为API地址，不含地址等请求路径
+	if observer.endpoints[0] != srv.URL || observer.codes[0] != http.StatusOK {
+		t.Fatalf("回调参数为%s %d，期望%s 200", observer.endpoints[0], observer.codes[0], srv.URL)
+	}
+}
+
+func TestObserverForwardedToBackend(t *testing.T) {
+	w := newTestWallet(t)
+	observer := &recordingObserver{}
+	if err := w.SetObserver(observer); err != nil {
+		t.Fatal(err)
+	}
+
+	rpc := NewCoreRPCBackend("127.0.0.1", 8332, "user", "pass")
+	w.SetBackend(rpc)
+	if rpc.observer != observer {
+		t.Fatal("切换后端时应把已设置的观察者交给CoreRPCBackend")
+	}
+
+	w.SetBackend(customBackend{})
+	if err := w.SetObserver(observer); err == nil {
+		t.Fatal("后端不支持观察者时应返回错误")
+	}
+}
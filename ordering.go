@@ -0,0 +1,82 @@
+package btc
+
+import (
+	"github.com/btcsuite/btcd/btcutil/txsort"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// OutputOrdering 交易输入输出的排序策略
+type OutputOrdering string
+
+const (
+	Preserve    OutputOrdering = "preserve" // 按传入顺序排列支付输出，找零在最后(默认)
+	BIP69Sorted OutputOrdering = "bip69"    // 按BIP69对输入和输出进行字典序排序
+	Shuffled    OutputOrdering = "shuffled" // 随机打乱输出顺序，找零不再固定在最后
+)
+
+// SetOutputOrdering 设置构建交易时输入输出的排序策略
+// 默认找零总在最后一个输出，容易被链上分析识别，BIP69Sorted和Shuffled可以隐藏找零位置
+func (w *BitcoinWallet) SetOutputOrdering(ordering OutputOrdering) {
+	w.outputOrdering = ordering
+}
+
+// GetOutputOrdering 获取输入输出的排序策略
+func (w *BitcoinWallet) GetOutputOrdering() OutputOrdering {
+	if w.outputOrdering == "" {
+		return Preserve
+	}
+	return w.outputOrdering
+}
+
+// orderTransaction 按排序策略重排未签名交易，返回与新输入顺序对应的UTXO和找零输出的新索引
+// 重排在签名之前进行，签名时按返回的UTXO顺序计算各输入的签名哈希
+func (w *BitcoinWallet) orderTransaction(tx *wire.MsgTx, utxos []UTXO, changeIndex int) ([]UTXO, int) {
+	var change *wire.TxOut
+	if changeIndex >= 0 {
+		change = tx.TxOut[changeIndex]
+	}
+
+	switch w.outputOrdering {
+	case BIP69Sorted:
+		txsort.InPlaceSort(tx)
+		utxos = alignUTXOs(tx, utxos)
+	case Shuffled:
+		w.coinSelectionRand().Shuffle(len(tx.TxOut), func(i, j int) {
+			tx.TxOut[i], tx.TxOut[j] = tx.TxOut[j], tx.TxOut[i]
+		})
+	default:
+		return utxos, changeIndex
+	}
+
+	// 排序只交换*wire.TxOut指针，按指针找回找零输出
+	for i, out := range tx.TxOut {
+		if out == change {
+			return utxos, i
+		}
+	}
+	return utxos, -1
+}
+
+// alignUTXOs 按交易输入的outpoint重排UTXO，使utxos[i]对应tx.TxIn[i]
+// 数量不一致或有输入找不到对应UTXO时原样返回，由调用方按位置处理
+func alignUTXOs(tx *wire.MsgTx, utxos []UTXO) []UTXO {
+	if len(utxos) != len(tx.TxIn) {
+		return utxos
+	}
+
+	byOutpoint := make(map[string]UTXO, len(utxos))
+	for _, utxo := range utxos {
+		byOutpoint[outpointKey(utxo.TxID, utxo.Vout)] = utxo
+	}
+
+	aligned := make([]UTXO, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		prev := txIn.PreviousOutPoint
+		utxo, ok := byOutpoint[outpointKey(prev.Hash.String(), prev.Index)]
+		if !ok {
+			return utxos
+		}
+		aligned[i] = utxo
+	}
+	return aligned
+}
@@ -0,0 +1,177 @@
+package btc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// isBIP69Sorted 按BIP69的定义检查顺序: 输入按前序交易ID(显示顺序)和输出索引，输出按金额和脚本字节
+func isBIP69Sorted(tx *wire.MsgTx) bool {
+	for i := 1; i < len(tx.TxIn); i++ {
+		prev, cur := tx.TxIn[i-1].PreviousOutPoint, tx.TxIn[i].PreviousOutPoint
+		prevID, curID := prev.Hash.String(), cur.Hash.String()
+		if prevID > curID || (prevID == curID && prev.Index > cur.Index) {
+			return false
+		}
+	}
+	for i := 1; i < len(tx.TxOut); i++ {
+		prev, cur := tx.TxOut[i-1], tx.TxOut[i]
+		if prev.Value > cur.Value || (prev.Value == cur.Value && bytes.Compare(prev.PkScript, cur.PkScript) > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func orderingTestUTXOs() []UTXO {
+	return []UTXO{
+		{TxID: strings.Repeat("f", 64), Vout: 0, Value: 30000},
+		{TxID: strings.Repeat("1", 64), Vout: 3, Value: 20000},
+		{TxID: strings.Repeat("1", 64), Vout: 1, Value: 20000},
+	}
+}
+
+func TestBIP69Ordering(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	w.SetOutputOrdering(BIP69Sorted)
+
+	utxos := orderingTestUTXOs()
+	outputs := []PaymentOutput{
+		{Address: testAddress(t, "bip69-a"), Amount: 40000},
+		{Address: testAddress(t, "bip69-b"), Amount: 1000},
+	}
+	prepared, err := w.PrepareTransactionWithInputs(P2WPKH, outputs, utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !isBIP69Sorted(prepared.Tx) {
+		t.Fatal("交易的输入输出不符合BIP69顺序")
+	}
+	if err := w.VerifyTransaction(prepared.Tx, prepared.Inputs, P2WPKH); err != nil {
+		t.Fatalf("排序后的签名无效: %v", err)
+	}
+
+	ownScript, _ := w.addressScript(P2WPKH)
+	if !bytes.Equal(prepared.Tx.TxOut[prepared.ChangeIndex].PkScript, ownScript) {
+		t.Fatal("ChangeIndex没有指向找零输出")
+	}
+	if prepared.Inputs[0].Vout != 1 || prepared.Inputs[1].Vout != 3 {
+		t.Fatalf("Inputs应与排序后的交易输入一致: %v", prepared.Inputs)
+	}
+}
+
+func TestBIP69SendAll(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	w.SetOutputOrdering(BIP69Sorted)
+	script, _ := w.addressScript(P2WPKH)
+
+	// 服务端返回的UTXO不是BIP69顺序
+	var sent string
+	utxos := orderingTestUTXOs()
+	utxosJSON := fmt.Sprintf(`[{"txid":"%s","vout":%d,"value":%d},{"txid":"%s","vout":%d,"value":%d},{"txid":"%s","vout":%d,"value":%d}]`,
+		utxos[0].TxID, utxos[0].Vout, utxos[0].Value, utxos[1].TxID, utxos[1].Vout, utxos[1].Value, utxos[2].TxID, utxos[2].Vout, utxos[2].Value)
+	newTestServer(t, w, utxosJSON, func(txHex string) { sent = txHex })
+
+	if _, err := w.SendAllWithResult(P2WPKH, testAddress(t, "bip69-sweep")); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := deserializeTx(t, sent)
+	if !isBIP69Sorted(tx) {
+		t.Fatal("SendAll交易的输入不符合BIP69顺序")
+	}
+	// 排序后依次为1...1:1、1...1:3和f...f:0
+	verifyTx(t, tx, [][]byte{script, script, script}, []int64{20000, 20000, 30000})
+}
+
+func TestShuffledOrderingMovesChange(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	w.SetOutputOrdering(Shuffled)
+
+	utxos := orderingTestUTXOs()
+	outputs := []PaymentOutput{
+		{Address: testAddress(t, "shuffle-a"), Amount: 40000},
+		{Address: testAddress(t, "shuffle-b"), Amount: 1000},
+	}
+	ownScript, _ := w.addressScript(P2WPKH)
+
+	positions := make(map[int]bool)
+	for i := 0; i < 30; i++ {
+		prepared, err := w.PrepareTransactionWithInputs(P2WPKH, outputs, utxos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(prepared.Tx.TxOut[prepared.ChangeIndex].PkScript, ownScript) {
+			t.Fatal("ChangeIndex没有指向找零输出")
+		}
+		if err := w.VerifyTransaction(prepared.Tx, prepared.Inputs, P2WPKH); err != nil {
+			t.Fatal(err)
+		}
+		positions[prepared.ChangeIndex] = true
+	}
+
+	// 三个输出随机排列30次，找零始终在同一位置的概率可以忽略
+	if len(positions) < 2 {
+		t.Fatalf("找零总是出现在位置%v", positions)
+	}
+}
+
+// TestBIP69Vectors 使用BIP69参考实现的测试向量检查排序后的交易哈希
+func TestBIP69Vectors(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetOutputOrdering(BIP69Sorted)
+
+	tests := []struct {
+		file string
+		hash string
+	}{
+		{"bip69-1.hex", "839503cb611a3e3734bd521c608f881be2293ff77b7384057ab994c794fce623"},
+		{"bip69-2.hex", "28204cad1d7fc1d199e8ef4fa22f182de6258a3eaafe1bbe56ebdcacd3069a5f"},
+		{"bip69-3.hex", "0a8c246c55f6b82f094d211f4f57167bf2ea4898741d218b09bdb2536fd8d13f"},
+		{"bip69-4.hex", "a3196553b928b0b6154b002fa9a1ce875adabc486fedaaaf4c17430fd4486329"},
+		{"bip69-5.hex", "9a6c24746de024f77cac9b2138694f11101d1c66289261224ca52a25155a7c94"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := deserializeTx(t, strings.TrimSpace(string(data)))
+		w.orderTransaction(tx, nil, -1)
+		if got := tx.TxHash().String(); got != tt.hash {
+			t.Errorf("%s: 排序后的哈希为%s，期望%s", tt.file, got, tt.hash)
+		}
+	}
+}
+
+func TestShuffledKeepsInputOrder(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetOutputOrdering(Shuffled)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "bip69-3.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := deserializeTx(t, strings.TrimSpace(string(data)))
+	before := make([]wire.OutPoint, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		before[i] = txIn.PreviousOutPoint
+	}
+
+	w.orderTransaction(tx, nil, -1)
+	for i, txIn := range tx.TxIn {
+		if txIn.PreviousOutPoint != before[i] {
+			t.Fatalf("Shuffled不应改变输入顺序，输入%d已移动", i)
+		}
+	}
+}
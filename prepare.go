@@ -0,0 +1,295 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// PreparedTx 已签名但尚未广播的交易，用于在广播前向用户展示确认信息
+type PreparedTx struct {
+	Tx           *wire.MsgTx // 已签名的交易
+	Hex          string      // 序列化后的十六进制交易
+	TxID         string      // 交易ID
+	Fee          int64       // 手续费(satoshi)
+	ChangeAmount int64       // 找零金额，0表示没有找零输出
+	ChangeIndex  int         // 找零输出的索引，-1表示没有找零输出
+	Inputs       []UTXO      // 选中的输入，顺序与交易输入一致
+	VSize        int         // 交易虚拟大小(vbyte)
+}
+
+// PrepareTransaction 完成UTXO选择、手续费计算、构建和签名，但不广播交易
+func (w *BitcoinWallet) PrepareTransaction(fromAddrType AddressType, outputs []PaymentOutput) (*PreparedTx, error) {
+	return w.PrepareTransactionContext(context.Background(), fromAddrType, outputs)
+}
+
+// PrepareTransactionContext 准备交易，支持通过ctx取消获取UTXO和校验所有权时的网络请求
+func (w *BitcoinWallet) PrepareTransactionContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+) (*PreparedTx, error) {
+	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := w.fromAddrUTXOs(ctx, fromAddrType)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.prepareFromUTXOs(ctx, fromAddrType, utxos, resolvedOutputs, totalAmount)
+}
+
+// EstimateSendFee 预估从fromAddrType地址向outputs转账的手续费，只获取UTXO并进行选择，不构建和签名交易
+// 结果与SendMany实际选择相同输入时的手续费一致，余额不足时返回InsufficientFundsError
+func (w *BitcoinWallet) EstimateSendFee(fromAddrType AddressType, outputs []PaymentOutput) (int64, error) {
+	return w.EstimateSendFeeContext(context.Background(), fromAddrType, outputs)
+}
+
+// EstimateSendFeeContext 预估转账手续费，支持通过ctx取消获取UTXO的网络请求
+func (w *BitcoinWallet) EstimateSendFeeContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+) (int64, error) {
+	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return 0, err
+	}
+
+	utxos, err := w.fromAddrUTXOs(ctx, fromAddrType)
+	if err != nil {
+		return 0, err
+	}
+
+	_, _, fee, _, err := w.selectForOutputs(fromAddrType, utxos, resolvedOutputs, totalAmount)
+	if err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
+
+// fromAddrUTXOs 获取fromAddrType地址上可花费的UTXO，并检查资金确实位于该类型的地址上
+func (w *BitcoinWallet) fromAddrUTXOs(ctx context.Context, fromAddrType AddressType) ([]UTXO, error) {
+	fromAddr, err := w.GetAddress(fromAddrType)
+	if err != nil {
+		return nil, fmt.Errorf("获取发送方地址失败: %w", err)
+	}
+
+	utxos, err := w.spendableUTXOs(ctx, fromAddr)
+	if err != nil {
+		return nil, fmt.Errorf("获取UTXO失败: %w", err)
+	}
+
+	if err = w.checkFromAddrType(ctx, fromAddrType, utxos); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// PrepareFromAll 汇总钱包全部四种地址上的UTXO进行选择并签名，但不广播交易
+// 各输入按所在地址的类型分别签名，未设置找零地址时找零到本钱包的P2WPKH地址
+func (w *BitcoinWallet) PrepareFromAll(outputs []PaymentOutput) (*PreparedTx, error) {
+	return w.PrepareFromAllContext(context.Background(), outputs)
+}
+
+// PrepareFromAllContext 汇总全部地址类型的UTXO准备交易，支持通过ctx取消获取UTXO和校验所有权时的网络请求
+func (w *BitcoinWallet) PrepareFromAllContext(ctx context.Context, outputs []PaymentOutput) (*PreparedTx, error) {
+	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	var utxos []UTXO
+	for _, addrType := range spendableAddressTypes {
+		addr, err := w.GetAddress(addrType)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s地址失败: %w", addrType, err)
+		}
+
+		found, err := w.spendableUTXOs(ctx, addr)
+		if err != nil {
+			return nil, fmt.Errorf("获取%s地址的UTXO失败: %w", addrType, err)
+		}
+		utxos = append(utxos, found...)
+	}
+
+	return w.prepareFromUTXOs(ctx, sendFromAllChangeType, utxos, resolvedOutputs, totalAmount)
+}
+
+// sendFromAllChangeType 汇总多种地址类型转账时找零使用的地址类型
+const sendFromAllChangeType = P2WPKH
+
+// prepareFromUTXOs 从候选UTXO中选择足够支付输出和手续费的输入并签名
+// 携带PkScript的UTXO按其实际地址类型估算大小和签名，fromAddrType决定默认找零地址
+func (w *BitcoinWallet) prepareFromUTXOs(
+	ctx context.Context,
+	fromAddrType AddressType,
+	utxos []UTXO,
+	resolvedOutputs []resolvedOutput,
+	totalAmount int64,
+) (*PreparedTx, error) {
+	selectedUTXOs, totalValue, estimatedFee, changeAmount, err := w.selectForOutputs(fromAddrType, utxos, resolvedOutputs, totalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.prepareSigned(ctx, fromAddrType, selectedUTXOs, resolvedOutputs, totalAmount, totalValue, estimatedFee, changeAmount)
+}
+
+// selectForOutputs 选择足够支付输出金额和按所选输入估算的手续费的UTXO，手续费在选择过程中逐个输入计入
+// 返回选中的UTXO、其金额总和、手续费和找零金额
+func (w *BitcoinWallet) selectForOutputs(
+	fromAddrType AddressType,
+	utxos []UTXO,
+	resolvedOutputs []resolvedOutput,
+	totalAmount int64,
+) (selected []UTXO, totalValue, fee, change int64, err error) {
+	outputSizes := make([]int, len(resolvedOutputs))
+	for i, output := range resolvedOutputs {
+		outputSizes[i] = outputSize(len(output.script))
+	}
+
+	selected, totalValue, _, err = w.selectUTXOsForFee(utxos, totalAmount, outputSizes, w.feeRateMilli, fromAddrType)
+	if err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("选择UTXO失败: %w", err)
+	}
+
+	// 选择与computeFeeAndChange使用相同的估算，这里按dust处理策略确定最终的手续费和找零
+	fee, change = w.computeFeeAndChange(fromAddrType, totalAmount, resolvedOutputs, selected, totalValue)
+	if change < 0 {
+		return nil, 0, 0, 0, newInsufficientFundsError(totalAmount+fee, totalValue, fee)
+	}
+	return selected, totalValue, fee, change, nil
+}
+
+// PrepareTransactionWithInputs 使用指定的UTXO作为全部输入准备交易，不进行UTXO选择
+// 手续费和找零按这些输入计算，输入不足以支付金额和手续费时返回余额不足错误
+func (w *BitcoinWallet) PrepareTransactionWithInputs(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	inputs []UTXO,
+) (*PreparedTx, error) {
+	return w.PrepareTransactionWithInputsContext(context.Background(), fromAddrType, outputs, inputs)
+}
+
+// PrepareTransactionWithInputsContext 使用指定的UTXO准备交易，支持通过ctx取消校验所有权时获取UTXO脚本的网络请求
+// 不属于本钱包的输入返回ForeignUTXOError
+func (w *BitcoinWallet) PrepareTransactionWithInputsContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	inputs []UTXO,
+) (*PreparedTx, error) {
+	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("没有指定输入")
+	}
+
+	seen := make(map[string]struct{}, len(inputs))
+	var totalValue int64
+	for i, utxo := range inputs {
+		key := outpointKey(utxo.TxID, utxo.Vout)
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("输入%d重复: %s", i, key)
+		}
+		seen[key] = struct{}{}
+
+		totalValue += utxo.Value
+		if totalValue < 0 {
+			return nil, fmt.Errorf("UTXO金额总和溢出")
+		}
+	}
+
+	fee, changeAmount := w.computeFeeAndChange(fromAddrType, totalAmount, resolvedOutputs, inputs, totalValue)
+	if changeAmount < 0 {
+		return nil, newInsufficientFundsError(totalAmount+fee, totalValue, fee)
+	}
+
+	return w.prepareSigned(ctx, fromAddrType, inputs, resolvedOutputs, totalAmount, totalValue, fee, changeAmount)
+}
+
+// prepareSigned 按已确定的输入、手续费和找零构建并签名交易
+func (w *BitcoinWallet) prepareSigned(
+	ctx context.Context,
+	fromAddrType AddressType,
+	selectedUTXOs []UTXO,
+	resolvedOutputs []resolvedOutput,
+	totalAmount, totalValue, estimatedFee, changeAmount int64,
+) (*PreparedTx, error) {
+	resolvedOutputs = w.applyDustPolicy(resolvedOutputs, totalAmount, totalValue, estimatedFee, changeAmount)
+	built, err := w.buildTransaction(fromAddrType, selectedUTXOs, resolvedOutputs, changeAmount)
+	if err != nil {
+		return nil, fmt.Errorf("创建交易失败: %w", err)
+	}
+
+	return w.signBuilt(ctx, fromAddrType, built, totalAmount, totalValue, estimatedFee, changeAmount)
+}
+
+// signBuilt 签名已构建的交易，检查手续费后序列化为PreparedTx
+func (w *BitcoinWallet) signBuilt(
+	ctx context.Context,
+	fromAddrType AddressType,
+	built *TxBuildResult,
+	totalAmount, totalValue, estimatedFee, changeAmount int64,
+) (*PreparedTx, error) {
+	tx, selectedUTXOs := built.Tx, built.Inputs
+
+	if err := w.SignTransactionContext(ctx, tx, fromAddrType, selectedUTXOs); err != nil {
+		return nil, fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	fee := totalValue - txOutputTotal(tx)
+	if err := w.checkMinRelayFee(tx, fee); err != nil {
+		return nil, err
+	}
+
+	if err := w.checkFeeLimits(fee, totalAmount, TxVSize(tx)); err != nil {
+		return nil, err
+	}
+
+	if w.verifyBeforeBroadcast {
+		if err := w.VerifyTransaction(tx, selectedUTXOs, fromAddrType); err != nil {
+			return nil, fmt.Errorf("验证交易失败: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return &PreparedTx{
+		Tx:           tx,
+		Hex:          hex.EncodeToString(buf.Bytes()),
+		TxID:         tx.TxHash().String(),
+		Fee:          estimatedFee,
+		ChangeAmount: changeAmount,
+		ChangeIndex:  built.ChangeIndex,
+		Inputs:       selectedUTXOs,
+		VSize:        TxVSize(tx),
+	}, nil
+}
+
+// Commit 广播PrepareTransaction准备好的交易，返回交易ID
+func (w *BitcoinWallet) Commit(prepared *PreparedTx) (string, error) {
+	return w.CommitContext(context.Background(), prepared)
+}
+
+// CommitContext 广播准备好的交易，支持通过ctx取消请求
+func (w *BitcoinWallet) CommitContext(ctx context.Context, prepared *PreparedTx) (string, error) {
+	if prepared == nil || prepared.Hex == "" {
+		return "", fmt.Errorf("交易未准备")
+	}
+
+	return w.BroadcastTransactionContext(ctx, prepared.Hex)
+}
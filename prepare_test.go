@@ -0,0 +1,182 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestPrepareTransactionDoesNotBroadcast(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+	utxosJSON := fmt.Sprintf(`[{"txid":"%s","vout":0,"value":30000},{"txid":"%s","vout":1,"value":20000}]`,
+		strings.Repeat("1", 64), strings.Repeat("2", 64))
+	broadcasts := 0
+	newTestServer(t, w, utxosJSON, func(string) {
+		broadcasts++
+		t.Error("PrepareTransaction不应广播交易")
+	})
+
+	prepared, err := w.PrepareTransaction(P2WPKH, []PaymentOutput{{Address: testAddress(t, "dryrun"), Amount: 25000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broadcasts != 0 {
+		t.Fatalf("准备交易时广播了%d次", broadcasts)
+	}
+
+	tx := deserializeTx(t, prepared.Hex)
+	if tx.TxHash().String() != prepared.TxID || prepared.VSize != TxVSize(tx) {
+		t.Fatalf("PreparedTx与序列化的交易不一致: %+v", prepared)
+	}
+	values := make([]int64, len(prepared.Inputs))
+	scripts := make([][]byte, len(prepared.Inputs))
+	for i, utxo := range prepared.Inputs {
+		values[i], scripts[i] = utxo.Value, script
+	}
+	verifyTx(t, tx, scripts, values)
+}
+
+func TestPrepareWithInputsForeignUTXO(t *testing.T) {
+	w := newTestWallet(t)
+	ownScript, _ := w.addressScript(P2WPKH)
+	foreign, _ := txscript.PayToAddrScript(mustDecodeAddress(t, testAddress(t, "foreign")))
+	inputs := []UTXO{
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000, PkScript: ownScript},
+		{TxID: strings.Repeat("2", 64), Vout: 5, Value: 20000, PkScript: foreign},
+	}
+	outputs := []PaymentOutput{{Address: testAddress(t, "pay"), Amount: 25000}}
+
+	_, err := w.PrepareTransactionWithInputsContext(context.Background(), P2WPKH, outputs, inputs)
+	var foreignErr *ForeignUTXOError
+	if !errors.As(err, &foreignErr) || !errors.Is(err, ErrForeignUTXO) {
+		t.Fatalf("花费不属于本钱包的UTXO应返回ForeignUTXOError，实际为%v", err)
+	}
+	if foreignErr.Index != 1 || foreignErr.TxID != inputs[1].TxID || foreignErr.Vout != 5 {
+		t.Fatalf("错误详情为%+v，期望指出输入1", foreignErr)
+	}
+	// 错误信息指出具体的输入、outpoint和脚本
+	for _, want := range []string{"输入1", inputs[1].TxID + ":5", hex.EncodeToString(foreign)} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("错误信息%q应包含%q", err.Error(), want)
+		}
+	}
+
+	// 校验所有权需要获取UTXO脚本时，请求使用调用方的ctx
+	w.SetVerifyUTXOOwnership(true)
+	newTestServer(t, w, "[]", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.PrepareTransactionWithInputsContext(ctx, P2WPKH, outputs, testUTXOs()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ctx已取消时应返回context.Canceled，实际为%v", err)
+	}
+}
+
+// mustDecodeAddress 按测试网解码地址
+func mustDecodeAddress(t *testing.T, addr string) btcutil.Address {
+	t.Helper()
+
+	decoded, err := btcutil.DecodeAddress(addr, &chaincfg.TestNet3Params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestPrepareFromAllCombinesAddressTypes(t *testing.T) {
+	w := offlineWallet(t)
+	pkhAddr, _ := w.GetAddress(P2PKH)
+	trAddr, _ := w.GetAddress(P2TR)
+	w.SetUTXOProvider(staticUTXOProvider{
+		pkhAddr: {{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000}},
+		trAddr:  {{TxID: strings.Repeat("2", 64), Vout: 1, Value: 40000}},
+	})
+
+	// 任一地址的余额都不足以支付
+	prepared, err := w.PrepareFromAll([]PaymentOutput{{Address: testAddress(t, "all"), Amount: 60000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prepared.Inputs) != 2 {
+		t.Fatalf("应合并两个地址的UTXO，实际有%d个输入", len(prepared.Inputs))
+	}
+
+	pkhScript, _ := w.addressScript(P2PKH)
+	trScript, _ := w.addressScript(P2TR)
+	scripts := make([][]byte, len(prepared.Inputs))
+	values := make([]int64, len(prepared.Inputs))
+	for i, utxo := range prepared.Inputs {
+		values[i] = utxo.Value
+		scripts[i] = trScript
+		if utxo.Address == pkhAddr {
+			scripts[i] = pkhScript
+		}
+		if !bytes.Equal(utxo.PkScript, scripts[i]) {
+			t.Fatalf("输入%d的脚本为%x，与所在地址不符", i, utxo.PkScript)
+		}
+	}
+	// 每个输入按其所在地址的类型签名
+	verifyTx(t, prepared.Tx, scripts, values)
+
+	// 找零使用P2WPKH地址
+	if prepared.ChangeIndex < 0 {
+		t.Fatal("应有找零输出")
+	}
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	if !bytes.Equal(prepared.Tx.TxOut[prepared.ChangeIndex].PkScript, wpkhScript) {
+		t.Fatal("汇总转账的找零应使用P2WPKH地址")
+	}
+}
+
+func TestEstimateSendFeeMatchesPrepared(t *testing.T) {
+	w := offlineWallet(t)
+	w.SetFeeRate(3)
+	addr, _ := w.GetAddress(P2WPKH)
+	w.SetUTXOProvider(staticUTXOProvider{addr: {
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000},
+		{TxID: strings.Repeat("3", 64), Vout: 2, Value: 15000},
+	}})
+	outputs := []PaymentOutput{
+		{Address: testAddress(t, "a"), Amount: 20000},
+		{Address: testAddress(t, "b"), Amount: 15000},
+	}
+
+	fee, err := w.EstimateSendFee(P2WPKH, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prepared, err := w.PrepareTransaction(P2WPKH, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != prepared.Fee {
+		t.Fatalf("预估手续费为%d，实际准备的交易手续费为%d", fee, prepared.Fee)
+	}
+
+	// 与按选中输入、支付输出和找零输出估算的大小一致
+	outputSizes := []int{outputSize(22), outputSize(22)}
+	if prepared.ChangeIndex >= 0 {
+		outputSizes = append(outputSizes, outputSize(outputScriptSize(P2WPKH)))
+	}
+	inputTypes := make([]AddressType, len(prepared.Inputs))
+	for i := range inputTypes {
+		inputTypes[i] = P2WPKH
+	}
+	if want := int64(estimateVSize(inputTypes, outputSizes)) * 3; fee != want {
+		t.Fatalf("预估手续费为%d，期望%d", fee, want)
+	}
+
+	var insufficient *InsufficientFundsError
+	if _, err := w.EstimateSendFee(P2WPKH, []PaymentOutput{{Address: testAddress(t, "a"), Amount: 65000}}); !errors.As(err, &insufficient) {
+		t.Fatalf("余额不足时应返回InsufficientFundsError，实际为%v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+)
+
+// UTXOProvider 自定义的UTXO来源，如本地节点或索引服务
+// 设置后SendMany、SendAll等自动选币的转账从这里获取UTXO，不再请求区块浏览器
+type UTXOProvider interface {
+	// UTXOs 获取地址的UTXO
+	UTXOs(address string) ([]UTXO, error)
+}
+
+// SetUTXOProvider 设置转账时使用的UTXO来源，传入nil时恢复使用区块链数据后端
+// 要求的最小确认数大于1时仍会向后端查询最新区块高度以计算确认数
+func (w *BitcoinWallet) SetUTXOProvider(p UTXOProvider) {
+	w.utxoProvider = p
+}
+
+// spendableUTXOs 获取转账可用的UTXO，设置了UTXOProvider时优先使用
+// 开启SetAllowUnconfirmedChange时同时识别其中由本钱包创建的未确认输出
+func (w *BitcoinWallet) spendableUTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	var utxos []UTXO
+	if w.utxoProvider == nil {
+		fetched, err := w.GetUTXOsContext(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		utxos = fetched
+	} else {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		provided, err := w.utxoProvider.UTXOs(address)
+		if err != nil {
+			return nil, fmt.Errorf("从UTXOProvider获取UTXO失败: %w", err)
+		}
+
+		utxos, err = w.annotateUTXOs(ctx, address, provided)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if w.minConfirmations > 0 && w.allowUnconfirmedOwn {
+		if err := w.trustOwnUnconfirmed(ctx, utxos); err != nil {
+			return nil, err
+		}
+	}
+	return utxos, nil
+}
+
+// Broadcaster 自定义的交易广播方式，如节点RPC、ZMQ或只记录不广播的测试实现
+type Broadcaster interface {
+	// Broadcast 广播交易并返回交易ID
+	Broadcast(txHex string) (string, error)
+}
+
+// SetBroadcaster 设置BroadcastTransaction及SendMany、SendAll等转账使用的广播方式，
+// 传入nil时恢复通过区块链数据后端广播。返回的交易ID同样会与本地计算的交易ID比对
+func (w *BitcoinWallet) SetBroadcaster(b Broadcaster) {
+	w.broadcaster = b
+}
+
+// broadcast 通过自定义Broadcaster或区块链数据后端广播交易
+func (w *BitcoinWallet) broadcast(ctx context.Context, txHex string) (string, error) {
+	if w.broadcaster == nil {
+		return w.backend.Broadcast(ctx, txHex)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return w.broadcaster.Broadcast(txHex)
+}
+
+// spendableAddressTypes 钱包可以签名花费的地址类型
+var spendableAddressTypes = []AddressType{P2PKH, P2WPKH, P2SH, P2TR}
+
+// checkFromAddrType 检查UTXO都可以由本钱包签名，脚本不属于本钱包时返回ForeignUTXOError
+// 位于本钱包其他地址类型上的UTXO按其自身脚本签名，不视为错误。fromAddrType地址上没有任何UTXO时，
+// 若UTXO来自区块链数据后端，依次查询钱包的其他地址类型，发现资金时提示应使用的类型，查询失败则不作判断
+func (w *BitcoinWallet) checkFromAddrType(ctx context.Context, fromAddrType AddressType, utxos []UTXO) error {
+	if w.publicKey == nil {
+		// 观察钱包不签名，无需检查
+		return nil
+	}
+
+	for i, utxo := range utxos {
+		if len(utxo.PkScript) == 0 {
+			continue
+		}
+		if _, ok := w.ownScriptType(utxo.PkScript); !ok {
+			return &ForeignUTXOError{Index: i, TxID: utxo.TxID, Vout: utxo.Vout, PkScript: utxo.PkScript}
+		}
+	}
+
+	// UTXOProvider提供的UTXO由调用方负责，不再额外查询其他地址
+	if len(utxos) > 0 || w.utxoProvider != nil {
+		return nil
+	}
+
+	for _, addrType := range spendableAddressTypes {
+		if addrType == fromAddrType {
+			continue
+		}
+
+		addr, err := w.GetAddress(addrType)
+		if err != nil {
+			continue
+		}
+		others, err := w.GetUTXOsContext(ctx, addr)
+		if err != nil {
+			return nil
+		}
+		if len(others) > 0 {
+			return &AddressTypeMismatchError{Claimed: fromAddrType, Actual: addrType}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// staticUTXOProvider 按地址返回固定UTXO的UTXOProvider
+type staticUTXOProvider map[string][]UTXO
+
+func (p staticUTXOProvider) UTXOs(address string) ([]UTXO, error) {
+	return append([]UTXO(nil), p[address]...), nil
+}
+
+// recordingBroadcaster 只记录交易不广播的Broadcaster
+type recordingBroadcaster struct {
+	hexes []string
+}
+
+func (b *recordingBroadcaster) Broadcast(txHex string) (string, error) {
+	b.hexes = append(b.hexes, txHex)
+	return txIDOfHex(txHex)
+}
+
+// txIDOfHex 计算十六进制交易的交易ID
+func txIDOfHex(txHex string) (string, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return "", err
+	}
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", err
+	}
+	return tx.TxHash().String(), nil
+}
+
+// offlineWallet 返回后端收到任何请求都会使测试失败的测试钱包
+func offlineWallet(t *testing.T) *BitcoinWallet {
+	t.Helper()
+
+	w := newTestWallet(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		t.Errorf("不应请求区块链数据后端: %s %s", r.Method, r.URL.Path)
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestSendManyWithUTXOProvider(t *testing.T) {
+	w := offlineWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+	script, _ := w.addressScript(P2WPKH)
+	provider := staticUTXOProvider{addr: {
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000},
+		{TxID: strings.Repeat("3", 64), Vout: 2, Value: 1000000},
+	}}
+	w.SetUTXOProvider(provider)
+	broadcaster := &recordingBroadcaster{}
+	w.SetBroadcaster(broadcaster)
+
+	txID, err := w.SendMany(P2WPKH, []PaymentOutput{{Address: testAddress(t, "provider"), Amount: 40000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(broadcaster.hexes) != 1 {
+		t.Fatalf("应广播1笔交易，实际为%d笔", len(broadcaster.hexes))
+	}
+
+	// 默认从小到大选择，使用UTXOProvider提供的前两个UTXO
+	tx := deserializeTx(t, broadcaster.hexes[0])
+	if tx.TxHash().String() != txID || len(tx.TxIn) != 2 {
+		t.Fatalf("交易ID为%s、输入%d个，期望%s和2个输入", tx.TxHash(), len(tx.TxIn), txID)
+	}
+	values := make([]int64, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		for _, utxo := range provider[addr] {
+			if txIn.PreviousOutPoint.Hash.String() == utxo.TxID && txIn.PreviousOutPoint.Index == utxo.Vout {
+				values[i] = utxo.Value
+			}
+		}
+	}
+	verifyTx(t, tx, [][]byte{script, script}, values)
+
+	// 地址上没有UTXO时返回ErrNoUTXOs，同样不访问网络
+	w.SetUTXOProvider(staticUTXOProvider{})
+	if _, err := w.SendMany(P2WPKH, []PaymentOutput{{Address: testAddress(t, "provider"), Amount: 40000}}); err == nil {
+		t.Fatal("没有UTXO时应返回错误")
+	}
+	if len(broadcaster.hexes) != 1 {
+		t.Fatal("失败的转账不应广播")
+	}
+}
+
+func TestBroadcasterReceivesBuiltHex(t *testing.T) {
+	w := offlineWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+	w.SetUTXOProvider(staticUTXOProvider{addr: {
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000},
+	}})
+	broadcaster := &recordingBroadcaster{}
+	w.SetBroadcaster(broadcaster)
+	outputs := []PaymentOutput{{Address: testAddress(t, "broadcaster"), Amount: 25000}}
+
+	// P2WPKH使用RFC6979确定性签名，相同输入构建的交易完全相同
+	prepared, err := w.PrepareTransaction(P2WPKH, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txID, err := w.SendMany(P2WPKH, outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(broadcaster.hexes) != 1 {
+		t.Fatalf("Broadcaster收到%d笔交易，期望1笔", len(broadcaster.hexes))
+	}
+	if broadcaster.hexes[0] != prepared.Hex {
+		t.Fatalf("Broadcaster收到的交易为%s，期望构建的%s", broadcaster.hexes[0], prepared.Hex)
+	}
+	if txID != prepared.TxID {
+		t.Fatalf("SendMany返回的交易ID为%s，期望%s", txID, prepared.TxID)
+	}
+
+	// 恢复默认后通过后端广播
+	w.SetBroadcaster(nil)
+	var sent []string
+	newTestServer(t, w, "[]", func(txHex string) { sent = append(sent, txHex) })
+	if _, err := w.Commit(prepared); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0] != prepared.Hex || len(broadcaster.hexes) != 1 {
+		t.Fatal("SetBroadcaster(nil)后应通过后端广播")
+	}
+}
+
+func TestFromAddrTypeMismatchNamesActualType(t *testing.T) {
+	w := newTestWallet(t)
+	trAddr, _ := w.GetAddress(P2TR)
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/utxo") {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// 资金只在P2TR地址上
+		if r.URL.Path == "/address/"+trAddr+"/utxo" {
+			rw.Write([]byte(`[{"txid":"` + strings.Repeat("1", 64) + `","vout":0,"value":50000}]`))
+			return
+		}
+		rw.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.SendMany(P2WPKH, []PaymentOutput{{Address: testAddress(t, "mismatch"), Amount: 20000}})
+	var mismatch *AddressTypeMismatchError
+	if !errors.As(err, &mismatch) || !errors.Is(err, ErrAddressTypeMismatch) {
+		t.Fatalf("资金位于P2TR地址时应返回AddressTypeMismatchError，实际为%v", err)
+	}
+	if mismatch.Claimed != P2WPKH || mismatch.Actual != P2TR {
+		t.Fatalf("错误详情为%+v，期望指定P2WPKH、实际P2TR", mismatch)
+	}
+	if !strings.Contains(err.Error(), string(P2TR)) {
+		t.Fatalf("错误信息%q应提示使用%s", err.Error(), P2TR)
+	}
+
+	// 使用正确的类型可以转账
+	if _, err := w.PrepareTransaction(P2TR, []PaymentOutput{{Address: testAddress(t, "mismatch"), Amount: 20000}}); err != nil {
+		t.Fatalf("使用P2TR应能转账，实际为%v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CreatePSBT 创建未签名的PSBT(BIP174)，返回base64编码
+func (w *BitcoinWallet) CreatePSBT(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) (string, error) {
+	return w.CreatePSBTContext(context.Background(), fromAddrType, outputs, utxos)
+}
+
+// CreatePSBTContext 创建未签名的PSBT，支持通过ctx取消P2PKH输入获取前序交易的网络请求
+func (w *BitcoinWallet) CreatePSBTContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) (string, error) {
+	tx, err := w.buildUnsignedTransaction(fromAddrType, outputs, utxos)
+	if err != nil {
+		return "", err
+	}
+	utxos = alignUTXOs(tx, utxos)
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return "", fmt.Errorf("创建PSBT失败: %w", err)
+	}
+
+	fromScript, err := w.addressScript(fromAddrType)
+	if err != nil {
+		return "", fmt.Errorf("创建发送方脚本失败: %w", err)
+	}
+
+	for i, utxo := range utxos {
+		input := &packet.Inputs[i]
+
+		switch fromAddrType {
+		case P2PKH:
+			// 传统输入需要完整的前序交易，fetchTransaction已校验其哈希等于utxo.TxID
+			prevTx, err := w.fetchTransaction(ctx, utxo.TxID)
+			if err != nil {
+				return "", fmt.Errorf("获取输入%d的前序交易失败: %w", i, err)
+			}
+			if int(utxo.Vout) >= len(prevTx.TxOut) || prevTx.TxOut[utxo.Vout].Value != utxo.Value {
+				return "", fmt.Errorf("输入%d的前序交易中没有金额为%d的输出%d", i, utxo.Value, utxo.Vout)
+			}
+			input.NonWitnessUtxo = prevTx
+		case P2WPKH:
+			input.WitnessUtxo = wire.NewTxOut(utxo.Value, fromScript)
+		case P2SH:
+			redeemScript, err := w.nestedRedeemScript()
+			if err != nil {
+				return "", fmt.Errorf("创建赎回脚本失败: %w", err)
+			}
+			input.WitnessUtxo = wire.NewTxOut(utxo.Value, fromScript)
+			input.RedeemScript = redeemScript
+		case P2TR:
+			input.WitnessUtxo = wire.NewTxOut(utxo.Value, fromScript)
+			input.TaprootInternalKey = schnorr.SerializePubKey(w.publicKey)
+		default:
+			return "", fmt.Errorf("不支持的地址类型: %s", fromAddrType)
+		}
+	}
+
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return "", fmt.Errorf("编码PSBT失败: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// fetchTransaction 获取并解析链上交易
+func (w *BitcoinWallet) fetchTransaction(ctx context.Context, txID string) (*wire.MsgTx, error) {
+	txHex, err := w.GetTxHexContext(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, fmt.Errorf("解码十六进制失败: %w", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("反序列化交易失败: %w", err)
+	}
+
+	if !strings.EqualFold(tx.TxHash().String(), txID) {
+		return nil, fmt.Errorf("交易哈希不匹配: %s", txID)
+	}
+
+	return tx, nil
+}
+
+// SignPSBT 为PSBT中属于本钱包的输入签名，所有输入签名完成后自动finalize
+// 脚本与本钱包地址不匹配的输入会被跳过
+func (w *BitcoinWallet) SignPSBT(psbtBase64 string, fromAddrType AddressType) (string, error) {
+	packet, err := psbt.NewFromRawBytes(strings.NewReader(psbtBase64), true)
+	if err != nil {
+		return "", fmt.Errorf("解析PSBT失败: %w", err)
+	}
+
+	fromScript, err := w.addressScript(fromAddrType)
+	if err != nil {
+		return "", fmt.Errorf("创建发送方脚本失败: %w", err)
+	}
+
+	// Taproot签名需要全部输入的前序输出
+	prevFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	missingPrevOut := -1
+	for i, txIn := range packet.UnsignedTx.TxIn {
+		prevOut := psbtPrevOut(packet, i)
+		if prevOut == nil {
+			if missingPrevOut < 0 {
+				missingPrevOut = i
+			}
+			continue
+		}
+		prevFetcher.AddPrevOut(txIn.PreviousOutPoint, prevOut)
+	}
+
+	updater, err := psbt.NewUpdater(packet)
+	if err != nil {
+		return "", fmt.Errorf("创建PSBT更新器失败: %w", err)
+	}
+
+	tx := packet.UnsignedTx
+	pubKey := w.publicKey.SerializeCompressed()
+
+	for i := range packet.Inputs {
+		input := &packet.Inputs[i]
+		if len(input.FinalScriptSig) > 0 || len(input.FinalScriptWitness) > 0 {
+			continue
+		}
+
+		prevOut := psbtPrevOut(packet, i)
+		if prevOut == nil || !bytes.Equal(prevOut.PkScript, fromScript) {
+			continue
+		}
+
+		switch fromAddrType {
+		case P2PKH:
+			sig, err := w.p2pkhSignature(tx, i, fromScript, txscript.SigHashAll)
+			if err != nil {
+				return "", fmt.Errorf("签名输入%d失败: %w", i, err)
+			}
+			if _, err := updater.Sign(i, sig, pubKey, nil, nil); err != nil {
+				return "", fmt.Errorf("添加输入%d签名失败: %w", i, err)
+			}
+		case P2WPKH:
+			sig, err := w.witnessV0Signature(tx, i, prevOut.Value, fromScript, fromScript, txscript.SigHashAll)
+			if err != nil {
+				return "", fmt.Errorf("签名输入%d失败: %w", i, err)
+			}
+			if _, err := updater.Sign(i, sig, pubKey, nil, nil); err != nil {
+				return "", fmt.Errorf("添加输入%d签名失败: %w", i, err)
+			}
+		case P2SH:
+			redeemScript, err := w.nestedRedeemScript()
+			if err != nil {
+				return "", fmt.Errorf("创建赎回脚本失败: %w", err)
+			}
+			sig, err := w.witnessV0Signature(tx, i, prevOut.Value, fromScript, redeemScript, txscript.SigHashAll)
+			if err != nil {
+				return "", fmt.Errorf("签名输入%d失败: %w", i, err)
+			}
+			if _, err := updater.Sign(i, sig, pubKey, redeemScript, nil); err != nil {
+				return "", fmt.Errorf("添加输入%d签名失败: %w", i, err)
+			}
+		case P2TR:
+			if missingPrevOut >= 0 {
+				return "", fmt.Errorf("输入%d缺少前序输出信息，无法计算Taproot签名哈希", missingPrevOut)
+			}
+			sig, err := w.taprootSignature(tx, i, prevOut.Value, fromScript, prevFetcher, txscript.SigHashDefault)
+			if err != nil {
+				return "", fmt.Errorf("签名输入%d失败: %w", i, err)
+			}
+			input.TaprootKeySpendSig = sig
+		default:
+			return "", fmt.Errorf("不支持的地址类型: %s", fromAddrType)
+		}
+	}
+
+	// 仅在全部输入都可以finalize时才finalize，否则保留部分签名供其他签名方继续处理
+	if psbtFinalizable(packet) {
+		if err := psbt.MaybeFinalizeAll(packet); err != nil {
+			return "", fmt.Errorf("finalize PSBT失败: %w", err)
+		}
+	}
+
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		return "", fmt.Errorf("编码PSBT失败: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// psbtPrevOut 获取PSBT输入对应的前序输出
+func psbtPrevOut(packet *psbt.Packet, idx int) *wire.TxOut {
+	input := packet.Inputs[idx]
+	if input.WitnessUtxo != nil {
+		return input.WitnessUtxo
+	}
+
+	if input.NonWitnessUtxo != nil {
+		outIndex := packet.UnsignedTx.TxIn[idx].PreviousOutPoint.Index
+		if int(outIndex) < len(input.NonWitnessUtxo.TxOut) {
+			return input.NonWitnessUtxo.TxOut[outIndex]
+		}
+	}
+
+	return nil
+}
+
+// psbtFinalizable 判断PSBT的全部输入是否都已签名
+func psbtFinalizable(packet *psbt.Packet) bool {
+	for _, input := range packet.Inputs {
+		if len(input.FinalScriptSig) > 0 || len(input.FinalScriptWitness) > 0 {
+			continue
+		}
+		if len(input.PartialSigs) == 0 && len(input.TaprootKeySpendSig) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package btc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSignPSBT(t *testing.T) {
+	w := newTestWallet(t)
+	utxos := testUTXOs()
+	outputs := []PaymentOutput{{Address: testAddress(t, "psbt"), Amount: 40000}}
+
+	for _, addrType := range []AddressType{P2WPKH, P2SH, P2TR} {
+		t.Run(string(addrType), func(t *testing.T) {
+			unsigned, err := w.CreatePSBT(addrType, outputs, utxos)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			signed, err := w.SignPSBT(unsigned, addrType)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			packet, err := psbt.NewFromRawBytes(strings.NewReader(signed), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tx, err := psbt.Extract(packet)
+			if err != nil {
+				t.Fatalf("提取最终交易失败: %v", err)
+			}
+
+			script, _ := w.addressScript(addrType)
+			verifyTx(t, tx, [][]byte{script, script}, []int64{30000, 20000})
+		})
+	}
+}
+
+func TestSignPSBTSkipsForeignInputs(t *testing.T) {
+	w := newTestWallet(t)
+	unsigned, err := w.CreatePSBT(P2WPKH, []PaymentOutput{{Address: testAddress(t, "psbt"), Amount: 40000}}, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewWalletFromMnemonic(testMnemonic, "", TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := other.SignPSBT(unsigned, P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != unsigned {
+		t.Fatal("其他钱包不应修改不属于它的输入")
+	}
+}
+
+func TestSignPSBTMissingPrevOut(t *testing.T) {
+	w := newTestWallet(t)
+	unsigned, err := w.CreatePSBT(P2TR, []PaymentOutput{{Address: testAddress(t, "psbt"), Amount: 10000}}, testUTXOs()[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 追加一个没有前序输出信息的输入，Taproot签名哈希无法计算
+	packet, _ := psbt.NewFromRawBytes(strings.NewReader(unsigned), true)
+	packet.UnsignedTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{9}, 0), nil, nil))
+	packet.Inputs = append(packet.Inputs, psbt.PInput{})
+	unsigned, _ = packet.B64Encode()
+
+	if _, err := w.SignPSBT(unsigned, P2TR); err == nil {
+		t.Fatal("缺少前序输出时应返回错误而不是panic")
+	}
+}
+
+func TestCreatePSBTP2PKHPrevTx(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2PKH)
+
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{7}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(10000, []byte{0x51}))
+	prevTx.AddTxOut(wire.NewTxOut(50000, script))
+	prevTxID := prevTx.TxHash().String()
+
+	// other的哈希与请求的交易ID不同，服务端返回它时不能放入PSBT
+	other := prevTx.Copy()
+	other.TxOut[0].Value++
+
+	served := prevTx
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tx/"+prevTxID+"/hex" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		rw.Write([]byte(serializeTx(t, served)))
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := []PaymentOutput{{Address: testAddress(t, "psbt"), Amount: 40000}}
+	utxos := []UTXO{{TxID: prevTxID, Vout: 1, Value: 50000}}
+
+	unsigned, err := w.CreatePSBT(P2PKH, outputs, utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, _ := psbt.NewFromRawBytes(strings.NewReader(unsigned), true)
+	if prev := packet.Inputs[0].NonWitnessUtxo; prev == nil || prev.TxHash() != prevTx.TxHash() {
+		t.Fatal("P2PKH输入应携带哈希与输入outpoint一致的前序交易")
+	}
+
+	signed, err := w.SignPSBT(unsigned, P2PKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packet, _ = psbt.NewFromRawBytes(strings.NewReader(signed), true)
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		t.Fatalf("提取最终交易失败: %v", err)
+	}
+	verifyTx(t, tx, [][]byte{script}, []int64{50000})
+
+	if _, err := w.CreatePSBT(P2PKH, outputs, []UTXO{{TxID: prevTxID, Vout: 1, Value: 60000}}); err == nil {
+		t.Fatal("UTXO金额与前序交易输出不一致时应返回错误")
+	}
+
+	served = other
+	if _, err := w.CreatePSBT(P2PKH, outputs, utxos); err == nil {
+		t.Fatal("服务端返回的交易哈希与UTXO不一致时应返回错误")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.CreatePSBTContext(ctx, P2PKH, outputs, utxos); err == nil {
+		t.Fatal("ctx已取消时应返回错误")
+	}
+}
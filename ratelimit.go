@@ -0,0 +1,92 @@
+package btc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter 令牌桶限速器，按固定速率补充令牌，最多累积burst个
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒补充的令牌数
+	burst  float64 // 令牌桶容量
+	tokens float64 // 当前令牌数，为负时表示已被预约的等待量
+	last   time.Time
+}
+
+// newRateLimiter 创建令牌桶已满的限速器
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait 获取一个令牌，令牌不足时等待补充，ctx取消时归还令牌并返回
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// 先预约令牌再等待，保证并发调用按到达顺序均匀排开
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if err := sleepContext(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// SetRateLimit 设置对区块浏览器的请求速率，rps为每秒请求数，burst为允许的突发请求数
+// rps小于等于0时取消限速
+func (w *BitcoinWallet) SetRateLimit(rps float64, burst int) {
+	if rps <= 0 {
+		w.client.limiter = nil
+		return
+	}
+
+	if burst < 1 {
+		burst = 1
+	}
+	w.client.limiter = newRateLimiter(rps, burst)
+}
+
+// parseRetryAfter 解析Retry-After响应头，支持秒数和HTTP日期两种格式，无法解析时返回0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
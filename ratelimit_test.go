@@ -0,0 +1,115 @@
+package btc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSpacesConcurrentRequests(t *testing.T) {
+	var mu sync.Mutex
+	var arrivals []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		arrivals = append(arrivals, time.Now())
+		mu.Unlock()
+		rw.Write([]byte(balanceBody))
+	}))
+	defer srv.Close()
+
+	w := newTestWallet(t)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	// 每秒20个请求、不允许突发: 请求间隔50ms
+	w.SetRateLimit(20, 1)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	const requests = 5
+	var wg sync.WaitGroup
+	errs := make([]error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = w.GetBalance(addr)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sort.Slice(arrivals, func(i, j int) bool { return arrivals[i].Before(arrivals[j]) })
+	if len(arrivals) != requests {
+		t.Fatalf("服务端收到%d个请求，期望%d个", len(arrivals), requests)
+	}
+	// 允许调度误差，但相邻请求不应挤在一起
+	for i := 1; i < len(arrivals); i++ {
+		if gap := arrivals[i].Sub(arrivals[i-1]); gap < 35*time.Millisecond {
+			t.Fatalf("第%d和%d个请求间隔%s，低于限速的50ms", i-1, i, gap)
+		}
+	}
+	if span := arrivals[requests-1].Sub(arrivals[0]); span < 180*time.Millisecond {
+		t.Fatalf("%d个请求在%s内完成，限速未生效", requests, span)
+	}
+}
+
+func TestRetryAfterRateLimitError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Retry-After", "5")
+		rw.WriteHeader(http.StatusTooManyRequests)
+		rw.Write([]byte("Too Many Requests"))
+	}))
+	defer srv.Close()
+
+	w := newTestWallet(t)
+	w.SetRetryPolicy(0, 0)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := w.GetAddress(P2WPKH)
+
+	_, err := w.GetBalance(addr)
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("429应返回RateLimitError，实际为%v", err)
+	}
+	if rateErr.RetryAfter != 5*time.Second {
+		t.Fatalf("RetryAfter为%s，期望5s", rateErr.RetryAfter)
+	}
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatal("RateLimitError应匹配ErrRateLimited")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("应能取得429的原始响应，实际为%v", apiErr)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"5", 5 * time.Second},
+		{" 120 ", 2 * time.Minute},
+		{"Mon, 01 Jan 2024 00:00:30 GMT", 30 * time.Second},
+		{"Sun, 31 Dec 2023 23:59:00 GMT", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("Retry-After %q解析为%s，期望%s", tt.value, got, tt.want)
+		}
+	}
+}
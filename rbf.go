@@ -0,0 +1,183 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// signalsRBF 判断交易是否声明了BIP125可替换
+func signalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// BumpFee 按新费率重建声明了RBF的交易并重新签名
+// utxos需包含原交易全部输入对应的UTXO，额外提供的UTXO会在找零不足时追加为输入
+// 新手续费不低于原手续费加上按最低转发费率计算的新交易费用，并受SetMaxFeeRate等手续费上限约束
+func (w *BitcoinWallet) BumpFee(txHex string, newFeeRate int64, fromAddrType AddressType, utxos []UTXO) (string, error) {
+	return w.BumpFeeContext(context.Background(), txHex, newFeeRate, fromAddrType, utxos)
+}
+
+// BumpFeeContext 按新费率替换交易，支持通过ctx取消签名时获取UTXO脚本的网络请求
+func (w *BitcoinWallet) BumpFeeContext(
+	ctx context.Context,
+	txHex string,
+	newFeeRate int64,
+	fromAddrType AddressType,
+	utxos []UTXO,
+) (string, error) {
+	data, err := hex.DecodeString(txHex)
+	if err != nil {
+		return "", fmt.Errorf("解码十六进制失败: %w", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err = tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("反序列化交易失败: %w", err)
+	}
+
+	if !signalsRBF(tx) {
+		return "", fmt.Errorf("原交易未声明RBF，无法替换")
+	}
+
+	changeScript, err := w.changeScript(fromAddrType)
+	if err != nil {
+		return "", err
+	}
+
+	// 定位找零输出
+	changeIndex := -1
+	for i, txOut := range tx.TxOut {
+		if bytes.Equal(txOut.PkScript, changeScript) ||
+			(w.changeAddress == nil && w.hdChange() && w.hd.isChangeScript(w.hdAccount, txOut.PkScript, fromAddrType)) {
+			changeIndex = i
+			break
+		}
+	}
+	if changeIndex < 0 {
+		return "", fmt.Errorf("原交易没有可用于扣减手续费的找零输出")
+	}
+
+	utxoByOutpoint := make(map[wire.OutPoint]UTXO, len(utxos))
+	for _, utxo := range utxos {
+		outpoint, err := utxoOutPoint(utxo)
+		if err != nil {
+			return "", err
+		}
+		utxoByOutpoint[*outpoint] = utxo
+	}
+
+	// 收集原输入对应的UTXO
+	inputs := make([]UTXO, 0, len(tx.TxIn))
+	var inputTotal int64
+	for i, txIn := range tx.TxIn {
+		utxo, ok := utxoByOutpoint[txIn.PreviousOutPoint]
+		if !ok {
+			return "", fmt.Errorf("缺少输入%d对应的UTXO: %s", i, txIn.PreviousOutPoint)
+		}
+		delete(utxoByOutpoint, txIn.PreviousOutPoint)
+		inputs = append(inputs, utxo)
+		inputTotal += utxo.Value
+	}
+
+	var outputTotal int64
+	for _, txOut := range tx.TxOut {
+		outputTotal += txOut.Value
+	}
+
+	oldFee := inputTotal - outputTotal
+	if oldFee < 0 {
+		return "", fmt.Errorf("原交易输出金额超过输入金额")
+	}
+	paymentTotal := outputTotal - tx.TxOut[changeIndex].Value
+
+	// 剩余UTXO按金额从大到小作为追加输入的候选
+	var candidates []UTXO
+	for _, utxo := range utxoByOutpoint {
+		if utxo.Value > 0 {
+			candidates = append(candidates, utxo)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Value > candidates[j].Value
+	})
+
+	outputSizes := make([]int, len(tx.TxOut))
+	for i, txOut := range tx.TxOut {
+		outputSizes[i] = outputSize(len(txOut.PkScript))
+	}
+
+	sequence := tx.TxIn[0].Sequence
+	for {
+		// 追加的UTXO可能位于其他类型的地址上，按每个输入的实际类型估算大小
+		vsize := estimateVSize(w.inputTypesFor(inputs, fromAddrType), outputSizes)
+		newFee := w.feeForVSize(vsize, newFeeRate*1000)
+		// BIP125要求新手续费至少覆盖原手续费加上新交易自身的最低中继费用
+		if minFee := oldFee + w.feeForVSize(vsize, 0); newFee < minFee {
+			newFee = minFee
+		}
+
+		changeAmount := inputTotal - paymentTotal - newFee
+		if changeAmount > dustLimit(changeScript) {
+			tx.TxOut[changeIndex].Value = changeAmount
+			break
+		}
+
+		if len(candidates) == 0 {
+			if changeAmount >= 0 {
+				// 找零过小，移除找零输出并全部作为手续费
+				tx.TxOut = append(tx.TxOut[:changeIndex], tx.TxOut[changeIndex+1:]...)
+				break
+			}
+			return "", newInsufficientFundsError(paymentTotal+newFee, inputTotal, newFee)
+		}
+
+		extra := candidates[0]
+		candidates = candidates[1:]
+
+		outpoint, err := utxoOutPoint(extra)
+		if err != nil {
+			return "", err
+		}
+
+		txIn := wire.NewTxIn(outpoint, nil, nil)
+		txIn.Sequence = sequence
+		tx.AddTxIn(txIn)
+		inputs = append(inputs, extra)
+		inputTotal += extra.Value
+	}
+
+	// 清除旧签名后重新签名
+	for _, txIn := range tx.TxIn {
+		txIn.SignatureScript = nil
+		txIn.Witness = nil
+	}
+
+	if err = w.SignTransactionContext(ctx, tx, fromAddrType, inputs); err != nil {
+		return "", fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	fee := inputTotal - txOutputTotal(tx)
+	if err = w.checkMinRelayFee(tx, fee); err != nil {
+		return "", err
+	}
+	if err = w.checkFeeLimits(fee, paymentTotal, TxVSize(tx)); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,146 @@
+package btc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestRBFSequence(t *testing.T) {
+	w := newTestWallet(t)
+	outputs := []PaymentOutput{{Address: testAddress(t, "rbf"), Amount: 25000}}
+	script, _ := w.addressScript(P2WPKH)
+
+	prepared, err := w.PrepareTransactionWithInputs(P2WPKH, outputs, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := deserializeTx(t, prepared.Hex)
+	if signalsRBF(tx) || tx.TxIn[0].Sequence != wire.MaxTxInSequenceNum {
+		t.Fatalf("默认不应声明RBF，序列号为%#x", tx.TxIn[0].Sequence)
+	}
+
+	w.SetRBF(true)
+	prepared, err = w.PrepareTransactionWithInputs(P2WPKH, outputs, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx = deserializeTx(t, prepared.Hex)
+	for i, txIn := range tx.TxIn {
+		if txIn.Sequence != 0xfffffffd {
+			t.Fatalf("输入%d的序列号为%#x，期望0xfffffffd", i, txIn.Sequence)
+		}
+	}
+	if !signalsRBF(tx) {
+		t.Fatal("启用RBF后交易应声明可替换")
+	}
+
+	// 序列号在签名哈希覆盖范围内，必须在签名前写入
+	verifyTx(t, tx, [][]byte{script, script}, []int64{30000, 20000})
+}
+
+// rbfOriginal 构建一笔声明了RBF、以2 sat/vB从P2WPKH地址支付amount的原交易
+func rbfOriginal(t *testing.T, w *BitcoinWallet, utxo UTXO, amount int64) *PreparedTx {
+	t.Helper()
+
+	w.SetRBF(true)
+	w.SetFeeRate(2)
+	prepared, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "bump"), Amount: amount}}, []UTXO{utxo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepared.ChangeIndex < 0 {
+		t.Fatal("原交易应包含找零输出")
+	}
+	return prepared
+}
+
+func TestBumpFee(t *testing.T) {
+	w := newTestWallet(t)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	utxo := UTXO{TxID: strings.Repeat("1", 64), Vout: 0, Value: 100000, PkScript: wpkhScript}
+	original := rbfOriginal(t, w, utxo, 60000)
+
+	bumpedHex, err := w.BumpFee(original.Hex, 10, P2WPKH, []UTXO{utxo})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bumped := deserializeTx(t, bumpedHex)
+	verifyTx(t, bumped, [][]byte{wpkhScript}, []int64{utxo.Value})
+	if len(bumped.TxIn) != 1 || !signalsRBF(bumped) {
+		t.Fatal("找零足够时不应追加输入，且替换交易仍应声明RBF")
+	}
+
+	fee := utxo.Value - txOutputTotal(bumped)
+	if fee <= original.Fee || EffectiveFeeRate(bumped, []int64{utxo.Value}) < 10 {
+		t.Fatalf("替换交易手续费为%d，原手续费%d，新费率未达到10 sat/vB", fee, original.Fee)
+	}
+	change := bumped.TxOut[original.ChangeIndex].Value
+	if change >= original.ChangeAmount || change != original.ChangeAmount-(fee-original.Fee) {
+		t.Fatalf("找零为%d，原找零%d，增加的手续费应全部从找零中扣除", change, original.ChangeAmount)
+	}
+	if bumped.TxOut[1-original.ChangeIndex].Value != 60000 {
+		t.Fatal("替换交易不应改变支付金额")
+	}
+}
+
+func TestBumpFeeAppendsOtherInputType(t *testing.T) {
+	w := newTestWallet(t)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	trScript, _ := w.addressScript(P2TR)
+	utxo := UTXO{TxID: strings.Repeat("1", 64), Vout: 0, Value: 100000, PkScript: wpkhScript}
+	extra := UTXO{TxID: strings.Repeat("2", 64), Vout: 1, Value: 50000, PkScript: trScript}
+	original := rbfOriginal(t, w, utxo, 95000)
+
+	// 原找零不足以支付40 sat/vB的手续费，需要追加P2TR地址上的UTXO
+	bumpedHex, err := w.BumpFee(original.Hex, 40, P2WPKH, []UTXO{utxo, extra})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bumped := deserializeTx(t, bumpedHex)
+	if len(bumped.TxIn) != 2 {
+		t.Fatalf("应追加一个输入，实际有%d个输入", len(bumped.TxIn))
+	}
+	verifyTx(t, bumped, [][]byte{wpkhScript, trScript}, []int64{utxo.Value, extra.Value})
+	if rate := EffectiveFeeRate(bumped, []int64{utxo.Value, extra.Value}); rate < 40 {
+		t.Fatalf("替换交易费率为%.2f sat/vB，低于40", rate)
+	}
+}
+
+func TestBumpFeeRelayFloorAndLimits(t *testing.T) {
+	w := newTestWallet(t)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	utxo := UTXO{TxID: strings.Repeat("1", 64), Vout: 0, Value: 100000, PkScript: wpkhScript}
+	original := rbfOriginal(t, w, utxo, 60000)
+
+	// 新费率低于最低转发费率时，增加的手续费按最低转发费率计算
+	w.SetMinRelayFeeRate(5)
+	bumpedHex, err := w.BumpFee(original.Hex, 1, P2WPKH, []UTXO{utxo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bumped := deserializeTx(t, bumpedHex)
+	if fee := utxo.Value - txOutputTotal(bumped); fee < original.Fee+int64(5*TxVSize(bumped)) {
+		t.Fatalf("替换交易手续费为%d，应至少为原手续费%d加上5 sat/vB的中继费用", fee, original.Fee)
+	}
+
+	w.SetMinRelayFeeRate(0)
+	w.SetMaxFeeRate(20)
+	var tooHigh *FeeTooHighError
+	if _, err := w.BumpFee(original.Hex, 30, P2WPKH, []UTXO{utxo}); !errors.As(err, &tooHigh) {
+		t.Fatalf("超过最高费率时应返回FeeTooHighError，实际为%v", err)
+	}
+
+	w.SetRBF(false)
+	final, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "bump"), Amount: 60000}}, []UTXO{utxo})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.BumpFee(final.Hex, 10, P2WPKH, []UTXO{utxo}); err == nil {
+		t.Fatal("未声明RBF的交易不应被替换")
+	}
+}
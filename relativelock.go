@@ -0,0 +1,91 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+)
+
+// relativeLockTxVersion BIP68相对锁定时间要求的最低交易版本
+const relativeLockTxVersion = 2
+
+// SendWithRelativeLock 向多个地址转账，并按BIP68在每个输入的nSequence上设置以区块数计的相对锁定时间
+// 只选择确认数不少于blocks的UTXO，使交易广播后即可被打包。相对锁定要求交易版本至少为2，
+// 通过SetTxVersion设置了版本1时也会使用版本2；这样的nSequence同时声明了BIP125可替换
+func (w *BitcoinWallet) SendWithRelativeLock(fromAddrType AddressType, outputs []PaymentOutput, blocks uint16) (string, error) {
+	return w.SendWithRelativeLockContext(context.Background(), fromAddrType, outputs, blocks)
+}
+
+// SendWithRelativeLockContext 带相对锁定时间转账，支持通过ctx取消网络请求
+func (w *BitcoinWallet) SendWithRelativeLockContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	blocks uint16,
+) (string, error) {
+	prepared, err := w.prepareWithRelativeLock(ctx, fromAddrType, outputs, blocks)
+	if err != nil {
+		return "", err
+	}
+
+	return w.CommitContext(ctx, prepared)
+}
+
+// prepareWithRelativeLock 选择确认数足够的UTXO，构建带相对锁定时间的交易并签名
+func (w *BitcoinWallet) prepareWithRelativeLock(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	blocks uint16,
+) (*PreparedTx, error) {
+	if blocks == 0 {
+		return nil, fmt.Errorf("相对锁定区块数必须大于0")
+	}
+
+	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := w.fromAddrUTXOs(ctx, fromAddrType)
+	if err != nil {
+		return nil, err
+	}
+
+	// 确认数不足blocks的输入会使交易在锁定期满前无法被打包，节点拒绝转发
+	if err := w.fillConfirmations(ctx, utxos); err != nil {
+		return nil, err
+	}
+	matured := make([]UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if utxoConfirmations(utxo) >= int64(blocks) {
+			matured = append(matured, utxo)
+		}
+	}
+	if len(matured) == 0 {
+		return nil, fmt.Errorf("%w: 没有确认数达到%d的UTXO", ErrNoSpendableUTXOs, blocks)
+	}
+
+	selected, totalValue, fee, change, err := w.selectForOutputs(fromAddrType, matured, resolvedOutputs, totalAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvedOutputs = w.applyDustPolicy(resolvedOutputs, totalAmount, totalValue, fee, change)
+	built, err := w.buildTransaction(fromAddrType, selected, resolvedOutputs, change)
+	if err != nil {
+		return nil, fmt.Errorf("创建交易失败: %w", err)
+	}
+
+	// 签名哈希涵盖nSequence和交易版本，必须在签名之前写入
+	sequence := blockchain.LockTimeToSequence(false, uint32(blocks))
+	if built.Tx.Version < relativeLockTxVersion {
+		built.Tx.Version = relativeLockTxVersion
+	}
+	for _, txIn := range built.Tx.TxIn {
+		txIn.Sequence = sequence
+	}
+
+	return w.signBuilt(ctx, fromAddrType, built, totalAmount, totalValue, fee, change)
+}
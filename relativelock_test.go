@@ -0,0 +1,63 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSendWithRelativeLock(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	var sent string
+	// 最新区块高度为100，第一个UTXO有41个确认，第二个只有6个
+	utxosJSON := fmt.Sprintf(`[{"txid":"%s","vout":0,"value":30000,"status":{"confirmed":true,"block_height":60}},`+
+		`{"txid":"%s","vout":1,"value":80000,"status":{"confirmed":true,"block_height":95}}]`,
+		strings.Repeat("1", 64), strings.Repeat("2", 64))
+	newConfirmTestServer(t, w, utxosJSON, nil, func(txHex string) { sent = txHex })
+
+	outputs := []PaymentOutput{{Address: testAddress(t, "csv"), Amount: 20000}}
+	if _, err := w.SendWithRelativeLock(P2WPKH, outputs, 0); err == nil {
+		t.Fatal("锁定区块数为0时应返回错误")
+	}
+	if _, err := w.SendWithRelativeLock(P2WPKH, outputs, 144); !errors.Is(err, ErrNoSpendableUTXOs) {
+		t.Fatalf("没有确认数达到144的UTXO时应返回ErrNoSpendableUTXOs，实际为%v", err)
+	}
+
+	if _, err := w.SendWithRelativeLock(P2WPKH, outputs, 20); err != nil {
+		t.Fatal(err)
+	}
+	tx := deserializeTx(t, sent)
+	if tx.Version < 2 {
+		t.Fatalf("BIP68要求交易版本至少为2，实际为%d", tx.Version)
+	}
+	// 只有第一个UTXO的确认数达到20
+	if len(tx.TxIn) != 1 || tx.TxIn[0].PreviousOutPoint.Hash.String() != strings.Repeat("1", 64) {
+		t.Fatal("应只花费确认数达到锁定区块数的UTXO")
+	}
+	// 禁用位(bit 31)和类型位(bit 22)均为0，低16位为区块数
+	if tx.TxIn[0].Sequence != 20 {
+		t.Fatalf("nSequence为%#x，期望0x14", tx.TxIn[0].Sequence)
+	}
+
+	script, _ := w.addressScript(P2WPKH)
+	verifyTx(t, tx, [][]byte{script}, []int64{30000})
+}
+
+func TestRelativeLockOverridesTxVersion(t *testing.T) {
+	w := newTestWallet(t)
+	if err := w.SetTxVersion(1); err != nil {
+		t.Fatal(err)
+	}
+	var sent string
+	utxosJSON := fmt.Sprintf(`[{"txid":"%s","vout":0,"value":30000,"status":{"confirmed":true,"block_height":60}}]`, strings.Repeat("1", 64))
+	newConfirmTestServer(t, w, utxosJSON, nil, func(txHex string) { sent = txHex })
+
+	if _, err := w.SendWithRelativeLock(P2WPKH, []PaymentOutput{{Address: testAddress(t, "csv"), Amount: 20000}}, 10); err != nil {
+		t.Fatal(err)
+	}
+	if v := deserializeTx(t, sent).Version; v != 2 {
+		t.Fatalf("相对锁定交易的版本应为2，实际为%d", v)
+	}
+}
@@ -0,0 +1,49 @@
+package btc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// sigHashBaseMask 取出sighash基础类型(ALL/NONE/SINGLE)的掩码，与比特币核心一致
+const sigHashBaseMask = 0x1f
+
+// validateECDSASigHash 检查传统和SegWit v0签名使用的sighash类型
+// 只允许ALL、NONE、SINGLE及其ANYONECANPAY组合，SINGLE要求存在同索引的输出，否则返回ErrSigHashSingleNoOutput
+func validateECDSASigHash(tx *wire.MsgTx, idx int, hashType txscript.SigHashType) error {
+	if hashType == txscript.SigHashDefault {
+		return fmt.Errorf("SIGHASH_DEFAULT仅适用于Taproot签名")
+	}
+	return validateSigHashBase(tx, idx, hashType)
+}
+
+// validateTaprootSigHash 检查Taproot签名使用的sighash类型，额外允许SIGHASH_DEFAULT
+func validateTaprootSigHash(tx *wire.MsgTx, idx int, hashType txscript.SigHashType) error {
+	if hashType == txscript.SigHashDefault {
+		return nil
+	}
+	return validateSigHashBase(tx, idx, hashType)
+}
+
+// validateSigHashBase 检查sighash的基础类型和ANYONECANPAY标志
+func validateSigHashBase(tx *wire.MsgTx, idx int, hashType txscript.SigHashType) error {
+	if hashType&^(txscript.SigHashAnyOneCanPay|sigHashBaseMask) != 0 {
+		return fmt.Errorf("无效的sighash类型: 0x%02x", uint32(hashType))
+	}
+
+	switch hashType & sigHashBaseMask {
+	case txscript.SigHashAll, txscript.SigHashNone:
+		return nil
+	case txscript.SigHashSingle:
+		// 没有对应输出时传统签名会对常量1签名，签名可被挪用到任意交易；
+		// SegWit v0的hashOutputs为全零，签名不承诺任何输出；Taproot则直接无效。三种情况统一拒绝
+		if idx >= len(tx.TxOut) {
+			return fmt.Errorf("%w: 输入%d, 交易只有%d个输出", ErrSigHashSingleNoOutput, idx, len(tx.TxOut))
+		}
+		return nil
+	default:
+		return fmt.Errorf("无效的sighash类型: 0x%02x", uint32(hashType))
+	}
+}
@@ -0,0 +1,109 @@
+package btc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// signWithSigHash 按地址类型使用指定的sighash签名第idx个输入，前序输出金额为5000
+func signWithSigHash(w *BitcoinWallet, addrType AddressType, tx *wire.MsgTx, idx int, script []byte, hashType txscript.SigHashType) error {
+	switch addrType {
+	case P2PKH:
+		return w.SignP2PKHTransactionWithSigHash(tx, idx, script, hashType)
+	case P2WPKH:
+		return w.SignP2WPKHTransactionWithSigHash(tx, idx, 5000, script, hashType)
+	case P2SH:
+		return w.SignP2SHTransactionWithSigHash(tx, idx, 5000, script, hashType)
+	default:
+		return w.SignP2TRTransactionWithSigHash(tx, idx, 5000, script, hashType)
+	}
+}
+
+// executeInput 只验证第idx个输入，签名哈希按该输入的前序输出计算
+func executeInput(tx *wire.MsgTx, idx int, script []byte, value int64) error {
+	prevFetcher := txscript.NewCannedPrevOutputFetcher(script, value)
+	vm, err := txscript.NewEngine(
+		script, tx, idx, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(tx, prevFetcher), value, prevFetcher,
+	)
+	if err != nil {
+		return err
+	}
+	return vm.Execute()
+}
+
+func TestSignSingleAnyoneCanPay(t *testing.T) {
+	w := newTestWallet(t)
+	hashType := txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
+
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		t.Run(string(addrType), func(t *testing.T) {
+			script, _ := w.addressScript(addrType)
+			tx := wire.NewMsgTx(2)
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0), nil, nil))
+			tx.AddTxOut(wire.NewTxOut(1000, script))
+			tx.AddTxOut(wire.NewTxOut(2000, script))
+
+			if err := signWithSigHash(w, addrType, tx, 0, script, hashType); err != nil {
+				t.Fatal(err)
+			}
+
+			// 其他输入和不对应的输出不在签名范围内，修改后签名仍然有效
+			tx.TxIn[1].PreviousOutPoint.Index = 7
+			tx.TxOut[1].Value = 1
+			if err := executeInput(tx, 0, script, 5000); err != nil {
+				t.Fatalf("修改未覆盖的部分后验证失败: %v", err)
+			}
+
+			// 对应的输出在签名范围内
+			tx.TxOut[0].Value = 1
+			if err := executeInput(tx, 0, script, 5000); err == nil {
+				t.Fatal("修改对应的输出后签名不应有效")
+			}
+		})
+	}
+}
+
+func TestSignRejectsInvalidSigHash(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, script))
+
+	if err := w.SignP2WPKHTransactionWithSigHash(tx, 0, 5000, script, txscript.SigHashDefault); err == nil {
+		t.Fatal("ECDSA签名不应接受SIGHASH_DEFAULT")
+	}
+	if err := w.SignP2TRTransactionWithSigHash(tx, 0, 5000, script, 0x44); err == nil {
+		t.Fatal("不应接受无效的sighash类型")
+	}
+}
+
+func TestSignSingleWithoutMatchingOutput(t *testing.T) {
+	w := newTestWallet(t)
+
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		t.Run(string(addrType), func(t *testing.T) {
+			script, _ := w.addressScript(addrType)
+			tx := wire.NewMsgTx(2)
+			for i := 0; i < 3; i++ {
+				tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, 0), nil, nil))
+			}
+			tx.AddTxOut(wire.NewTxOut(1000, script))
+
+			if err := signWithSigHash(w, addrType, tx, 0, script, txscript.SigHashSingle); err != nil {
+				t.Fatal(err)
+			}
+
+			// 输入2没有对应的输出，legacy签名会变成对常数1签名，taproot则直接无效
+			err := signWithSigHash(w, addrType, tx, 2, script, txscript.SigHashSingle)
+			if !errors.Is(err, ErrSigHashSingleNoOutput) {
+				t.Fatalf("没有对应输出时应返回ErrSigHashSingleNoOutput，实际为%v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package btc
+
+import (
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// 交易固定部分大小: version(4) + locktime(4)
+const txOverheadSize = 8
+
+// segwit交易的marker和flag字节，只计入见证数据
+const witnessHeaderSize = 2
+
+// inputSize 返回花费指定类型输出的输入大小(基础字节数, 见证字节数)
+// 签名按最长的72字节DER编码计算，估算结果略偏保守
+func inputSize(addrType AddressType) (int, int) {
+	// outpoint(36) + scriptSig长度(1) + sequence(4)
+	const baseInput = 32 + 4 + 1 + 4
+
+	switch addrType {
+	case P2WPKH:
+		// 见证: 元素数(1) + 签名(1+72) + 公钥(1+33)
+		return baseInput, 1 + 1 + 72 + 1 + 33
+	case P2SH:
+		// scriptSig为赎回脚本推送(1+22)，见证与P2WPKH相同
+		return baseInput + 23, 1 + 1 + 72 + 1 + 33
+	case P2TR:
+		// 见证: 元素数(1) + schnorr签名(1+64)
+		return baseInput, 1 + 1 + 64
+	default:
+		// 传统输入: scriptSig为签名(1+72) + 压缩公钥(1+33)
+		return baseInput + 1 + 72 + 1 + 33, 0
+	}
+}
+
+// outputScriptSize 返回指定地址类型输出脚本的长度
+func outputScriptSize(addrType AddressType) int {
+	switch addrType {
+	case P2PKH:
+		return 25
+	case P2WPKH:
+		return 22
+	case P2SH:
+		return 23
+	case P2TR, P2WSH:
+		return 34
+	default:
+		return 25
+	}
+}
+
+// outputSize 返回输出脚本长度为scriptLen的输出大小: 金额(8) + 脚本长度 + 脚本
+func outputSize(scriptLen int) int {
+	return 8 + wire.VarIntSerializeSize(uint64(scriptLen)) + scriptLen
+}
+
+// EstimateTxSizeDetailed 按每个输入和输出的实际类型估算交易的虚拟大小(vbyte)
+func (w *BitcoinWallet) EstimateTxSizeDetailed(inputTypes []AddressType, outputTypes []AddressType) int {
+	outputSizes := make([]int, len(outputTypes))
+	for i, addrType := range outputTypes {
+		outputSizes[i] = outputSize(outputScriptSize(addrType))
+	}
+	return estimateVSize(inputTypes, outputSizes)
+}
+
+// estimateVSize 按输入类型和输出大小计算虚拟大小: weight = 基础大小*4 + 见证大小，vsize = weight/4 向上取整
+func estimateVSize(inputTypes []AddressType, outputSizes []int) int {
+	base := txOverheadSize +
+		wire.VarIntSerializeSize(uint64(len(inputTypes))) +
+		wire.VarIntSerializeSize(uint64(len(outputSizes)))
+
+	witness := 0
+	hasWitness := false
+	for _, addrType := range inputTypes {
+		inBase, inWitness := inputSize(addrType)
+		base += inBase
+		witness += inWitness
+		if inWitness > 0 {
+			hasWitness = true
+		}
+	}
+
+	for _, size := range outputSizes {
+		base += size
+	}
+
+	if hasWitness {
+		// 非见证输入在segwit交易中也需要一个表示空见证的字节
+		for _, addrType := range inputTypes {
+			if _, inWitness := inputSize(addrType); inWitness == 0 {
+				witness++
+			}
+		}
+		witness += witnessHeaderSize
+	}
+
+	weight := base*4 + witness
+	return (weight + 3) / 4
+}
+
+// scriptInputType 按输出脚本类别推断花费该输出的输入类型，P2SH按嵌套P2WPKH处理
+func scriptInputType(pkScript []byte) (AddressType, bool) {
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.PubKeyHashTy:
+		return P2PKH, true
+	case txscript.WitnessV0PubKeyHashTy:
+		return P2WPKH, true
+	case txscript.ScriptHashTy:
+		return P2SH, true
+	case txscript.WitnessV1TaprootTy:
+		return P2TR, true
+	default:
+		return "", false
+	}
+}
+
+// inputTypesFor 获取UTXO作为输入时的地址类型，带有输出脚本的UTXO按脚本识别
+func (w *BitcoinWallet) inputTypesFor(utxos []UTXO, fromAddrType AddressType) []AddressType {
+	types := make([]AddressType, len(utxos))
+	for i, utxo := range utxos {
+		types[i] = fromAddrType
+		if len(utxo.PkScript) > 0 {
+			if addrType, ok := w.ownScriptType(utxo.PkScript); ok {
+				types[i] = addrType
+			} else if addrType, ok := scriptInputType(utxo.PkScript); ok {
+				types[i] = addrType
+			}
+		}
+	}
+	return types
+}
@@ -0,0 +1,72 @@
+package btc
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestEstimateTxSizeDetailedMixed(t *testing.T) {
+	w := newTestWallet(t)
+	types := []AddressType{P2PKH, P2WPKH, P2SH, P2TR}
+
+	// 遍历输入类型的所有非空组合，输出包含全部四种类型
+	for mask := 1; mask < 1<<len(types); mask++ {
+		var inputTypes []AddressType
+		var inputs []InputInfo
+		tx := wire.NewMsgTx(2)
+		for i, addrType := range types {
+			if mask&(1<<i) == 0 {
+				continue
+			}
+			script, _ := w.addressScript(addrType)
+			inputTypes = append(inputTypes, addrType)
+			inputs = append(inputs, InputInfo{Value: 10000, PkScript: script, AddressType: addrType})
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, 0), nil, nil))
+		}
+		for _, addrType := range types {
+			script, _ := w.addressScript(addrType)
+			tx.AddTxOut(wire.NewTxOut(1000, script))
+		}
+
+		if err := w.SignTransactionMixed(tx, inputs); err != nil {
+			t.Fatal(err)
+		}
+
+		// 估算按72字节签名计算，每个输入最多多出1字节
+		estimated := w.EstimateTxSizeDetailed(inputTypes, types)
+		actual := TxVSize(tx)
+		if estimated < actual || estimated > actual+len(inputTypes)+1 {
+			t.Fatalf("输入%v: 估算%d vbyte，实际%d vbyte", inputTypes, estimated, actual)
+		}
+	}
+}
+
+func TestEstimateTxSizeNestedSegwit(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2SH)
+
+	for n := 1; n <= 3; n++ {
+		tx := wire.NewMsgTx(2)
+		utxos := make([]UTXO, n)
+		for i := range utxos {
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, 0), nil, nil))
+			utxos[i] = UTXO{Value: 10000}
+		}
+		tx.AddTxOut(wire.NewTxOut(1000, script))
+		tx.AddTxOut(wire.NewTxOut(1000, script))
+
+		if err := w.SignTransaction(tx, P2SH, utxos); err != nil {
+			t.Fatal(err)
+		}
+		verifyTx(t, tx, [][]byte{script, script, script}[:n], []int64{10000, 10000, 10000}[:n])
+
+		// 签名长度不定，每个输入允许1字节误差
+		estimated := w.EstimateTxSize(n, 2, P2SH)
+		actual := TxVSize(tx)
+		if estimated < actual || estimated > actual+n {
+			t.Fatalf("%d个输入: 估算%d vbyte，实际%d vbyte", n, estimated, actual)
+		}
+	}
+}
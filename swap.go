@@ -0,0 +1,66 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// swapSigHashType 原子交换输入使用的签名类型，只承诺本方输入和同索引的输出
+const swapSigHashType = txscript.SigHashSingle | txscript.SigHashAnyOneCanPay
+
+// CreateSwapInput 构建原子交换中本方的一半: 花费utxo并支付myOutput，
+// 以SIGHASH_SINGLE|ANYONECANPAY签名后返回部分签名交易的十六进制。
+// 对手方可以在其后追加自己的输入和输出并签名广播，本方签名仍然有效
+func (w *BitcoinWallet) CreateSwapInput(utxo UTXO, addrType AddressType, myOutput PaymentOutput) (string, error) {
+	outputs, _, err := w.resolvePaymentOutputs([]PaymentOutput{myOutput})
+	if err != nil {
+		return "", err
+	}
+
+	pkScript := utxo.PkScript
+	if len(pkScript) > 0 {
+		scriptType, ok := w.ownScriptType(pkScript)
+		if !ok {
+			return "", fmt.Errorf("UTXO的脚本不属于本钱包")
+		}
+		addrType = scriptType
+	} else {
+		pkScript, err = w.addressScript(addrType)
+		if err != nil {
+			return "", fmt.Errorf("创建发送方脚本失败: %w", err)
+		}
+	}
+
+	built, err := w.buildTransaction(addrType, []UTXO{utxo}, outputs, 0)
+	if err != nil {
+		return "", fmt.Errorf("创建交易失败: %w", err)
+	}
+	tx := built.Tx
+
+	signer := w.inputSigner(pkScript, addrType)
+	switch addrType {
+	case P2PKH:
+		err = signer.SignP2PKHTransactionWithSigHash(tx, 0, pkScript, swapSigHashType)
+	case P2WPKH:
+		err = signer.SignP2WPKHTransactionWithSigHash(tx, 0, utxo.Value, pkScript, swapSigHashType)
+	case P2SH:
+		err = signer.SignP2SHTransactionWithSigHash(tx, 0, utxo.Value, pkScript, swapSigHashType)
+	case P2TR:
+		err = signer.SignP2TRTransactionWithSigHash(tx, 0, utxo.Value, pkScript, swapSigHashType)
+	default:
+		return "", fmt.Errorf("不支持的地址类型: %s", addrType)
+	}
+	if err != nil {
+		return "", fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,41 @@
+package btc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestCreateSwapInputCounterpartyAppends(t *testing.T) {
+	maker := newTestWallet(t)
+	taker, err := NewWalletFromMnemonic(testMnemonic, "", TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	takerScript, _ := taker.addressScript(P2WPKH)
+	makerReceive, _ := maker.GetAddress(P2TR)
+
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		t.Run(string(addrType), func(t *testing.T) {
+			makerScript, _ := maker.addressScript(addrType)
+			utxo := UTXO{TxID: strings.Repeat("11", 32), Vout: 0, Value: 50000}
+
+			partial, err := maker.CreateSwapInput(utxo, addrType, PaymentOutput{Address: makerReceive, Amount: 30000})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 对手方追加自己的输入和输出后签名，双方的签名都有效
+			tx := deserializeTx(t, partial)
+			tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{9}, 1), nil, nil))
+			tx.AddTxOut(wire.NewTxOut(45000, takerScript))
+			if err := taker.SignP2WPKHTransaction(tx, 1, 40000, takerScript); err != nil {
+				t.Fatal(err)
+			}
+
+			verifyTx(t, tx, [][]byte{makerScript, takerScript}, []int64{50000, 40000})
+		})
+	}
+}
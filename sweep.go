@@ -0,0 +1,114 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// sweepAddressTypes 清扫私钥时检查资金的地址类型
+var sweepAddressTypes = []AddressType{P2PKH, P2WPKH, P2SH, P2TR}
+
+// SweepKey 将WIF私钥(如纸钱包)在各标准地址类型上的全部资金转到toAddress，返回交易ID
+func SweepKey(wif string, network Network, toAddress string, feeRate int64) (string, error) {
+	return SweepKeyContext(context.Background(), wif, network, toAddress, feeRate)
+}
+
+// SweepKeyContext 清扫WIF私钥的全部资金，支持通过ctx取消网络请求
+func SweepKeyContext(ctx context.Context, wif string, network Network, toAddress string, feeRate int64) (string, error) {
+	w, err := NewWallet(wif, network)
+	if err != nil {
+		return "", err
+	}
+	w.SetFeeRate(feeRate)
+
+	return w.sweep(ctx, toAddress)
+}
+
+// sweep 查询钱包所有标准地址类型的UTXO，并在一笔交易中全部转到toAddress
+func (w *BitcoinWallet) sweep(ctx context.Context, toAddress string) (string, error) {
+	targetAddr, err := w.decodeAndValidateAddress(toAddress)
+	if err != nil {
+		return "", err
+	}
+
+	targetScript, err := txscript.PayToAddrScript(targetAddr)
+	if err != nil {
+		return "", fmt.Errorf("创建接收方脚本失败: %w", err)
+	}
+
+	addresses := make([]string, 0, len(sweepAddressTypes))
+	for _, addrType := range sweepAddressTypes {
+		addr, err := w.GetAddress(addrType)
+		if err != nil {
+			return "", fmt.Errorf("获取%s地址失败: %w", addrType, err)
+		}
+		addresses = append(addresses, addr)
+	}
+
+	utxos, err := w.GetUTXOsForAddressesContext(ctx, addresses)
+	if err != nil {
+		return "", fmt.Errorf("获取UTXO失败: %w", err)
+	}
+
+	if len(utxos) == 0 {
+		return "", ErrNoUTXOs
+	}
+
+	// 按输入类型分别估算大小，各类型的交易头部开销会重复计入，估算结果偏保守
+	counts := make(map[AddressType]int)
+	var totalBalance int64
+	for i, utxo := range utxos {
+		addrType, ok := w.ownScriptType(utxo.PkScript)
+		if !ok {
+			return "", fmt.Errorf("输入%d的脚本不属于本钱包", i)
+		}
+		counts[addrType]++
+		totalBalance += utxo.Value
+	}
+
+	estimatedSize := wire.NewTxOut(0, targetScript).SerializeSize()
+	for addrType, count := range counts {
+		estimatedSize += w.EstimateTxSize(count, 0, addrType)
+	}
+
+	estimatedFee := w.feeForVSize(estimatedSize, w.feeRateMilli)
+
+	transferAmount := totalBalance - estimatedFee
+	if limit := dustLimit(targetScript); transferAmount < limit {
+		return "", newInsufficientFundsError(estimatedFee+limit, totalBalance, estimatedFee)
+	}
+
+	output := resolvedOutput{address: targetAddr, script: targetScript, amount: transferAmount}
+	built, err := w.buildTransaction(P2PKH, utxos, []resolvedOutput{output}, 0)
+	if err != nil {
+		return "", fmt.Errorf("创建交易失败: %w", err)
+	}
+	tx := built.Tx
+
+	// 每个UTXO都带有输出脚本，签名时按脚本识别各自的地址类型
+	if err = w.SignTransactionContext(ctx, tx, P2PKH, utxos); err != nil {
+		return "", fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	if err = w.checkMinRelayFee(tx, estimatedFee); err != nil {
+		return "", err
+	}
+
+	if w.verifyBeforeBroadcast {
+		if err = w.VerifyTransaction(tx, utxos, P2PKH); err != nil {
+			return "", fmt.Errorf("验证交易失败: %w", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return w.BroadcastTransactionContext(ctx, hex.EncodeToString(buf.Bytes()))
+}
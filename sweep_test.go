@@ -0,0 +1,89 @@
+package btc
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSweepAllAddressTypes(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(3)
+
+	// 每种地址类型上各有一个UTXO，P2SH地址上没有资金
+	funded := map[AddressType]int64{P2PKH: 10000, P2WPKH: 20000, P2TR: 30000}
+	responses := make(map[string]string)
+	scripts := make(map[string][]byte)
+	values := make(map[string]int64)
+	for i, addrType := range sweepAddressTypes {
+		addr, _ := w.GetAddress(addrType)
+		responses[addr] = "[]"
+		if value, ok := funded[addrType]; ok {
+			txID := strings.Repeat(fmt.Sprint(i+1), 64)
+			responses[addr] = fmt.Sprintf(`[{"txid":"%s","vout":0,"value":%d}]`, txID, value)
+			scripts[txID], _ = w.addressScript(addrType)
+			values[txID] = value
+		}
+	}
+
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/utxo"):
+			addr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/address/"), "/utxo")
+			rw.Write([]byte(responses[addr]))
+		case r.Method == http.MethodPost && r.URL.Path == "/tx":
+			body, _ := io.ReadAll(r.Body)
+			sent = string(body)
+			data, _ := hex.DecodeString(sent)
+			tx := wire.NewMsgTx(wire.TxVersion)
+			if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+				t.Errorf("解析广播的交易失败: %v", err)
+				http.Error(rw, err.Error(), http.StatusBadRequest)
+				return
+			}
+			rw.Write([]byte(tx.TxHash().String()))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	to := testAddress(t, "sweep")
+	txID, err := w.sweep(context.Background(), to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := deserializeTx(t, sent)
+	if tx.TxHash().String() != txID {
+		t.Fatal("返回的交易ID与广播的交易不一致")
+	}
+	if len(tx.TxIn) != len(funded) || len(tx.TxOut) != 1 {
+		t.Fatalf("应在一笔交易中花费%d个输入到单个输出，实际为%d个输入、%d个输出", len(funded), len(tx.TxIn), len(tx.TxOut))
+	}
+
+	prevScripts := make([][]byte, len(tx.TxIn))
+	prevValues := make([]int64, len(tx.TxIn))
+	for i, txIn := range tx.TxIn {
+		hash := txIn.PreviousOutPoint.Hash.String()
+		prevScripts[i], prevValues[i] = scripts[hash], values[hash]
+	}
+	verifyTx(t, tx, prevScripts, prevValues)
+
+	fee := 60000 - tx.TxOut[0].Value
+	if fee <= 0 || EffectiveFeeRate(tx, prevValues) < 3 {
+		t.Fatalf("清扫交易手续费为%d，费率低于3 sat/vB", fee)
+	}
+}
@@ -0,0 +1,190 @@
+package btc
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// assembleScriptTree 以叶子脚本构建Taproot脚本树
+func assembleScriptTree(leaves [][]byte) (*txscript.IndexedTapScriptTree, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("叶子脚本不能为空")
+	}
+
+	tapLeaves := make([]txscript.TapLeaf, len(leaves))
+	for i, script := range leaves {
+		if len(script) == 0 {
+			return nil, fmt.Errorf("叶子脚本%d为空", i)
+		}
+		tapLeaves[i] = txscript.NewBaseTapLeaf(script)
+	}
+
+	return txscript.AssembleTaprootScriptTree(tapLeaves...), nil
+}
+
+// GetTaprootAddressWithScripts 以钱包公钥为内部公钥，生成承诺了脚本树的P2TR地址
+// 返回地址和第一个叶子的序列化控制块，其他叶子的控制块通过TaprootControlBlock获取，
+// key-path签名需要的默克尔根通过TaprootMerkleRoot获取
+func (w *BitcoinWallet) GetTaprootAddressWithScripts(leaves [][]byte) (string, []byte, error) {
+	tree, err := assembleScriptTree(leaves)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rootHash := tree.RootNode.TapHash()
+	tapKey := txscript.ComputeTaprootOutputKey(w.publicKey, rootHash[:])
+	addr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(tapKey), w.network)
+	if err != nil {
+		return "", nil, fmt.Errorf("生成Taproot地址失败: %w", err)
+	}
+
+	ctrlBlock, err := w.controlBlock(tree, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return addr.String(), ctrlBlock, nil
+}
+
+// TaprootMerkleRoot 计算脚本树的默克尔根，用于SignP2TRKeyPath
+func (w *BitcoinWallet) TaprootMerkleRoot(leaves [][]byte) ([]byte, error) {
+	tree, err := assembleScriptTree(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	rootHash := tree.RootNode.TapHash()
+	return rootHash[:], nil
+}
+
+// TaprootControlBlock 获取脚本树中第index个叶子的序列化控制块，用于脚本路径花费
+func (w *BitcoinWallet) TaprootControlBlock(leaves [][]byte, index int) ([]byte, error) {
+	tree, err := assembleScriptTree(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.controlBlock(tree, index)
+}
+
+// controlBlock 序列化脚本树中第index个叶子的控制块
+func (w *BitcoinWallet) controlBlock(tree *txscript.IndexedTapScriptTree, index int) ([]byte, error) {
+	if index < 0 || index >= len(tree.LeafMerkleProofs) {
+		return nil, fmt.Errorf("叶子索引%d超出范围", index)
+	}
+
+	ctrlBlock := tree.LeafMerkleProofs[index].ToControlBlock(w.publicKey)
+	data, err := ctrlBlock.ToBytes()
+	if err != nil {
+		return nil, fmt.Errorf("序列化控制块失败: %w", err)
+	}
+
+	return data, nil
+}
+
+// SignP2TRKeyPath 通过key-path签名承诺了脚本树的P2TR输入，merkleRoot为空时等同于无脚本输出
+// values和prevScripts为交易全部输入的前序输出金额和脚本，按输入顺序一一对应
+func (w *BitcoinWallet) SignP2TRKeyPath(
+	tx *wire.MsgTx,
+	idx int,
+	values []int64,
+	prevScripts [][]byte,
+	merkleRoot []byte,
+) error {
+	sighashes, err := taprootSigHashes(tx, idx, values, prevScripts)
+	if err != nil {
+		return err
+	}
+
+	sig, err := w.taprootKeySpendSignature(
+		tx, sighashes, idx, values[idx], prevScripts[idx], merkleRoot, txscript.SigHashDefault,
+	)
+	if err != nil {
+		return fmt.Errorf("生成Taproot签名失败: %w", err)
+	}
+
+	tx.TxIn[idx].Witness = wire.TxWitness{sig}
+	return nil
+}
+
+// taprootSigHashes 校验前序输出并计算覆盖全部输入的签名哈希缓存
+func taprootSigHashes(tx *wire.MsgTx, idx int, values []int64, prevScripts [][]byte) (*txscript.TxSigHashes, error) {
+	if idx < 0 || idx >= len(tx.TxIn) {
+		return nil, fmt.Errorf("输入索引%d超出范围", idx)
+	}
+
+	if len(values) != len(tx.TxIn) || len(prevScripts) != len(tx.TxIn) {
+		return nil, fmt.Errorf("前序输出数量与交易输入数量(%d)不一致", len(tx.TxIn))
+	}
+
+	if txscript.GetScriptClass(prevScripts[idx]) != txscript.WitnessV1TaprootTy {
+		return nil, fmt.Errorf("输入%d不是P2TR输出", idx)
+	}
+
+	prevFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, txIn := range tx.TxIn {
+		prevFetcher.AddPrevOut(txIn.PreviousOutPoint, wire.NewTxOut(values[i], prevScripts[i]))
+	}
+	return txscript.NewTxSigHashes(tx, prevFetcher), nil
+}
+
+// SignP2TRScriptPath 通过脚本路径签名P2TR输入，witness为[签名, 叶子脚本, 控制块]
+// value为该输入的前序输出金额，prevScripts为交易全部输入的前序输出脚本，按输入顺序一一对应，
+// 叶子脚本需要本钱包私钥对应的x-only公钥签名(如 <pubkey> OP_CHECKSIG)
+// Taproot签名哈希覆盖全部输入的金额，多输入交易请使用SignP2TRScriptPathWithValues
+func (w *BitcoinWallet) SignP2TRScriptPath(
+	tx *wire.MsgTx,
+	idx int,
+	value int64,
+	prevScripts [][]byte,
+	tapLeafScript []byte,
+	controlBlock []byte,
+) error {
+	if len(tx.TxIn) != 1 {
+		return fmt.Errorf("交易有%d个输入，需要通过SignP2TRScriptPathWithValues提供全部输入的金额", len(tx.TxIn))
+	}
+
+	return w.SignP2TRScriptPathWithValues(tx, idx, []int64{value}, prevScripts, tapLeafScript, controlBlock)
+}
+
+// SignP2TRScriptPathWithValues 通过脚本路径签名P2TR输入
+// values和prevScripts为交易全部输入的前序输出金额和脚本，按输入顺序一一对应
+func (w *BitcoinWallet) SignP2TRScriptPathWithValues(
+	tx *wire.MsgTx,
+	idx int,
+	values []int64,
+	prevScripts [][]byte,
+	tapLeafScript []byte,
+	controlBlock []byte,
+) error {
+	sighashes, err := taprootSigHashes(tx, idx, values, prevScripts)
+	if err != nil {
+		return err
+	}
+
+	prevScript := prevScripts[idx]
+	ctrlBlock, err := txscript.ParseControlBlock(controlBlock)
+	if err != nil {
+		return fmt.Errorf("解析控制块失败: %w", err)
+	}
+
+	// 校验控制块中的内部公钥和默克尔路径确实承诺了该叶子脚本
+	if err := txscript.VerifyTaprootLeafCommitment(ctrlBlock, prevScript[2:], tapLeafScript); err != nil {
+		return fmt.Errorf("控制块与输出不匹配: %w", err)
+	}
+
+	tapLeaf := txscript.NewTapLeaf(ctrlBlock.LeafVersion, tapLeafScript)
+	sig, err := w.tapscriptSignature(
+		tx, sighashes, idx, values[idx], prevScript, tapLeaf, txscript.SigHashDefault,
+	)
+	if err != nil {
+		return fmt.Errorf("生成Tapscript签名失败: %w", err)
+	}
+
+	tx.TxIn[idx].Witness = wire.TxWitness{sig, tapLeafScript, controlBlock}
+	return nil
+}
@@ -0,0 +1,119 @@
+package btc
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// taprootOutputScript 解析地址并返回其输出脚本
+func taprootOutputScript(t *testing.T, w *BitcoinWallet, addr string) []byte {
+	t.Helper()
+
+	decoded, err := btcutil.DecodeAddress(addr, w.network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script, err := txscript.PayToAddrScript(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestSignP2TRScriptPath(t *testing.T) {
+	w := newTestWallet(t)
+
+	// 单叶子脚本: <本钱包x-only公钥> OP_CHECKSIG
+	leaf, err := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(w.publicKey)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, ctrlBlock, err := w.GetTaprootAddressWithScripts([][]byte{leaf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevScript := taprootOutputScript(t, w, addr)
+
+	newSpend := func() *wire.MsgTx {
+		tx := wire.NewMsgTx(2)
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+		tx.AddTxOut(wire.NewTxOut(9000, prevScript))
+		return tx
+	}
+
+	tx := newSpend()
+	if err := w.SignP2TRScriptPath(tx, 0, 10000, [][]byte{prevScript}, leaf, ctrlBlock); err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.TxIn[0].Witness) != 3 {
+		t.Fatalf("脚本路径见证应为[签名, 叶子脚本, 控制块]，实际有%d项", len(tx.TxIn[0].Witness))
+	}
+	verifyTx(t, tx, [][]byte{prevScript}, []int64{10000})
+
+	// 同一输出也可以用调整后的私钥通过key-path花费
+	merkleRoot, err := w.TaprootMerkleRoot([][]byte{leaf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx = newSpend()
+	if err := w.SignP2TRKeyPath(tx, 0, []int64{10000}, [][]byte{prevScript}, merkleRoot); err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, tx, [][]byte{prevScript}, []int64{10000})
+
+	// 叶子脚本与控制块承诺的不一致时拒绝签名
+	other := append([]byte{txscript.OP_DROP}, leaf...)
+	if err := w.SignP2TRScriptPath(newSpend(), 0, 10000, [][]byte{prevScript}, other, ctrlBlock); err == nil {
+		t.Fatal("未被承诺的叶子脚本应返回错误")
+	}
+}
+
+func TestTaprootScriptTreeBIP341Vector(t *testing.T) {
+	// BIP341 wallet-test-vectors.json scriptPubKey第1项: 单个叶子的脚本树
+	internalKey, _ := hex.DecodeString("187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27")
+	leaf, _ := hex.DecodeString("20d85a959b0290bf19bb89ed43c916be835475d013da4b362117393e25a48229b8ac")
+	const (
+		wantMerkleRoot   = "5b75adecf53548f3ec6ad7d78383bf84cc57b55a3127c72b9a2481752dd88b21"
+		wantOutputKey    = "147c9c57132f6e7ecddba9800bb0c4449251c92a1e60371ee77557b6620f3ea3"
+		wantAddress      = "bc1pz37fc4cn9ah8anwm4xqqhvxygjf9rjf2resrw8h8w4tmvcs0863sa2e586"
+		wantControlBlock = "c1187791b6f712a8ea41c8ecdd0ee77fab3e85263b37e1ec18a3651926b3a6cf27"
+	)
+
+	pubKey, err := schnorr.ParsePubKey(internalKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &BitcoinWallet{publicKey: pubKey, compressed: true, network: &chaincfg.MainNetParams}
+
+	addr, ctrlBlock, err := w.GetTaprootAddressWithScripts([][]byte{leaf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != wantAddress {
+		t.Fatalf("地址为%s，期望%s", addr, wantAddress)
+	}
+	if got := hex.EncodeToString(taprootOutputScript(t, w, addr)[2:]); got != wantOutputKey {
+		t.Fatalf("调整后的输出公钥为%s，期望%s", got, wantOutputKey)
+	}
+	if got := hex.EncodeToString(ctrlBlock); got != wantControlBlock {
+		t.Fatalf("控制块为%s，期望%s", got, wantControlBlock)
+	}
+
+	merkleRoot, err := w.TaprootMerkleRoot([][]byte{leaf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(merkleRoot); got != wantMerkleRoot {
+		t.Fatalf("默克尔根为%s，期望%s", got, wantMerkleRoot)
+	}
+}
@@ -0,0 +1,65 @@
+package btc
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testNet4Magic testnet4(BIP94)的网络魔数
+const testNet4Magic wire.BitcoinNet = 0x283f161c
+
+// testNet4GenesisMessage testnet4创世区块coinbase中的消息
+const testNet4GenesisMessage = "03/May/2024 000000000000000000001ebd58c244970b3aa9d783bb001011fbe8ea8e98e00e"
+
+// testNet4GenesisBlock testnet4创世区块，与Bitcoin Core的chainparams一致
+var testNet4GenesisBlock = newTestNet4GenesisBlock()
+
+// testNet4Params testnet4链参数，btcd尚未内置，地址和密钥前缀与testnet3相同
+// 只用于地址编码和交易构建，不注册到chaincfg，避免与testnet3的bech32前缀冲突
+var testNet4Params = newTestNet4Params()
+
+// newTestNet4GenesisBlock 按Bitcoin Core的CreateGenesisBlock构建testnet4创世区块
+func newTestNet4GenesisBlock() *wire.MsgBlock {
+	// Core以CScriptNum(4)压入单字节数据，AddData会将其转为OP_4，因此直接写入OP_DATA_1
+	sigScript, _ := txscript.NewScriptBuilder().
+		AddInt64(0x1d00ffff).
+		AddOps([]byte{txscript.OP_DATA_1, 4}).
+		AddData([]byte(testNet4GenesisMessage)).
+		Script()
+	pkScript, _ := txscript.NewScriptBuilder().
+		AddData(make([]byte, 33)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+
+	coinbase := wire.NewMsgTx(1)
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), sigScript, nil))
+	coinbase.AddTxOut(wire.NewTxOut(50*1e8, pkScript))
+
+	// 只有一笔交易时默克尔根就是该交易的哈希
+	merkleRoot := coinbase.TxHash()
+	block := wire.NewMsgBlock(wire.NewBlockHeader(1, &chainhash.Hash{}, &merkleRoot, 0x1d00ffff, 393743547))
+	block.Header.Timestamp = time.Unix(1714777860, 0)
+	block.AddTransaction(coinbase)
+	return block
+}
+
+// newTestNet4Params 以testnet3参数为基础设置testnet4的魔数、端口、创世区块和种子节点
+func newTestNet4Params() *chaincfg.Params {
+	params := chaincfg.TestNet3Params
+	params.Name = "testnet4"
+	params.Net = testNet4Magic
+	params.DefaultPort = "48333"
+	params.DNSSeeds = []chaincfg.DNSSeed{
+		{Host: "seed.testnet4.bitcoin.sprovoost.nl", HasFiltering: true},
+		{Host: "seed.testnet4.wiz.biz", HasFiltering: true},
+	}
+	params.GenesisBlock = testNet4GenesisBlock
+	genesisHash := testNet4GenesisBlock.BlockHash()
+	params.GenesisHash = &genesisHash
+	params.Checkpoints = nil
+	return &params
+}
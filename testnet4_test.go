@@ -0,0 +1,48 @@
+package btc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTestNet4Params(t *testing.T) {
+	// BIP94中testnet4创世区块的哈希和默克尔根
+	const (
+		wantGenesis = "00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043"
+		wantMerkle  = "7aa0a7ae1e223414cb807e40cd57e667b718e42aaf9306db9102fe28912b7b4e"
+	)
+	if got := testNet4Params.GenesisHash.String(); got != wantGenesis {
+		t.Fatalf("testnet4创世区块哈希为%s，期望%s", got, wantGenesis)
+	}
+	if got := testNet4GenesisBlock.Header.MerkleRoot.String(); got != wantMerkle {
+		t.Fatalf("testnet4默克尔根为%s，期望%s", got, wantMerkle)
+	}
+	if testNet4Params.Net != 0x283f161c || testNet4Params.DefaultPort != "48333" || testNet4Params.Bech32HRPSegwit != "tb" {
+		t.Fatalf("testnet4参数错误: 魔数%#x，端口%s，bech32前缀%s",
+			uint32(testNet4Params.Net), testNet4Params.DefaultPort, testNet4Params.Bech32HRPSegwit)
+	}
+
+	// testnet3的WIF同样适用于testnet4，地址为tb1开头
+	wif := mustWIF(t, newTestWallet(t))
+	w, err := NewWallet(wif, TestNet4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.network != testNet4Params || !strings.Contains(w.defaultAPIURL, "testnet4") {
+		t.Fatalf("testnet4钱包使用了%s网络和%s接口", w.network.Name, w.defaultAPIURL)
+	}
+
+	addr, err := w.GetAddress(P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(addr, "tb1q") {
+		t.Fatalf("testnet4的P2WPKH地址为%s，期望tb1q开头", addr)
+	}
+	if got, err := ValidateAddress(addr, TestNet4); err != nil || got != P2WPKH {
+		t.Fatalf("testnet4地址%s验证失败: %s, %v", addr, got, err)
+	}
+	if _, err := ValidateAddress(addr, MainNet); err == nil {
+		t.Fatal("testnet4地址在主网上应被拒绝")
+	}
+}
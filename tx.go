@@ -2,18 +2,57 @@ package btc
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"strings"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 )
 
+// dustThreshold 传统P2PKH输出的dust阈值，也用作无法识别找零脚本时的默认值
 const dustThreshold int64 = 546
 
+// dustRelayFeeRate Bitcoin Core计算dust使用的费率(sat/vB)
+const dustRelayFeeRate int64 = 3
+
+// dustLimit 按Bitcoin Core规则计算输出脚本的dust阈值:
+// (输出大小 + 花费该输出所需的输入大小) * dustRelayFeeRate
+func dustLimit(script []byte) int64 {
+	// 不可花费的输出(如OP_RETURN)没有dust限制
+	if len(script) > 0 && script[0] == txscript.OP_RETURN {
+		return 0
+	}
+
+	outputSize := int64(8 + wire.VarIntSerializeSize(uint64(len(script))) + len(script))
+
+	// 见证输入: outpoint(36) + scriptSig长度(1) + sequence(4) + 见证数据折算(107/4)
+	inputSize := int64(32 + 4 + 1 + 107/4 + 4)
+	if !txscript.IsWitnessProgram(script) {
+		// 传统输入: outpoint(36) + scriptSig(1+107) + sequence(4)
+		inputSize = 32 + 4 + 1 + 107 + 4
+	}
+
+	return (outputSize + inputSize) * dustRelayFeeRate
+}
+
+// changeDustLimit 获取找零输出的dust阈值
+func (w *BitcoinWallet) changeDustLimit(fromAddrType AddressType) int64 {
+	script, err := w.changeScript(fromAddrType)
+	if err != nil {
+		return dustThreshold
+	}
+	return dustLimit(script)
+}
+
+// rbfSequence BIP125可替换交易使用的输入序列号
+const rbfSequence uint32 = 0xfffffffd
+
 type PaymentOutput struct {
 	Address string
 	Amount  int64
@@ -26,35 +65,21 @@ type resolvedOutput struct {
 }
 
 func (w *BitcoinWallet) estimateFee(inputCount, outputCount int, addrType AddressType) int64 {
+	return w.estimateFeeAtMilli(inputCount, outputCount, addrType, w.feeRateMilli)
+}
+
+// estimateFeeAtMilli 按千分之一sat/vB为单位的费率估算手续费
+func (w *BitcoinWallet) estimateFeeAtMilli(inputCount, outputCount int, addrType AddressType, rateMilli int64) int64 {
 	size := w.EstimateTxSize(inputCount, outputCount, addrType)
 	if size <= 0 {
 		return 0
 	}
 
-	feeRate := w.feeRate
-	if feeRate <= 0 {
-		feeRate = 1
-	}
-
-	return int64(size) * feeRate
+	return w.feeForVSize(size, rateMilli)
 }
 
 func (w *BitcoinWallet) decodeAndValidateAddress(addr string) (btcutil.Address, error) {
-	trimmed := strings.TrimSpace(addr)
-	if trimmed == "" {
-		return nil, fmt.Errorf("地址不能为空")
-	}
-
-	decoded, err := btcutil.DecodeAddress(trimmed, w.network)
-	if err != nil {
-		return nil, fmt.Errorf("解析地址失败: %w", err)
-	}
-
-	if !decoded.IsForNet(w.network) {
-		return nil, fmt.Errorf("地址与当前网络不匹配")
-	}
-
-	return decoded, nil
+	return decodeAddress(addr, w.network)
 }
 
 func (w *BitcoinWallet) resolvePaymentOutputs(outputs []PaymentOutput) ([]resolvedOutput, int64, error) {
@@ -80,8 +105,8 @@ func (w *BitcoinWallet) resolvePaymentOutputs(outputs []PaymentOutput) ([]resolv
 			return nil, 0, fmt.Errorf("创建输出%d脚本失败: %w", idx, err)
 		}
 
-		if output.Amount < dustThreshold {
-			return nil, 0, fmt.Errorf("输出%d的金额低于dust阈值(%d)", idx, dustThreshold)
+		if limit := dustLimit(script); output.Amount < limit {
+			return nil, 0, fmt.Errorf("输出%d的金额低于dust阈值(%d)", idx, limit)
 		}
 
 		resolved = append(resolved, resolvedOutput{
@@ -102,7 +127,7 @@ func (w *BitcoinWallet) resolvePaymentOutputs(outputs []PaymentOutput) ([]resolv
 func (w *BitcoinWallet) computeFeeAndChange(
 	fromAddrType AddressType,
 	totalAmount int64,
-	outputCount int,
+	outputs []resolvedOutput,
 	utxos []UTXO,
 	totalValue int64,
 ) (fee int64, changeAmount int64) {
@@ -110,18 +135,36 @@ func (w *BitcoinWallet) computeFeeAndChange(
 		return 0, -totalAmount
 	}
 
-	feeNoChange := w.estimateFee(len(utxos), outputCount, fromAddrType)
+	// 按每个输入和输出的实际类型估算大小
+	inputTypes := w.inputTypesFor(utxos, fromAddrType)
+	outputSizes := make([]int, 0, len(outputs)+1)
+	for _, output := range outputs {
+		outputSizes = append(outputSizes, outputSize(len(output.script)))
+	}
+
+	feeNoChange := w.feeForVSize(estimateVSize(inputTypes, outputSizes), w.feeRateMilli)
 	changeNoChange := totalValue - totalAmount - feeNoChange
 	if changeNoChange < 0 {
 		return feeNoChange, changeNoChange
 	}
 
-	feeWithChange := w.estimateFee(len(utxos), outputCount+1, fromAddrType)
+	changeScriptLen := outputScriptSize(fromAddrType)
+	if script, err := w.changeScript(fromAddrType); err == nil {
+		changeScriptLen = len(script)
+	}
+	outputSizes = append(outputSizes, outputSize(changeScriptLen))
+
+	feeWithChange := w.feeForVSize(estimateVSize(inputTypes, outputSizes), w.feeRateMilli)
 	changeWithChange := totalValue - totalAmount - feeWithChange
-	if changeWithChange > dustThreshold {
+	if changeWithChange > w.changeDustLimit(fromAddrType) {
 		return feeWithChange, changeWithChange
 	}
 
+	// SendExact策略下剩余金额由applyDustPolicy加到收款输出，手续费按无找零估算
+	if w.dustPolicy == SendExact && len(outputs) == 1 {
+		return feeNoChange, 0
+	}
+
 	// 找零过小或不足以覆盖额外输出，直接作为手续费处理
 	actualFee := totalValue - totalAmount
 	if actualFee < 0 {
@@ -131,13 +174,194 @@ func (w *BitcoinWallet) computeFeeAndChange(
 	return actualFee, 0
 }
 
-// CreateTransaction 创建交易
+// utxoOutPoint 将UTXO转换为交易输入引用的outpoint
+func utxoOutPoint(utxo UTXO) (*wire.OutPoint, error) {
+	if utxo.TxID == "" {
+		return nil, fmt.Errorf("UTXO缺少交易ID")
+	}
+
+	txHash, err := chainhash.NewHashFromStr(utxo.TxID)
+	if err != nil {
+		return nil, fmt.Errorf("解析交易哈希失败: %w", err)
+	}
+
+	return wire.NewOutPoint(txHash, utxo.Vout), nil
+}
+
+// SetRBF 设置是否启用BIP125 Replace-By-Fee
+func (w *BitcoinWallet) SetRBF(enabled bool) {
+	w.rbf = enabled
+}
+
+// lockTimeThreshold 小于该值的nLockTime表示区块高度，否则表示Unix时间戳
+const lockTimeThreshold uint32 = 500000000
+
+// SetLockTime 设置交易的nLockTime，传入0表示不启用
+// 小于500000000时按区块高度解释，否则按Unix时间戳解释且不能早于创世区块时间
+func (w *BitcoinWallet) SetLockTime(lock uint32) error {
+	if lock >= lockTimeThreshold {
+		genesisTime := chaincfg.MainNetParams.GenesisBlock.Header.Timestamp.Unix()
+		if int64(lock) < genesisTime {
+			return fmt.Errorf("时间戳锁定时间早于创世区块: %d", lock)
+		}
+	}
+
+	w.lockTime = lock
+	return nil
+}
+
+// GetLockTime 获取交易的nLockTime
+func (w *BitcoinWallet) GetLockTime() uint32 {
+	return w.lockTime
+}
+
+// defaultTxVersion 新建交易默认使用的版本，版本2支持BIP68相对锁定时间，是目前的标准版本
+const defaultTxVersion int32 = 2
+
+// SetTxVersion 设置新建交易的版本号，只允许1或2，默认为2
+func (w *BitcoinWallet) SetTxVersion(v int32) error {
+	if v != 1 && v != 2 {
+		return fmt.Errorf("不支持的交易版本: %d，只允许1或2", v)
+	}
+	w.txVersion = v
+	return nil
+}
+
+// GetTxVersion 获取新建交易的版本号
+func (w *BitcoinWallet) GetTxVersion() int32 {
+	if w.txVersion == 0 {
+		return defaultTxVersion
+	}
+	return w.txVersion
+}
+
+// inputSequence 获取新建交易输入使用的序列号
+func (w *BitcoinWallet) inputSequence() uint32 {
+	if w.rbf {
+		return rbfSequence
+	}
+	if w.lockTime != 0 {
+		// nLockTime仅在存在非final输入时生效
+		return wire.MaxTxInSequenceNum - 1
+	}
+	return wire.MaxTxInSequenceNum
+}
+
+// DustPolicy 找零低于dust阈值时的处理方式
+type DustPolicy string
+
+const (
+	DustToFee DustPolicy = "fee"        // 并入手续费(默认)
+	SendExact DustPolicy = "send_exact" // 只有一个收款输出时加到收款金额上，避免多付手续费
+)
+
+// SetDustPolicy 设置找零低于dust阈值时的处理方式
+// SendExact会让收款方收到略多于指定金额的整数，可能暴露这是一笔无找零的整额花费，
+// 多个收款输出时仍按DustToFee处理，传入空字符串时恢复默认的DustToFee
+func (w *BitcoinWallet) SetDustPolicy(policy DustPolicy) error {
+	switch policy {
+	case "", DustToFee, SendExact:
+	default:
+		return fmt.Errorf("不支持的dust处理方式: %s", policy)
+	}
+
+	w.dustPolicy = policy
+	return nil
+}
+
+// GetDustPolicy 获取找零低于dust阈值时的处理方式
+func (w *BitcoinWallet) GetDustPolicy() DustPolicy {
+	if w.dustPolicy == "" {
+		return DustToFee
+	}
+	return w.dustPolicy
+}
+
+// applyDustPolicy 按SendExact策略将未作为找零输出的剩余金额加到唯一的收款输出上
+func (w *BitcoinWallet) applyDustPolicy(
+	outputs []resolvedOutput,
+	totalAmount int64,
+	totalValue int64,
+	fee int64,
+	changeAmount int64,
+) []resolvedOutput {
+	if w.dustPolicy != SendExact || changeAmount != 0 || len(outputs) != 1 {
+		return outputs
+	}
+
+	leftover := totalValue - totalAmount - fee
+	if leftover <= 0 {
+		return outputs
+	}
+
+	adjusted := []resolvedOutput{outputs[0]}
+	adjusted[0].amount += leftover
+	return adjusted
+}
+
+// SetChangeAddress 设置找零地址，传入空字符串时恢复为发送方地址
+func (w *BitcoinWallet) SetChangeAddress(addr string) error {
+	if strings.TrimSpace(addr) == "" {
+		w.changeAddress = nil
+		return nil
+	}
+
+	decoded, err := w.decodeAndValidateAddress(addr)
+	if err != nil {
+		return fmt.Errorf("找零地址无效: %w", err)
+	}
+
+	w.changeAddress = decoded
+	return nil
+}
+
+// hdChange 判断钱包是否由HD标准路径派生，此时找零使用账户内部链
+func (w *BitcoinWallet) hdChange() bool {
+	return w.hd != nil && w.hdAccount != ""
+}
+
+// changeScript 获取找零输出脚本，未设置找零地址时HD钱包使用内部链上的新地址，其余使用发送方地址
+func (w *BitcoinWallet) changeScript(fromAddrType AddressType) ([]byte, error) {
+	if w.changeAddress == nil && w.hdChange() {
+		// HD钱包的找零使用账户内部链上下一个未使用的地址，不复用接收地址；
+		// 交易广播成功后才将该索引标记为已使用，构建后未广播的交易不会消耗索引
+		script, err := w.hd.changeScript(w.hdAccount, w.hd.nextChangeIndex(w.hdAccount), fromAddrType)
+		if err != nil {
+			return nil, fmt.Errorf("创建找零脚本失败: %w", err)
+		}
+		return script, nil
+	}
+
+	if w.changeAddress == nil {
+		script, err := w.addressScript(fromAddrType)
+		if err != nil {
+			return nil, fmt.Errorf("创建找零脚本失败: %w", err)
+		}
+		return script, nil
+	}
+
+	script, err := txscript.PayToAddrScript(w.changeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("创建找零脚本失败: %w", err)
+	}
+	return script, nil
+}
+
+// TxBuildResult 构建交易的结果，标明哪个输出是找零
+type TxBuildResult struct {
+	Tx          *wire.MsgTx // 未签名的交易
+	Inputs      []UTXO      // 与交易输入顺序一致的UTXO，签名时应使用该顺序
+	ChangeIndex int         // 找零输出的索引，-1表示没有找零输出
+	Fee         int64       // 输入总额减去输出总额(satoshi)
+}
+
+// buildTransaction 创建交易，找零输出追加在所有支付输出之后，再按输出排序策略重排
 func (w *BitcoinWallet) buildTransaction(
 	fromAddrType AddressType,
 	utxos []UTXO,
 	outputs []resolvedOutput,
 	changeAmount int64,
-) (*wire.MsgTx, error) {
+) (*TxBuildResult, error) {
 	if len(outputs) == 0 {
 		return nil, fmt.Errorf("缺少交易输出")
 	}
@@ -146,12 +370,15 @@ func (w *BitcoinWallet) buildTransaction(
 		return nil, fmt.Errorf("找零金额无效: %d", changeAmount)
 	}
 
-	tx := wire.NewMsgTx(wire.TxVersion)
+	tx := wire.NewMsgTx(w.GetTxVersion())
+	tx.LockTime = w.lockTime
 
+	var totalIn int64
 	for idx, utxo := range utxos {
 		if utxo.TxID == "" {
 			return nil, fmt.Errorf("输入%d缺少交易ID", idx)
 		}
+		totalIn += utxo.Value
 
 		txHash, err := chainhash.NewHashFromStr(utxo.TxID)
 		if err != nil {
@@ -159,6 +386,7 @@ func (w *BitcoinWallet) buildTransaction(
 		}
 
 		txIn := wire.NewTxIn(wire.NewOutPoint(txHash, utxo.Vout), nil, nil)
+		txIn.Sequence = w.inputSequence()
 		tx.AddTxIn(txIn)
 	}
 
@@ -166,26 +394,28 @@ func (w *BitcoinWallet) buildTransaction(
 		tx.AddTxOut(wire.NewTxOut(output.amount, output.script))
 	}
 
-	if changeAmount > dustThreshold {
-		changeAddr, err := w.GetAddress(fromAddrType)
-		if err != nil {
-			return nil, fmt.Errorf("创建找零地址失败: %w", err)
-		}
-
-		changeAddrObj, err := btcutil.DecodeAddress(changeAddr, w.network)
+	changeIndex := -1
+	if changeAmount > 0 {
+		changeScript, err := w.changeScript(fromAddrType)
 		if err != nil {
-			return nil, fmt.Errorf("解析找零地址失败: %w", err)
+			return nil, err
 		}
 
-		changeScript, err := txscript.PayToAddrScript(changeAddrObj)
-		if err != nil {
-			return nil, fmt.Errorf("创建找零脚本失败: %w", err)
+		// 低于dust阈值的找零不创建输出，作为手续费处理
+		if changeAmount > dustLimit(changeScript) {
+			changeIndex = len(tx.TxOut)
+			tx.AddTxOut(wire.NewTxOut(changeAmount, changeScript))
 		}
-
-		tx.AddTxOut(wire.NewTxOut(changeAmount, changeScript))
 	}
 
-	return tx, nil
+	inputs, changeIndex := w.orderTransaction(tx, utxos, changeIndex)
+
+	return &TxBuildResult{
+		Tx:          tx,
+		Inputs:      inputs,
+		ChangeIndex: changeIndex,
+		Fee:         totalIn - txOutputTotal(tx),
+	}, nil
 }
 
 func (w *BitcoinWallet) CreateTransaction(
@@ -200,7 +430,11 @@ func (w *BitcoinWallet) CreateTransaction(
 		return nil, err
 	}
 
-	return w.buildTransaction(fromAddrType, utxos, resolved, changeAmount)
+	result, err := w.buildTransaction(fromAddrType, utxos, resolved, changeAmount)
+	if err != nil {
+		return nil, err
+	}
+	return result.Tx, nil
 }
 
 func (w *BitcoinWallet) CreateTransactionWithOutputs(
@@ -209,6 +443,20 @@ func (w *BitcoinWallet) CreateTransactionWithOutputs(
 	outputs []PaymentOutput,
 	changeAmount int64,
 ) (*wire.MsgTx, error) {
+	result, err := w.CreateTransactionWithOutputsResult(fromAddrType, utxos, outputs, changeAmount)
+	if err != nil {
+		return nil, err
+	}
+	return result.Tx, nil
+}
+
+// CreateTransactionWithOutputsResult 创建多输出交易并返回找零输出索引和手续费
+func (w *BitcoinWallet) CreateTransactionWithOutputsResult(
+	fromAddrType AddressType,
+	utxos []UTXO,
+	outputs []PaymentOutput,
+	changeAmount int64,
+) (*TxBuildResult, error) {
 	resolved, _, err := w.resolvePaymentOutputs(outputs)
 	if err != nil {
 		return nil, err
@@ -218,37 +466,126 @@ func (w *BitcoinWallet) CreateTransactionWithOutputs(
 }
 
 // SignTransaction 签名交易
+// UTXO携带PkScript时按其脚本识别本钱包对应的地址类型签名，否则按fromAddrType签名
+// 脚本不属于本钱包时返回ForeignUTXOError，启用SetVerifyUTXOOwnership后对未携带PkScript的UTXO同样校验
 func (w *BitcoinWallet) SignTransaction(tx *wire.MsgTx, fromAddrType AddressType, utxos []UTXO) error {
-	// 获取发送方地址
-	fromAddr, err := w.GetAddress(fromAddrType)
-	if err != nil {
-		return fmt.Errorf("获取发送方地址失败: %w", err)
+	return w.SignTransactionContext(context.Background(), tx, fromAddrType, utxos)
+}
+
+// SignTransactionContext 签名交易，支持通过ctx取消校验所有权时获取UTXO脚本的网络请求
+func (w *BitcoinWallet) SignTransactionContext(ctx context.Context, tx *wire.MsgTx, fromAddrType AddressType, utxos []UTXO) error {
+	if len(utxos) > len(tx.TxIn) {
+		return fmt.Errorf("UTXO数量(%d)超过交易输入数量(%d)", len(utxos), len(tx.TxIn))
 	}
 
-	fromAddrObj, err := btcutil.DecodeAddress(fromAddr, w.network)
-	if err != nil {
-		return fmt.Errorf("解析发送方地址失败: %w", err)
+	// 交易可能按BIP69重排过输入，先按outpoint对齐UTXO
+	utxos = alignUTXOs(tx, utxos)
+
+	var fromScript []byte
+	inputs := make([]InputInfo, len(utxos))
+
+	for i, utxo := range utxos {
+		if len(utxo.PkScript) == 0 && w.verifyOwnership {
+			script, err := w.utxoScript(ctx, utxo)
+			if err != nil {
+				return fmt.Errorf("获取输入%d的脚本失败: %w", i, err)
+			}
+			utxo.PkScript = script
+		}
+
+		if len(utxo.PkScript) > 0 {
+			addrType, ok := w.ownScriptType(utxo.PkScript)
+			if !ok {
+				return &ForeignUTXOError{Index: i, TxID: utxo.TxID, Vout: utxo.Vout, PkScript: utxo.PkScript}
+			}
+			inputs[i] = InputInfo{Value: utxo.Value, PkScript: utxo.PkScript, AddressType: addrType}
+			continue
+		}
+
+		if fromScript == nil {
+			// 获取发送方脚本
+			script, err := w.addressScript(fromAddrType)
+			if err != nil {
+				return fmt.Errorf("创建发送方脚本失败: %w", err)
+			}
+			fromScript = script
+		}
+		inputs[i] = InputInfo{Value: utxo.Value, PkScript: fromScript, AddressType: fromAddrType}
 	}
 
-	// 获取发送方脚本
-	fromScript, err := txscript.PayToAddrScript(fromAddrObj)
-	if err != nil {
-		return fmt.Errorf("创建发送方脚本失败: %w", err)
+	return w.signInputs(tx, inputs)
+}
+
+// InputInfo 签名单个输入所需的前序输出信息
+type InputInfo struct {
+	Value       int64
+	PkScript    []byte      // 前序输出脚本，为空时使用本钱包AddressType对应的脚本
+	AddressType AddressType // 签名使用的地址类型，为空时该输入只提供前序输出，不签名
+	PubKey      []byte      // 签名公钥(压缩格式)，仅SigHashes计算P2SH输入时需要，用于还原赎回脚本
+}
+
+// SignTransactionMixed 签名花费多种地址类型输入的交易，inputs按交易输入顺序一一对应
+func (w *BitcoinWallet) SignTransactionMixed(tx *wire.MsgTx, inputs []InputInfo) error {
+	if len(inputs) > len(tx.TxIn) {
+		return fmt.Errorf("输入信息数量(%d)超过交易输入数量(%d)", len(inputs), len(tx.TxIn))
 	}
 
-	// 根据地址类型选择签名方法
-	for i, utxo := range utxos {
-		switch fromAddrType {
+	resolved := make([]InputInfo, len(inputs))
+	for i, input := range inputs {
+		if len(input.PkScript) == 0 {
+			script, err := w.addressScript(input.AddressType)
+			if err != nil {
+				return fmt.Errorf("创建输入%d脚本失败: %w", i, err)
+			}
+			input.PkScript = script
+		}
+		resolved[i] = input
+	}
+
+	return w.signInputs(tx, resolved)
+}
+
+// signInputs 按每个输入的地址类型选择签名方法
+func (w *BitcoinWallet) signInputs(tx *wire.MsgTx, inputs []InputInfo) error {
+	// Taproot签名哈希需要覆盖全部输入的前序输出，缺少任何一个都无法计算
+	if len(inputs) < len(tx.TxIn) {
+		for i, input := range inputs {
+			if input.AddressType == P2TR {
+				return fmt.Errorf("输入%d为P2TR，需要提供全部%d个输入的前序输出，实际只有%d个", i, len(tx.TxIn), len(inputs))
+			}
+		}
+	}
+
+	prevFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, input := range inputs {
+		prevFetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, wire.NewTxOut(input.Value, input.PkScript))
+	}
+
+	for i, input := range inputs {
+		var err error
+		signer := w
+		if input.AddressType != "" {
+			signer = w.inputSigner(input.PkScript, input.AddressType)
+		}
+
+		switch input.AddressType {
 		case P2PKH:
-			err = w.SignP2PKHTransaction(tx, i, fromScript)
+			err = signer.SignP2PKHTransaction(tx, i, input.PkScript)
 		case P2WPKH:
-			err = w.SignP2WPKHTransaction(tx, i, utxo.Value, fromScript)
+			err = signer.SignP2WPKHTransaction(tx, i, input.Value, input.PkScript)
 		case P2SH:
-			err = w.SignP2SHTransaction(tx, i, utxo.Value, fromScript)
+			err = signer.SignP2SHTransaction(tx, i, input.Value, input.PkScript)
 		case P2TR:
-			err = w.SignP2TRTransaction(tx, i, utxo.Value, fromScript)
+			var sig []byte
+			sig, err = signer.taprootSignature(tx, i, input.Value, input.PkScript, prevFetcher, txscript.SigHashDefault)
+			if err == nil {
+				tx.TxIn[i].Witness = wire.TxWitness{sig}
+			}
+		case "":
+			// 不属于本钱包的输入只提供前序输出用于计算签名哈希，留给其他签名方
+			continue
 		default:
-			return fmt.Errorf("不支持的地址类型: %s", fromAddrType)
+			return fmt.Errorf("不支持的地址类型: %s", input.AddressType)
 		}
 
 		if err != nil {
@@ -264,146 +601,319 @@ func (w *BitcoinWallet) SendTransaction(fromAddrType AddressType, toAddress stri
 	return w.SendMany(fromAddrType, []PaymentOutput{{Address: toAddress, Amount: amount}})
 }
 
-func (w *BitcoinWallet) SendMany(fromAddrType AddressType, outputs []PaymentOutput) (string, error) {
-	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+// SendManyWithInputs 使用指定的UTXO向多个地址转账，跳过自动选择，返回交易ID
+func (w *BitcoinWallet) SendManyWithInputs(fromAddrType AddressType, outputs []PaymentOutput, inputs []UTXO) (string, error) {
+	return w.SendManyWithInputsContext(context.Background(), fromAddrType, outputs, inputs)
+}
+
+// SendManyWithInputsContext 使用指定的UTXO转账，支持通过ctx取消校验所有权和广播时的网络请求
+func (w *BitcoinWallet) SendManyWithInputsContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	inputs []UTXO,
+) (string, error) {
+	prepared, err := w.PrepareTransactionWithInputsContext(ctx, fromAddrType, outputs, inputs)
 	if err != nil {
 		return "", err
 	}
 
-	fromAddr, err := w.GetAddress(fromAddrType)
+	return w.CommitContext(ctx, prepared)
+}
+
+// SendFromAll 汇总钱包四种地址类型上的UTXO向多个地址转账，可一次合并分散在不同地址上的资金，返回交易ID
+func (w *BitcoinWallet) SendFromAll(outputs []PaymentOutput) (string, error) {
+	return w.SendFromAllContext(context.Background(), outputs)
+}
+
+// SendFromAllContext 汇总全部地址类型的UTXO转账，支持通过ctx取消网络请求
+func (w *BitcoinWallet) SendFromAllContext(ctx context.Context, outputs []PaymentOutput) (string, error) {
+	prepared, err := w.PrepareFromAllContext(ctx, outputs)
 	if err != nil {
-		return "", fmt.Errorf("获取发送方地址失败: %w", err)
+		return "", err
 	}
 
-	utxos, err := w.GetUTXOs(fromAddr)
+	return w.CommitContext(ctx, prepared)
+}
+
+// SendManyResult 转账结果，SendManyWithResult和SendAllWithResult共用
+type SendManyResult struct {
+	TxID         string
+	Hex          string // 已广播的已签名交易的十六进制数据，可用于存档或重新广播
+	Fee          int64  // 实际支付的手续费(satoshi)
+	ChangeAmount int64  // 找零金额，为0表示没有找零输出
+	ChangeIndex  int    // 找零输出的索引，-1表示没有找零输出
+	InputCount   int
+	VSize        int // 已签名交易的虚拟大小(vbytes)
+}
+
+// SendMany 向多个地址转账
+func (w *BitcoinWallet) SendMany(fromAddrType AddressType, outputs []PaymentOutput) (string, error) {
+	return w.SendManyContext(context.Background(), fromAddrType, outputs)
+}
+
+// SendManyContext 向多个地址转账，支持通过ctx取消网络请求
+func (w *BitcoinWallet) SendManyContext(ctx context.Context, fromAddrType AddressType, outputs []PaymentOutput) (string, error) {
+	result, err := w.SendManyWithResultContext(ctx, fromAddrType, outputs)
 	if err != nil {
-		return "", fmt.Errorf("获取UTXO失败: %w", err)
+		return "", err
 	}
+	return result.TxID, nil
+}
 
-	if len(utxos) == 0 {
-		return "", fmt.Errorf("没有可用的UTXO")
+// SendManyWithResult 向多个地址转账并返回手续费、找零等详细信息
+func (w *BitcoinWallet) SendManyWithResult(fromAddrType AddressType, outputs []PaymentOutput) (*SendManyResult, error) {
+	return w.SendManyWithResultContext(context.Background(), fromAddrType, outputs)
+}
+
+// SendManyWithResultContext 向多个地址转账并返回详细信息，支持通过ctx取消网络请求
+func (w *BitcoinWallet) SendManyWithResultContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+) (*SendManyResult, error) {
+	prepared, err := w.PrepareTransactionContext(ctx, fromAddrType, outputs)
+	if err != nil {
+		return nil, err
 	}
 
-	requiredAmount := totalAmount
-	var selectedUTXOs []UTXO
-	var totalValue int64
-	var estimatedFee int64
-	var changeAmount int64
+	txID, err := w.CommitContext(ctx, prepared)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		selectedUTXOs, totalValue, err = w.SelectUTXOs(utxos, requiredAmount)
-		if err != nil {
-			return "", fmt.Errorf("选择UTXO失败: %w", err)
-		}
+	return &SendManyResult{
+		TxID:         txID,
+		Hex:          prepared.Hex,
+		Fee:          prepared.Fee,
+		ChangeAmount: prepared.ChangeAmount,
+		ChangeIndex:  prepared.ChangeIndex,
+		InputCount:   len(prepared.Inputs),
+		VSize:        prepared.VSize,
+	}, nil
+}
 
-		estimatedFee, changeAmount = w.computeFeeAndChange(fromAddrType, totalAmount, len(resolvedOutputs), selectedUTXOs, totalValue)
-		if changeAmount >= 0 {
-			break
-		}
+// TxVSize 计算交易的虚拟大小: weight = 基础大小*3 + 完整大小，vsize = weight/4 向上取整
+func TxVSize(tx *wire.MsgTx) int {
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	return (weight + 3) / 4
+}
+
+// EffectiveFeeRate 计算交易实际支付的费率(sat/vB)，inputValues为各输入的前序输出金额
+func EffectiveFeeRate(tx *wire.MsgTx, inputValues []int64) float64 {
+	var fee int64
+	for _, value := range inputValues {
+		fee += value
+	}
+	for _, out := range tx.TxOut {
+		fee -= out.Value
+	}
 
-		requiredAmount = totalAmount + estimatedFee
+	vsize := TxVSize(tx)
+	if vsize == 0 {
+		return 0
 	}
+	return float64(fee) / float64(vsize)
+}
+
+// MaxSendable 查询发送方地址的UTXO，返回SendAll可转出的金额和手续费，不构建也不广播交易
+func (w *BitcoinWallet) MaxSendable(fromAddrType AddressType) (int64, int64, error) {
+	return w.MaxSendableContext(context.Background(), fromAddrType)
+}
 
-	tx, err := w.buildTransaction(fromAddrType, selectedUTXOs, resolvedOutputs, changeAmount)
+// MaxSendableContext 返回SendAll可转出的金额和手续费，支持通过ctx取消网络请求
+func (w *BitcoinWallet) MaxSendableContext(ctx context.Context, fromAddrType AddressType) (int64, int64, error) {
+	fromAddr, err := w.GetAddress(fromAddrType)
 	if err != nil {
-		return "", fmt.Errorf("创建交易失败: %w", err)
+		return 0, 0, fmt.Errorf("获取发送方地址失败: %w", err)
 	}
 
-	if err = w.SignTransaction(tx, fromAddrType, selectedUTXOs); err != nil {
-		return "", fmt.Errorf("签名交易失败: %w", err)
+	utxos, err := w.spendableUTXOs(ctx, fromAddr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取UTXO失败: %w", err)
 	}
 
-	var buf bytes.Buffer
-	if err = tx.Serialize(&buf); err != nil {
-		return "", fmt.Errorf("序列化交易失败: %w", err)
+	if err = w.checkFromAddrType(ctx, fromAddrType, utxos); err != nil {
+		return 0, 0, err
 	}
 
-	txHex := hex.EncodeToString(buf.Bytes())
-	return w.BroadcastTransaction(txHex)
+	// 地址上没有任何UTXO
+	if len(utxos) == 0 {
+		return 0, 0, ErrNoUTXOs
+	}
+
+	// 冻结和确认数不足的UTXO不参与转出
+	utxos = w.filterFrozen(utxos)
+	if len(utxos) == 0 {
+		return 0, 0, fmt.Errorf("%w: 全部UTXO已被冻结", ErrNoSpendableUTXOs)
+	}
+	utxos = w.filterByConfirmations(utxos)
+	if len(utxos) == 0 {
+		return 0, 0, fmt.Errorf("%w: 没有满足最小确认数(%d)的UTXO", ErrNoSpendableUTXOs, w.minConfirmations)
+	}
+
+	// 接收方未知，按与发送方相同类型的输出估算
+	return w.sendAllAmount(utxos, fromAddrType, outputScriptSize(fromAddrType))
+}
+
+// sendAllAmount 计算花费全部UTXO并只有一个长度为scriptLen的输出时的转账金额和手续费
+func (w *BitcoinWallet) sendAllAmount(utxos []UTXO, fromAddrType AddressType, scriptLen int) (int64, int64, error) {
+	// 计算总余额
+	var totalBalance int64
+	for _, utxo := range utxos {
+		totalBalance += utxo.Value
+	}
+
+	// 按每个输入的实际类型和输出脚本长度估算手续费
+	estimatedSize := estimateVSize(w.inputTypesFor(utxos, fromAddrType), []int{outputSize(scriptLen)})
+	estimatedFee := w.feeForVSize(estimatedSize, w.feeRateMilli)
+
+	// 计算实际转账金额，余额必须在支付手续费后仍有剩余
+	transferAmount := totalBalance - estimatedFee
+
+	if transferAmount <= 0 {
+		return 0, 0, newInsufficientFundsError(estimatedFee+1, totalBalance, estimatedFee)
+	}
+
+	return transferAmount, estimatedFee, nil
 }
 
-// SendAll 发送全部余额
+// SendAll 发送全部余额，冻结的UTXO不会被转出
 func (w *BitcoinWallet) SendAll(fromAddrType AddressType, toAddress string) (string, error) {
+	return w.SendAllContext(context.Background(), fromAddrType, toAddress)
+}
+
+// SendAllContext 发送全部余额，支持通过ctx取消网络请求
+func (w *BitcoinWallet) SendAllContext(ctx context.Context, fromAddrType AddressType, toAddress string) (string, error) {
+	result, err := w.SendAllWithResultContext(ctx, fromAddrType, toAddress)
+	if result == nil {
+		return "", err
+	}
+	return result.TxID, err
+}
+
+// SendAllWithResult 发送全部余额并返回手续费、已签名交易等详细信息
+func (w *BitcoinWallet) SendAllWithResult(fromAddrType AddressType, toAddress string) (*SendManyResult, error) {
+	return w.SendAllWithResultContext(context.Background(), fromAddrType, toAddress)
+}
+
+// SendAllWithResultContext 发送全部余额并返回详细信息，支持通过ctx取消网络请求
+// 服务端返回的交易ID不一致时同时返回结果和*TxIDMismatchError，此时交易已被服务端接受
+func (w *BitcoinWallet) SendAllWithResultContext(
+	ctx context.Context,
+	fromAddrType AddressType,
+	toAddress string,
+) (*SendManyResult, error) {
 	targetAddr, err := w.decodeAndValidateAddress(toAddress)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	fromAddr, err := w.GetAddress(fromAddrType)
 	if err != nil {
-		return "", fmt.Errorf("获取发送方地址失败: %w", err)
+		return nil, fmt.Errorf("获取发送方地址失败: %w", err)
 	}
 
-	utxos, err := w.GetUTXOs(fromAddr)
+	utxos, err := w.spendableUTXOs(ctx, fromAddr)
 	if err != nil {
-		return "", fmt.Errorf("获取UTXO失败: %w", err)
+		return nil, fmt.Errorf("获取UTXO失败: %w", err)
 	}
 
-	if len(utxos) == 0 {
-		return "", fmt.Errorf("没有可用的UTXO")
+	if err = w.checkFromAddrType(ctx, fromAddrType, utxos); err != nil {
+		return nil, err
 	}
 
-	// 计算总余额
-	var totalBalance int64
-	for _, utxo := range utxos {
-		totalBalance += utxo.Value
+	// 地址上没有任何UTXO
+	if len(utxos) == 0 {
+		return nil, ErrNoUTXOs
 	}
 
-	// 估算手续费
-	estimatedSize := w.EstimateTxSize(len(utxos), 1, fromAddrType) // 1个输出
-	feeRate := w.feeRate
-	if feeRate <= 0 {
-		feeRate = 1
+	// 冻结和确认数不足的UTXO不参与转出
+	utxos = w.filterFrozen(utxos)
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("%w: 全部UTXO已被冻结", ErrNoSpendableUTXOs)
+	}
+	utxos = w.filterByConfirmations(utxos)
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("%w: 没有满足最小确认数(%d)的UTXO", ErrNoSpendableUTXOs, w.minConfirmations)
 	}
-	estimatedFee := int64(estimatedSize) * feeRate
 
-	// 计算实际转账金额
-	transferAmount := totalBalance - estimatedFee
+	// 创建接收方输出脚本
+	receiverScript, err := txscript.PayToAddrScript(targetAddr)
+	if err != nil {
+		return nil, fmt.Errorf("创建接收方脚本失败: %w", err)
+	}
 
-	if transferAmount <= 0 {
-		return "", fmt.Errorf("余额不足以支付手续费")
+	transferAmount, fee, err := w.sendAllAmount(utxos, fromAddrType, len(receiverScript))
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建交易
-	tx := wire.NewMsgTx(wire.TxVersion)
+	tx := wire.NewMsgTx(w.GetTxVersion())
+	tx.LockTime = w.lockTime
 
 	// 添加所有输入
 	for _, utxo := range utxos {
 		txHash, err := chainhash.NewHashFromStr(utxo.TxID)
 		if err != nil {
-			return "", fmt.Errorf("解析交易哈希失败: %w", err)
+			return nil, fmt.Errorf("解析交易哈希失败: %w", err)
 		}
 
 		txIn := wire.NewTxIn(wire.NewOutPoint(txHash, utxo.Vout), nil, nil)
+		txIn.Sequence = w.inputSequence()
 		tx.AddTxIn(txIn)
 	}
 
-	// 创建接收方输出脚本
-	receiverScript, err := txscript.PayToAddrScript(targetAddr)
+	// 添加接收方输出（全部余额减去手续费）
+	tx.AddTxOut(wire.NewTxOut(transferAmount, receiverScript))
+
+	// 金额为0的UTXO从链上补全金额和脚本，避免segwit签名使用错误的金额
+	utxos, err = w.fillPrevouts(ctx, utxos)
 	if err != nil {
-		return "", fmt.Errorf("创建接收方脚本失败: %w", err)
+		return nil, err
 	}
 
-	// 添加接收方输出（全部余额减去手续费）
-	tx.AddTxOut(wire.NewTxOut(transferAmount, receiverScript))
+	// 按输出排序策略排列输入输出，utxos随之与交易输入对齐
+	utxos, _ = w.orderTransaction(tx, utxos, -1)
 
 	// 签名交易
-	err = w.SignTransaction(tx, fromAddrType, utxos)
+	err = w.SignTransactionContext(ctx, tx, fromAddrType, utxos)
 	if err != nil {
-		return "", fmt.Errorf("签名交易失败: %w", err)
+		return nil, fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	if err = w.checkMinRelayFee(tx, fee); err != nil {
+		return nil, err
+	}
+
+	if err = w.checkFeeLimits(fee, transferAmount, TxVSize(tx)); err != nil {
+		return nil, err
 	}
 
 	// 序列化交易
 	var buf bytes.Buffer
 	err = tx.Serialize(&buf)
 	if err != nil {
-		return "", fmt.Errorf("序列化交易失败: %w", err)
+		return nil, fmt.Errorf("序列化交易失败: %w", err)
 	}
 
 	txHex := hex.EncodeToString(buf.Bytes())
 
 	// 广播交易
-	return w.BroadcastTransaction(txHex)
+	txID, err := w.BroadcastTransactionContext(ctx, txHex)
+	if txID == "" {
+		return nil, err
+	}
+
+	return &SendManyResult{
+		TxID:        txID,
+		Hex:         txHex,
+		Fee:         fee,
+		ChangeIndex: -1,
+		InputCount:  len(tx.TxIn),
+		VSize:       TxVSize(tx),
+	}, err
 }
 
 // CreateRawTransaction 创建原始交易（不签名）
@@ -416,48 +926,145 @@ func (w *BitcoinWallet) CreateRawTransaction(
 	return w.CreateRawTransactionWithOutputs(fromAddrType, []PaymentOutput{{Address: toAddress, Amount: amount}}, utxos)
 }
 
+// CreateRawTransactionWithOutputs 构建未签名交易并以十六进制返回序列化结果
 func (w *BitcoinWallet) CreateRawTransactionWithOutputs(
 	fromAddrType AddressType,
 	outputs []PaymentOutput,
 	utxos []UTXO,
 ) (string, error) {
-	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	data, err := w.CreateRawTransactionWithOutputsBytes(fromAddrType, outputs, utxos)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// CreateRawTransactionWithOutputsBase64 构建未签名交易并以标准base64编码返回序列化结果
+// 返回的是原始交易而不是PSBT，需要PSBT时使用CreatePSBT
+func (w *BitcoinWallet) CreateRawTransactionWithOutputsBase64(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) (string, error) {
+	data, err := w.CreateRawTransactionWithOutputsBytes(fromAddrType, outputs, utxos)
 	if err != nil {
 		return "", err
 	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// CreateRawTransactionWithOutputsBytes 构建未签名交易并返回序列化后的原始字节
+func (w *BitcoinWallet) CreateRawTransactionWithOutputsBytes(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) ([]byte, error) {
+	tx, err := w.buildUnsignedTransaction(fromAddrType, outputs, utxos)
+	if err != nil {
+		return nil, err
+	}
 
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return nil, fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// buildUnsignedTransaction 使用全部给定UTXO计算手续费和找零并构建未签名交易
+func (w *BitcoinWallet) buildUnsignedTransaction(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) (*wire.MsgTx, error) {
+	resolvedOutputs, totalAmount, err := w.resolvePaymentOutputs(outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.buildUnsignedFromResolved(fromAddrType, resolvedOutputs, totalAmount, utxos)
+}
+
+// buildUnsignedFromResolved 使用已解析的输出构建未签名交易
+func (w *BitcoinWallet) buildUnsignedFromResolved(
+	fromAddrType AddressType,
+	resolvedOutputs []resolvedOutput,
+	totalAmount int64,
+	utxos []UTXO,
+) (*wire.MsgTx, error) {
 	if len(utxos) == 0 {
-		return "", fmt.Errorf("没有可用的UTXO")
+		return nil, ErrNoUTXOs
 	}
 
 	var totalValue int64
 	for _, utxo := range utxos {
 		totalValue += utxo.Value
 		if totalValue < 0 {
-			return "", fmt.Errorf("UTXO金额总和溢出")
+			return nil, fmt.Errorf("UTXO金额总和溢出")
 		}
 	}
 
-	_, changeAmount := w.computeFeeAndChange(fromAddrType, totalAmount, len(resolvedOutputs), utxos, totalValue)
+	fee, changeAmount := w.computeFeeAndChange(fromAddrType, totalAmount, resolvedOutputs, utxos, totalValue)
 	if changeAmount < 0 {
-		return "", fmt.Errorf("余额不足以支付金额和手续费")
+		return nil, newInsufficientFundsError(totalAmount+fee, totalValue, fee)
 	}
 
-	tx, err := w.buildTransaction(fromAddrType, utxos, resolvedOutputs, changeAmount)
+	resolvedOutputs = w.applyDustPolicy(resolvedOutputs, totalAmount, totalValue, fee, changeAmount)
+	built, err := w.buildTransaction(fromAddrType, utxos, resolvedOutputs, changeAmount)
 	if err != nil {
-		return "", fmt.Errorf("创建交易失败: %w", err)
+		return nil, fmt.Errorf("创建交易失败: %w", err)
 	}
 
-	var buf bytes.Buffer
-	if err = tx.Serialize(&buf); err != nil {
-		return "", fmt.Errorf("序列化交易失败: %w", err)
+	return built.Tx, nil
+}
+
+// fillPrevouts 为金额为0的UTXO从链上补全金额和输出脚本，返回副本，同一交易只请求一次
+func (w *BitcoinWallet) fillPrevouts(ctx context.Context, utxos []UTXO) ([]UTXO, error) {
+	filled := append([]UTXO(nil), utxos...)
+	fetched := make(map[string]*wire.MsgTx)
+
+	for i, utxo := range filled {
+		if utxo.Value != 0 {
+			continue
+		}
+
+		prevTx, ok := fetched[utxo.TxID]
+		if !ok {
+			var err error
+			prevTx, err = w.fetchTransaction(ctx, utxo.TxID)
+			if err != nil {
+				return nil, fmt.Errorf("获取输入%d的前序交易失败: %w", i, err)
+			}
+			fetched[utxo.TxID] = prevTx
+		}
+
+		if int(utxo.Vout) >= len(prevTx.TxOut) {
+			return nil, fmt.Errorf("交易%s没有输出%d", utxo.TxID, utxo.Vout)
+		}
+		output := prevTx.TxOut[utxo.Vout]
+		filled[i].Value = output.Value
+		if len(utxo.PkScript) == 0 {
+			filled[i].PkScript = output.PkScript
+		}
 	}
 
-	return hex.EncodeToString(buf.Bytes()), nil
+	return filled, nil
 }
 
 // SignRawTransaction 签名原始交易
+// UTXO的Value为0时按TxID和Vout从链上获取前序输出的金额和脚本，调用方可以只提供outpoint
 func (w *BitcoinWallet) SignRawTransaction(txHex string, fromAddrType AddressType, utxos []UTXO) (string, error) {
+	return w.SignRawTransactionContext(context.Background(), txHex, fromAddrType, utxos)
+}
+
+// SignRawTransactionContext 签名原始交易，支持通过ctx取消补全前序输出的请求
+func (w *BitcoinWallet) SignRawTransactionContext(
+	ctx context.Context,
+	txHex string,
+	fromAddrType AddressType,
+	utxos []UTXO,
+) (string, error) {
 	// 解码交易
 	data, err := hex.DecodeString(txHex)
 	if err != nil {
@@ -470,8 +1077,14 @@ func (w *BitcoinWallet) SignRawTransaction(txHex string, fromAddrType AddressTyp
 		return "", fmt.Errorf("反序列化交易失败: %w", err)
 	}
 
+	// 金额为0的UTXO从链上补全金额和脚本，避免segwit签名使用错误的金额
+	utxos, err = w.fillPrevouts(ctx, utxos)
+	if err != nil {
+		return "", err
+	}
+
 	// 签名交易
-	err = w.SignTransaction(tx, fromAddrType, utxos)
+	err = w.SignTransactionContext(ctx, tx, fromAddrType, utxos)
 	if err != nil {
 		return "", fmt.Errorf("签名交易失败: %w", err)
 	}
@@ -485,3 +1098,57 @@ func (w *BitcoinWallet) SignRawTransaction(txHex string, fromAddrType AddressTyp
 
 	return hex.EncodeToString(buf.Bytes()), nil
 }
+
+// PrevOut 交易输入花费的前序输出
+type PrevOut struct {
+	Value    int64  // 前序输出金额(satoshi)
+	PkScript []byte // 前序输出脚本
+}
+
+// SignRawTransactionWithPrevouts 签名外部构建的未签名交易，prevouts按交易输入顺序提供每个输入的前序输出
+// 各输入按脚本识别本钱包的地址类型并使用对应的签名方法，不属于本钱包的输入保持不变，留给其他签名方
+func (w *BitcoinWallet) SignRawTransactionWithPrevouts(txHex string, prevouts []PrevOut) (string, error) {
+	data, err := hex.DecodeString(strings.TrimSpace(txHex))
+	if err != nil {
+		return "", fmt.Errorf("解码十六进制失败: %w", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err = tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("反序列化交易失败: %w", err)
+	}
+
+	// Taproot签名哈希覆盖全部输入的前序输出，因此每个输入都必须提供
+	if len(prevouts) != len(tx.TxIn) {
+		return "", fmt.Errorf("前序输出数量(%d)与交易输入数量(%d)不一致", len(prevouts), len(tx.TxIn))
+	}
+
+	inputs := make([]InputInfo, len(prevouts))
+	var owned int
+	for i, prevout := range prevouts {
+		if len(prevout.PkScript) == 0 {
+			return "", fmt.Errorf("输入%d缺少前序输出脚本", i)
+		}
+
+		inputs[i] = InputInfo{Value: prevout.Value, PkScript: prevout.PkScript}
+		if addrType, ok := w.ownScriptType(prevout.PkScript); ok {
+			inputs[i].AddressType = addrType
+			owned++
+		}
+	}
+
+	if owned == 0 {
+		return "", fmt.Errorf("交易中没有属于本钱包的输入")
+	}
+
+	if err = w.signInputs(tx, inputs); err != nil {
+		return "", fmt.Errorf("签名交易失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return "", fmt.Errorf("序列化交易失败: %w", err)
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,619 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSignTransactionMixedInputTypes(t *testing.T) {
+	w := newTestWallet(t)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	trScript, _ := w.addressScript(P2TR)
+
+	utxos := []UTXO{
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000, PkScript: wpkhScript},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000, PkScript: trScript},
+	}
+	tx, err := w.CreateTransactionWithOutputs(P2WPKH, utxos, []PaymentOutput{{Address: testAddress(t, "mixed"), Amount: 40000}}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// fromAddrType为空，每个输入按其自身脚本选择签名方式
+	if err := w.SignTransaction(tx, "", utxos); err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, tx, [][]byte{wpkhScript, trScript}, []int64{30000, 20000})
+}
+
+func TestSignTransactionMixed(t *testing.T) {
+	w := newTestWallet(t)
+	pkhScript, _ := w.addressScript(P2PKH)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 1), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(45000, wpkhScript))
+
+	// P2PKH输入不提供PkScript，使用本钱包对应类型的脚本
+	inputs := []InputInfo{
+		{Value: 30000, AddressType: P2PKH},
+		{Value: 20000, PkScript: wpkhScript, AddressType: P2WPKH},
+	}
+	if err := w.SignTransactionMixed(tx, inputs); err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, tx, [][]byte{pkhScript, wpkhScript}, []int64{30000, 20000})
+}
+
+func TestSignTransactionTaprootNeedsAllPrevOuts(t *testing.T) {
+	w := newTestWallet(t)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{2}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+
+	utxos := []UTXO{{TxID: (&chainhash.Hash{1}).String(), Vout: 0, Value: 5000}}
+	if err := w.SignTransaction(tx, P2TR, utxos); err == nil {
+		t.Fatal("P2TR输入缺少其他输入的前序输出时应返回错误而不是panic")
+	}
+}
+
+func TestTxVSizeMatchesWeight(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(9000, script))
+	if err := w.SignP2WPKHTransaction(tx, 0, 10000, script); err != nil {
+		t.Fatal(err)
+	}
+
+	weight := blockchain.GetTransactionWeight(btcutil.NewTx(tx))
+	vsize := TxVSize(tx)
+	if int64(vsize) != (weight+3)/4 {
+		t.Fatalf("TxVSize为%d，按权重%d计算应为%d", vsize, weight, (weight+3)/4)
+	}
+
+	if rate := EffectiveFeeRate(tx, []int64{10000}); rate != 1000/float64(vsize) {
+		t.Fatalf("实际费率为%f，期望%f", rate, 1000/float64(vsize))
+	}
+}
+
+func TestDustPolicySendExact(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+
+	dest := testAddress(t, "exact")
+	destAddr, _ := btcutil.DecodeAddress(dest, w.network)
+	destScript, _ := txscript.PayToAddrScript(destAddr)
+	ownScript, _ := w.addressScript(P2WPKH)
+
+	// 输入金额在支付20000和无找零手续费后剩余200聪，低于dust阈值
+	fee := int64(estimateVSize([]AddressType{P2WPKH}, []int{outputSize(len(destScript))})) * 2
+	value := 20000 + fee + 200
+	newTestServer(t, w, fmt.Sprintf(`[{"txid":"%s","vout":0,"value":%d}]`, strings.Repeat("1", 64), value), nil)
+
+	if err := w.SetDustPolicy(SendExact); err != nil {
+		t.Fatal(err)
+	}
+	prepared, err := w.PrepareTransaction(P2WPKH, []PaymentOutput{{Address: dest, Amount: 20000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, prepared.Tx, [][]byte{ownScript}, []int64{value})
+	if len(prepared.Tx.TxOut) != 1 || prepared.Tx.TxOut[0].Value != 20200 {
+		t.Fatalf("剩余的200聪应加到收款输出上，实际输出为%v", prepared.Tx.TxOut)
+	}
+	if prepared.Fee != fee {
+		t.Fatalf("手续费为%d，期望%d", prepared.Fee, fee)
+	}
+
+	// 默认策略下剩余部分并入手续费
+	if err := w.SetDustPolicy(DustToFee); err != nil {
+		t.Fatal(err)
+	}
+	prepared, err = w.PrepareTransaction(P2WPKH, []PaymentOutput{{Address: dest, Amount: 20000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepared.Tx.TxOut[0].Value != 20000 || prepared.Fee != fee+200 {
+		t.Fatalf("DustToFee下收款%d、手续费%d，期望20000和%d", prepared.Tx.TxOut[0].Value, prepared.Fee, fee+200)
+	}
+
+	if err := w.SetDustPolicy("burn"); err == nil {
+		t.Fatal("未知的dust处理方式应返回错误")
+	}
+}
+
+func TestDustLimitByScriptType(t *testing.T) {
+	hash := btcutil.Hash160([]byte("dust"))
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(hash, &chaincfg.TestNet3Params)
+	p2wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(hash, &chaincfg.TestNet3Params)
+	p2tr, _ := btcutil.NewAddressTaproot(bytes.Repeat([]byte{0x02}, 32), &chaincfg.TestNet3Params)
+
+	for _, tc := range []struct {
+		addr btcutil.Address
+		want int64
+	}{
+		{p2wpkh, 294},
+		{p2tr, 330},
+		{p2pkh, 546},
+	} {
+		script, _ := txscript.PayToAddrScript(tc.addr)
+		if got := dustLimit(script); got != tc.want {
+			t.Fatalf("%T的dust阈值为%d，期望%d", tc.addr, got, tc.want)
+		}
+	}
+
+	// 330聪对P2TR不是dust，对P2PKH是dust
+	w := newTestWallet(t)
+	if _, _, err := w.resolvePaymentOutputs([]PaymentOutput{{Address: p2tr.EncodeAddress(), Amount: 330}}); err != nil {
+		t.Fatalf("330聪的P2TR输出应被接受，实际为%v", err)
+	}
+	if _, _, err := w.resolvePaymentOutputs([]PaymentOutput{{Address: p2pkh.EncodeAddress(), Amount: 330}}); err == nil {
+		t.Fatal("330聪的P2PKH输出低于dust阈值，应被拒绝")
+	}
+}
+
+func TestSignP2PKHMultipleInputs(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	script, _ := w.addressScript(P2PKH)
+
+	utxos := []UTXO{
+		{TxID: strings.Repeat("1", 64), Vout: 0, Value: 30000},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000},
+		{TxID: strings.Repeat("3", 64), Vout: 2, Value: 20000},
+	}
+	prepared, err := w.PrepareTransactionWithInputs(P2PKH, []PaymentOutput{{Address: testAddress(t, "p2pkh"), Amount: 65000}}, utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个输入签名时其他输入的SignatureScript必须为空，否则后签的输入会破坏先签的签名
+	verifyTx(t, prepared.Tx, [][]byte{script, script, script}, []int64{30000, 20000, 20000})
+}
+
+func TestSignP2SHP2WPKHMultipleInputs(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	script, _ := w.addressScript(P2SH)
+
+	prepared, err := w.PrepareTransactionWithInputs(P2SH, []PaymentOutput{{Address: testAddress(t, "p2sh"), Amount: 45000}}, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 见证签名哈希的scriptCode是P2PKH形式的脚本，而不是P2SH赎回脚本
+	verifyTx(t, prepared.Tx, [][]byte{script, script}, []int64{30000, 20000})
+}
+
+func TestSendResultHex(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(2)
+	var sent []string
+	utxosJSON := fmt.Sprintf(`[{"txid":"%s","vout":0,"value":30000},{"txid":"%s","vout":1,"value":20000}]`,
+		strings.Repeat("1", 64), strings.Repeat("2", 64))
+	newTestServer(t, w, utxosJSON, func(txHex string) { sent = append(sent, txHex) })
+	inputValues := map[string]int64{strings.Repeat("1", 64): 30000, strings.Repeat("2", 64): 20000}
+
+	check := func(name string, result *SendManyResult, broadcast string) {
+		t.Helper()
+		if result.Hex != broadcast {
+			t.Fatalf("%s返回的Hex与广播的交易不一致", name)
+		}
+		tx := deserializeTx(t, result.Hex)
+		if tx.TxHash().String() != result.TxID {
+			t.Fatalf("%s返回的Hex哈希为%s，TxID为%s", name, tx.TxHash(), result.TxID)
+		}
+		if result.VSize != TxVSize(tx) || result.InputCount != len(tx.TxIn) {
+			t.Fatalf("%s返回的VSize或InputCount与交易不一致: %+v", name, result)
+		}
+
+		var fee int64
+		for _, txIn := range tx.TxIn {
+			fee += inputValues[txIn.PreviousOutPoint.Hash.String()]
+		}
+		for _, txOut := range tx.TxOut {
+			fee -= txOut.Value
+		}
+		if result.Fee != fee {
+			t.Fatalf("%s返回的手续费为%d，交易输入减输出为%d", name, result.Fee, fee)
+		}
+		if result.ChangeIndex >= 0 && result.ChangeAmount != tx.TxOut[result.ChangeIndex].Value {
+			t.Fatalf("%s返回的找零金额为%d，找零输出金额为%d", name, result.ChangeAmount, tx.TxOut[result.ChangeIndex].Value)
+		}
+	}
+
+	result, err := w.SendManyWithResult(P2WPKH, []PaymentOutput{{Address: testAddress(t, "hex"), Amount: 25000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("SendManyWithResult", result, sent[0])
+	if result.ChangeIndex < 0 || result.ChangeAmount <= 0 {
+		t.Fatalf("SendManyWithResult应有找零输出: %+v", result)
+	}
+
+	result, err = w.SendAllWithResult(P2WPKH, testAddress(t, "hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("SendAllWithResult", result, sent[1])
+	if result.InputCount != 2 || result.ChangeIndex != -1 || result.ChangeAmount != 0 || result.Fee <= 0 {
+		t.Fatalf("SendAllWithResult应花费全部输入且没有找零: %+v", result)
+	}
+}
+
+func TestRawTransactionEncodings(t *testing.T) {
+	w := newTestWallet(t)
+	outputs := []PaymentOutput{{Address: testAddress(t, "encoding"), Amount: 25000}}
+
+	txHex, err := w.CreateRawTransactionWithOutputs(P2WPKH, outputs, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txBase64, err := w.CreateRawTransactionWithOutputsBase64(P2WPKH, outputs, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	txBytes, err := w.CreateRawTransactionWithOutputsBytes(P2WPKH, outputs, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromHex, _ := hex.DecodeString(txHex)
+	fromBase64, err := base64.StdEncoding.DecodeString(txBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fromHex, fromBase64) || !bytes.Equal(fromHex, txBytes) {
+		t.Fatal("三种编码解码后的交易数据不一致")
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		t.Fatal(err)
+	}
+	if tx.WitnessHash() != deserializeTx(t, txHex).WitnessHash() || len(tx.TxIn) != 2 {
+		t.Fatal("解码后的交易与十六进制编码的交易不一致")
+	}
+}
+
+func TestTxVersion(t *testing.T) {
+	w := newTestWallet(t)
+	outputs := []PaymentOutput{{Address: testAddress(t, "version"), Amount: 25000}}
+	version := func() int32 {
+		t.Helper()
+		txBytes, err := w.CreateRawTransactionWithOutputsBytes(P2WPKH, outputs, testUTXOs())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var tx wire.MsgTx
+		if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+			t.Fatal(err)
+		}
+		return tx.Version
+	}
+
+	if w.GetTxVersion() != 2 || version() != 2 {
+		t.Fatal("默认交易版本应为2")
+	}
+	if err := w.SetTxVersion(3); err == nil {
+		t.Fatal("交易版本3应返回错误")
+	}
+	if err := w.SetTxVersion(0); err == nil {
+		t.Fatal("交易版本0应返回错误")
+	}
+	if err := w.SetTxVersion(1); err != nil {
+		t.Fatal(err)
+	}
+	if v := version(); v != 1 {
+		t.Fatalf("设置版本1后交易版本为%d", v)
+	}
+}
+
+func TestLockTime(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+	outputs := []PaymentOutput{{Address: testAddress(t, "locktime"), Amount: 25000}}
+
+	if err := w.SetLockTime(850000); err != nil {
+		t.Fatal(err)
+	}
+	prepared, err := w.PrepareTransactionWithInputs(P2WPKH, outputs, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nLockTime是序列化的最后4个字节(小端)
+	if suffix := prepared.Hex[len(prepared.Hex)-8:]; suffix != "50f80c00" {
+		t.Fatalf("序列化的nLockTime为%s，期望850000(50f80c00)", suffix)
+	}
+	tx := deserializeTx(t, prepared.Hex)
+	if tx.LockTime != 850000 {
+		t.Fatalf("nLockTime为%d，期望850000", tx.LockTime)
+	}
+	for i, txIn := range tx.TxIn {
+		if txIn.Sequence == wire.MaxTxInSequenceNum {
+			t.Fatalf("输入%d为final，nLockTime不会生效", i)
+		}
+	}
+	verifyTx(t, tx, [][]byte{script, script}, []int64{30000, 20000})
+
+	if err := w.SetLockTime(lockTimeThreshold + 1); err == nil {
+		t.Fatal("早于创世区块的时间戳应返回错误")
+	}
+	if w.GetLockTime() != 850000 {
+		t.Fatal("设置失败时不应修改已有的nLockTime")
+	}
+}
+
+func TestMaxSendable(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetFeeRate(3)
+	var sent []string
+	newTestServer(t, w, fmt.Sprintf(`[{"txid":"%s","vout":0,"value":30000},{"txid":"%s","vout":1,"value":20000}]`,
+		strings.Repeat("1", 64), strings.Repeat("2", 64)), func(txHex string) { sent = append(sent, txHex) })
+
+	amount, fee, err := w.MaxSendable(P2WPKH)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFee := int64(estimateVSize([]AddressType{P2WPKH, P2WPKH}, []int{outputSize(outputScriptSize(P2WPKH))})) * 3
+	if fee != wantFee || amount != 50000-fee {
+		t.Fatalf("可转出%d、手续费%d，期望%d和%d", amount, fee, 50000-wantFee, wantFee)
+	}
+
+	// 估算结果与SendAll实际转出的金额一致
+	if _, err := w.SendAll(P2WPKH, testAddress(t, "max")); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("应广播1笔交易，实际为%d笔", len(sent))
+	}
+	if tx := deserializeTx(t, sent[0]); len(tx.TxOut) != 1 || tx.TxOut[0].Value != amount {
+		t.Fatalf("SendAll转出%v，期望单个输出%d", tx.TxOut, amount)
+	}
+
+	// 余额不足以支付手续费
+	newTestServer(t, w, fmt.Sprintf(`[{"txid":"%s","vout":0,"value":300}]`, strings.Repeat("1", 64)), nil)
+	var insufficient *InsufficientFundsError
+	if _, _, err := w.MaxSendable(P2WPKH); !errors.As(err, &insufficient) {
+		t.Fatalf("余额低于手续费时应返回InsufficientFundsError，实际为%v", err)
+	}
+}
+
+func TestSendManyWithInputs(t *testing.T) {
+	w := newTestWallet(t)
+	script, _ := w.addressScript(P2WPKH)
+	var sent []string
+	// 服务端还有一个更大的UTXO，不应被自动选中
+	newTestServer(t, w, fmt.Sprintf(`[{"txid":"%s","vout":0,"value":500000}]`, strings.Repeat("9", 64)),
+		func(txHex string) { sent = append(sent, txHex) })
+
+	inputs := []UTXO{
+		{TxID: strings.Repeat("1", 64), Vout: 3, Value: 30000, PkScript: script},
+		{TxID: strings.Repeat("2", 64), Vout: 1, Value: 20000, PkScript: script},
+	}
+	outputs := []PaymentOutput{{Address: testAddress(t, "manual"), Amount: 25000}}
+	if _, err := w.SendManyWithInputs(P2WPKH, outputs, inputs); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("应广播1笔交易，实际为%d笔", len(sent))
+	}
+
+	tx := deserializeTx(t, sent[0])
+	if len(tx.TxIn) != len(inputs) {
+		t.Fatalf("交易有%d个输入，期望%d个", len(tx.TxIn), len(inputs))
+	}
+	for i, txIn := range tx.TxIn {
+		if txIn.PreviousOutPoint.Hash.String() != inputs[i].TxID || txIn.PreviousOutPoint.Index != inputs[i].Vout {
+			t.Fatalf("输入%d为%s，期望%s:%d", i, txIn.PreviousOutPoint, inputs[i].TxID, inputs[i].Vout)
+		}
+	}
+	verifyTx(t, tx, [][]byte{script, script}, []int64{30000, 20000})
+
+	// 重复的输入和不足以支付的输入都在广播前被拒绝
+	if _, err := w.SendManyWithInputs(P2WPKH, outputs, []UTXO{inputs[0], inputs[0]}); err == nil || !strings.Contains(err.Error(), "重复") {
+		t.Fatalf("重复输入应返回错误，实际为%v", err)
+	}
+	var insufficient *InsufficientFundsError
+	if _, err := w.SendManyWithInputs(P2WPKH, outputs, inputs[1:]); !errors.As(err, &insufficient) {
+		t.Fatalf("输入不足时应返回InsufficientFundsError，实际为%v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatal("被拒绝的交易不应广播")
+	}
+}
+
+func TestChangeIndexPreserveOrdering(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetOutputOrdering(Preserve)
+	ownScript, _ := w.addressScript(P2TR)
+	outputs := []PaymentOutput{
+		{Address: testAddress(t, "first"), Amount: 10000},
+		{Address: testAddress(t, "second"), Amount: 20000},
+	}
+
+	result, err := w.CreateTransactionWithOutputsResult(P2TR, testUTXOs(), outputs, 15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ChangeIndex != 2 {
+		t.Fatalf("找零索引为%d，Preserve下找零应在支付输出之后", result.ChangeIndex)
+	}
+	change := result.Tx.TxOut[result.ChangeIndex]
+	if !bytes.Equal(change.PkScript, ownScript) || change.Value != 15000 {
+		t.Fatalf("找零索引指向的输出为%d聪、脚本%x，期望付回钱包自己的P2TR地址", change.Value, change.PkScript)
+	}
+	for i, output := range outputs {
+		if result.Tx.TxOut[i].Value != output.Amount {
+			t.Fatalf("支付输出%d的金额为%d，期望%d", i, result.Tx.TxOut[i].Value, output.Amount)
+		}
+	}
+	if result.Fee != 50000-45000 {
+		t.Fatalf("手续费为%d，期望5000", result.Fee)
+	}
+
+	// 没有找零时为-1
+	result, err = w.CreateTransactionWithOutputsResult(P2TR, testUTXOs(), outputs, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ChangeIndex != -1 || len(result.Tx.TxOut) != 2 {
+		t.Fatalf("没有找零时找零索引为%d，期望-1", result.ChangeIndex)
+	}
+}
+
+func TestSetChangeAddress(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetOutputOrdering(Preserve)
+	ownScript, _ := w.addressScript(P2WPKH)
+	outputs := []PaymentOutput{
+		{Address: testAddress(t, "first"), Amount: 10000},
+		{Address: testAddress(t, "second"), Amount: 20000},
+	}
+
+	base, err := w.CreateTransactionWithOutputsResult(P2WPKH, testUTXOs(), outputs, 15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changeAddr := testAddress(t, "change")
+	decoded, _ := btcutil.DecodeAddress(changeAddr, &chaincfg.TestNet3Params)
+	changeScript, _ := txscript.PayToAddrScript(decoded)
+	if err := w.SetChangeAddress(changeAddr); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := w.CreateTransactionWithOutputsResult(P2WPKH, testUTXOs(), outputs, 15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change := result.Tx.TxOut[result.ChangeIndex]
+	if !bytes.Equal(change.PkScript, changeScript) || change.Value != 15000 {
+		t.Fatalf("找零输出为%d聪、脚本%x，期望付到设置的找零地址", change.Value, change.PkScript)
+	}
+	for i := range outputs {
+		got, want := result.Tx.TxOut[i], base.Tx.TxOut[i]
+		if got.Value != want.Value || !bytes.Equal(got.PkScript, want.PkScript) {
+			t.Fatalf("设置找零地址后支付输出%d发生变化: %d聪%x，期望%d聪%x", i, got.Value, got.PkScript, want.Value, want.PkScript)
+		}
+	}
+
+	// 其他网络的地址被拒绝
+	mainnetAddr, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160([]byte("mainnet")), &chaincfg.MainNetParams)
+	if err := w.SetChangeAddress(mainnetAddr.EncodeAddress()); err == nil {
+		t.Fatal("测试网钱包应拒绝主网找零地址")
+	}
+
+	// 空字符串恢复为发送方地址找零
+	if err := w.SetChangeAddress(""); err != nil {
+		t.Fatal(err)
+	}
+	result, err = w.CreateTransactionWithOutputsResult(P2WPKH, testUTXOs(), outputs, 15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change := result.Tx.TxOut[result.ChangeIndex]; !bytes.Equal(change.PkScript, ownScript) {
+		t.Fatalf("清除找零地址后找零脚本为%x，期望发送方地址%x", change.PkScript, ownScript)
+	}
+}
+
+func TestSignRawTransactionWithPrevoutsRoundTrip(t *testing.T) {
+	w := newTestWallet(t)
+	types := []AddressType{P2PKH, P2SH, P2WPKH, P2TR}
+
+	// 外部构建的未签名交易，每个输入花费本钱包一种地址类型的输出
+	tx := wire.NewMsgTx(2)
+	prevouts := make([]PrevOut, len(types))
+	scripts := make([][]byte, len(types))
+	values := make([]int64, len(types))
+	for i, addrType := range types {
+		script, err := w.addressScript(addrType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values[i], scripts[i] = int64(10000*(i+1)), script
+		prevouts[i] = PrevOut{Value: values[i], PkScript: script}
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i + 1)}, uint32(i)), nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(99000, []byte{txscript.OP_TRUE}))
+
+	signedHex, err := w.SignRawTransactionWithPrevouts(serializeTx(t, tx), prevouts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := deserializeTx(t, signedHex)
+	if signed.TxIn[0].SignatureScript == nil || len(signed.TxIn[3].Witness) != 1 {
+		t.Fatal("P2PKH输入应有解锁脚本，P2TR密钥路径输入应只有一个签名")
+	}
+	verifyTx(t, signed, scripts, values)
+
+	// 签名只填充解锁脚本和见证，不改变输入的outpoint、序列号和输出
+	for i, txIn := range signed.TxIn {
+		if txIn.PreviousOutPoint != tx.TxIn[i].PreviousOutPoint || txIn.Sequence != tx.TxIn[i].Sequence {
+			t.Fatalf("输入%d在签名后被修改", i)
+		}
+	}
+	if len(signed.TxOut) != 1 || signed.TxOut[0].Value != 99000 || signed.LockTime != tx.LockTime {
+		t.Fatal("签名后交易输出不应改变")
+	}
+
+	if _, err := w.SignRawTransactionWithPrevouts(serializeTx(t, tx), prevouts[:3]); err == nil {
+		t.Fatal("前序输出数量与输入数量不一致时应返回错误")
+	}
+}
+
+func TestSignRawTransactionFillsPrevouts(t *testing.T) {
+	w := newTestWallet(t)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	trScript, _ := w.addressScript(P2TR)
+
+	// 前序交易的两个输出都属于本钱包
+	prevTx := wire.NewMsgTx(2)
+	prevTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{9}, 0), nil, nil))
+	prevTx.AddTxOut(wire.NewTxOut(30000, wpkhScript))
+	prevTx.AddTxOut(wire.NewTxOut(20000, trScript))
+	prevHash := prevTx.TxHash()
+
+	var requests []string
+	testOutputServer(t, w, prevTx, &requests)
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 0), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&prevHash, 1), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(45000, []byte{txscript.OP_TRUE}))
+
+	// 只提供txid和vout，金额和脚本从链上补全
+	utxos := []UTXO{{TxID: prevHash.String(), Vout: 0}, {TxID: prevHash.String(), Vout: 1}}
+	signedHex, err := w.SignRawTransaction(serializeTx(t, tx), P2WPKH, utxos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyTx(t, deserializeTx(t, signedHex), [][]byte{wpkhScript, trScript}, []int64{30000, 20000})
+
+	// 同一笔前序交易只获取一次
+	if len(requests) != 1 {
+		t.Fatalf("补全前序输出发起了%d次请求%v，期望1次", len(requests), requests)
+	}
+	if utxos[0].Value != 0 {
+		t.Fatal("补全前序输出不应修改调用方的UTXO")
+	}
+}
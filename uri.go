@@ -0,0 +1,77 @@
+package btc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// bip21Scheme BIP21支付链接的协议前缀，匹配时不区分大小写
+const bip21Scheme = "bitcoin:"
+
+// ParsePaymentURI 解析BIP21支付链接(如 bitcoin:bc1q...?amount=0.001&label=Shop)
+// 返回的PaymentOutput中地址已按钱包网络校验并规范化，金额由BTC换算为聪，链接未指定金额时为0
+// 除amount外的参数(label、message、lightning等)URL解码后放入map返回，
+// 出现不认识的req-前缀参数时按BIP21要求返回错误
+func (w *BitcoinWallet) ParsePaymentURI(uri string) (PaymentOutput, map[string]string, error) {
+	uri = strings.TrimSpace(uri)
+	if len(uri) < len(bip21Scheme) || !strings.EqualFold(uri[:len(bip21Scheme)], bip21Scheme) {
+		return PaymentOutput{}, nil, fmt.Errorf("不是bitcoin:支付链接")
+	}
+
+	rest := uri[len(bip21Scheme):]
+	rawAddr, rawQuery, _ := strings.Cut(rest, "?")
+
+	// 扫码得到的bech32地址可能是全大写，decodeAddress可以直接处理
+	addr, err := w.decodeAndValidateAddress(rawAddr)
+	if err != nil {
+		return PaymentOutput{}, nil, fmt.Errorf("支付链接的地址无效: %w", err)
+	}
+
+	output := PaymentOutput{Address: addr.EncodeAddress()}
+	params := make(map[string]string)
+	if rawQuery == "" {
+		return output, params, nil
+	}
+
+	hasAmount := false
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+
+		rawKey, rawValue, _ := strings.Cut(pair, "=")
+		// BIP21使用RFC 3986编码，'+'不表示空格，不能使用url.ParseQuery
+		key, err := url.PathUnescape(rawKey)
+		if err != nil {
+			return PaymentOutput{}, nil, fmt.Errorf("解析支付链接参数失败: %w", err)
+		}
+		value, err := url.PathUnescape(rawValue)
+		if err != nil {
+			return PaymentOutput{}, nil, fmt.Errorf("解析支付链接参数%s失败: %w", key, err)
+		}
+
+		if _, ok := params[key]; ok || (key == "amount" && hasAmount) {
+			return PaymentOutput{}, nil, fmt.Errorf("支付链接参数%s重复", key)
+		}
+
+		switch {
+		case key == "amount":
+			amount, err := BTCToSats(value)
+			if err != nil {
+				return PaymentOutput{}, nil, fmt.Errorf("支付链接的金额无效: %w", err)
+			}
+			if amount == 0 {
+				return PaymentOutput{}, nil, fmt.Errorf("支付链接的金额必须大于0")
+			}
+			output.Amount = amount
+			hasAmount = true
+		case strings.HasPrefix(key, "req-"):
+			return PaymentOutput{}, nil, fmt.Errorf("支付链接包含不支持的必需参数%s", key)
+		default:
+			params[key] = value
+		}
+	}
+
+	return output, params, nil
+}
@@ -0,0 +1,74 @@
+package btc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePaymentURIPlainAddress(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	output, params, err := w.ParsePaymentURI("bitcoin:" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Address != addr || output.Amount != 0 || len(params) != 0 {
+		t.Fatalf("解析结果错误: %+v %v", output, params)
+	}
+}
+
+func TestParsePaymentURIAmount(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	// 方案名和bech32地址大小写不敏感，0.29按浮点数换算会得到28999999
+	output, _, err := w.ParsePaymentURI("BITCOIN:" + strings.ToUpper(addr) + "?amount=0.29")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Address != addr || output.Amount != 29000000 {
+		t.Fatalf("解析结果错误: %+v", output)
+	}
+}
+
+func TestParsePaymentURIExtraParams(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	output, params, err := w.ParsePaymentURI("bitcoin:" + addr + "?amount=1.5&label=Luke-Jr&message=Donation%20for%20project&lightning=lnbc1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Amount != 150000000 {
+		t.Fatalf("金额为%d，期望150000000", output.Amount)
+	}
+	want := map[string]string{"label": "Luke-Jr", "message": "Donation for project", "lightning": "lnbc1"}
+	if len(params) != len(want) {
+		t.Fatalf("其余参数为%v，期望%v", params, want)
+	}
+	for k, v := range want {
+		if params[k] != v {
+			t.Fatalf("参数%s为%q，期望%q", k, params[k], v)
+		}
+	}
+}
+
+func TestParsePaymentURIRejects(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+
+	tests := []string{
+		"http:" + addr,
+		"bitcoin:?amount=1",
+		"bitcoin:1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", // 主网地址
+		"bitcoin:" + addr + "?amount=1&amount=2",
+		"bitcoin:" + addr + "?amount=0.000000001",
+		"bitcoin:" + addr + "?req-foo=1", // 无法识别的req-参数必须拒绝
+	}
+	for _, uri := range tests {
+		if _, _, err := w.ParsePaymentURI(uri); err == nil {
+			t.Errorf("%s 应返回错误", uri)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package btc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// maxConcurrentRequests 多地址查询时的最大并发请求数
+const maxConcurrentRequests = 4
+
+// GetUTXOsForAddresses 并发查询多个地址的UTXO并合并，结果按地址顺序排列并按outpoint去重
+func (w *BitcoinWallet) GetUTXOsForAddresses(addresses []string) ([]UTXO, error) {
+	return w.GetUTXOsForAddressesContext(context.Background(), addresses)
+}
+
+// GetUTXOsForAddressesContext 并发查询多个地址的UTXO，任一地址查询失败时返回错误
+func (w *BitcoinWallet) GetUTXOsForAddressesContext(ctx context.Context, addresses []string) ([]UTXO, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("地址列表不能为空")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]UTXO, len(addresses))
+	errs := make([]error, len(addresses))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := maxConcurrentRequests
+	if len(addresses) < workers {
+		workers = len(addresses)
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				utxos, err := w.GetUTXOsContext(ctx, addresses[idx])
+				if err != nil {
+					errs[idx] = err
+					// 任一地址失败后取消其余请求
+					cancel()
+					continue
+				}
+				results[idx] = utxos
+			}
+		}()
+	}
+
+	for idx := range addresses {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 优先报告第一个非取消导致的错误
+	for idx, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return nil, fmt.Errorf("查询地址%s的UTXO失败: %w", addresses[idx], err)
+		}
+	}
+	for idx, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("查询地址%s的UTXO失败: %w", addresses[idx], err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var merged []UTXO
+	for idx, utxos := range results {
+		for _, utxo := range utxos {
+			key := fmt.Sprintf("%s:%d", utxo.TxID, utxo.Vout)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			utxo.Address = addresses[idx]
+			merged = append(merged, utxo)
+		}
+	}
+
+	return merged, nil
+}
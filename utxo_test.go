@@ -0,0 +1,104 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEmptyUTXOsVersusServerError(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetRetryPolicy(0, 0)
+	body, code := "[]", http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(code)
+		rw.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := w.GetAddress(P2WPKH)
+	outputs := []PaymentOutput{{Address: testAddress(t, "empty"), Amount: 1000}}
+
+	// 200且为空数组: 地址确实没有UTXO
+	utxos, err := w.GetUTXOs(addr)
+	if err != nil || len(utxos) != 0 {
+		t.Fatalf("空地址应返回空列表: %v, %v", utxos, err)
+	}
+	if _, err := w.SendMany(P2WPKH, outputs); !errors.Is(err, ErrNoUTXOs) {
+		t.Fatalf("SendMany应返回ErrNoUTXOs，实际为%v", err)
+	}
+	if _, err := w.SendAll(P2WPKH, testAddress(t, "empty")); !errors.Is(err, ErrNoUTXOs) {
+		t.Fatalf("SendAll应返回ErrNoUTXOs，实际为%v", err)
+	}
+
+	// 503: 服务不可用，不能当作没有UTXO
+	code = http.StatusServiceUnavailable
+	var apiErr *APIError
+	if _, err := w.GetUTXOs(addr); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("503应返回APIError，实际为%v", err)
+	}
+	if _, err := w.SendMany(P2WPKH, outputs); errors.Is(err, ErrNoUTXOs) || !errors.As(err, &apiErr) {
+		t.Fatalf("503时SendMany应返回APIError而不是ErrNoUTXOs，实际为%v", err)
+	}
+
+	// 200但响应体不是数组
+	body, code = "null", http.StatusOK
+	if _, err := w.GetUTXOs(addr); err == nil {
+		t.Fatal("响应体为null时应返回错误")
+	}
+}
+
+func TestGetUTXOsForAddresses(t *testing.T) {
+	w := newTestWallet(t)
+	w.SetRetryPolicy(0, 0)
+	addrs := []string{testAddress(t, "a"), testAddress(t, "b"), testAddress(t, "c")}
+	shared := strings.Repeat("f", 64)
+
+	// 每个地址返回不同的UTXO，shared:0同时出现在a和c的响应中
+	responses := map[string]string{
+		addrs[0]: fmt.Sprintf(`[{"txid":"%s","vout":0,"value":1000},{"txid":"%s","vout":0,"value":5000}]`, strings.Repeat("1", 64), shared),
+		addrs[1]: fmt.Sprintf(`[{"txid":"%s","vout":2,"value":2000}]`, strings.Repeat("2", 64)),
+		addrs[2]: fmt.Sprintf(`[{"txid":"%s","vout":0,"value":5000},{"txid":"%s","vout":1,"value":3000}]`, shared, strings.Repeat("3", 64)),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		addr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/address/"), "/utxo")
+		body, ok := responses[addr]
+		if !ok {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		rw.Write([]byte(body))
+	}))
+	defer srv.Close()
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	utxos, err := w.GetUTXOsForAddresses(addrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		value int64
+		addr  string
+	}{{1000, addrs[0]}, {5000, addrs[0]}, {2000, addrs[1]}, {3000, addrs[2]}}
+	if len(utxos) != len(want) {
+		t.Fatalf("合并后有%d个UTXO，期望%d个: %v", len(utxos), len(want), utxos)
+	}
+	for i, utxo := range utxos {
+		if utxo.Value != want[i].value || utxo.Address != want[i].addr {
+			t.Fatalf("第%d个UTXO为%d@%s，期望%d@%s", i, utxo.Value, utxo.Address, want[i].value, want[i].addr)
+		}
+	}
+
+	// 任一地址查询失败时整体返回错误
+	if _, err := w.GetUTXOsForAddresses(append(addrs, testAddress(t, "missing"))); err == nil {
+		t.Fatal("某个地址查询失败时应返回错误")
+	}
+}
@@ -0,0 +1,93 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// SetVerifyBeforeBroadcast 设置是否在广播前本地验证已签名交易
+func (w *BitcoinWallet) SetVerifyBeforeBroadcast(enabled bool) {
+	w.verifyBeforeBroadcast = enabled
+}
+
+// SetVerifyUTXOOwnership 设置签名前是否校验每个UTXO都属于本钱包
+// 启用后未携带PkScript的UTXO按Address推导输出脚本，Address也为空时从后端获取前序交易，
+// 脚本不属于本钱包时SignTransaction返回ForeignUTXOError，而不是生成广播时才失败的无效签名
+func (w *BitcoinWallet) SetVerifyUTXOOwnership(enabled bool) {
+	w.verifyOwnership = enabled
+}
+
+// utxoScript 获取UTXO的输出脚本，优先使用PkScript，其次按Address推导，最后从后端获取前序交易
+func (w *BitcoinWallet) utxoScript(ctx context.Context, utxo UTXO) ([]byte, error) {
+	if len(utxo.PkScript) > 0 {
+		return utxo.PkScript, nil
+	}
+
+	if utxo.Address != "" {
+		addr, err := btcutil.DecodeAddress(utxo.Address, w.network)
+		if err != nil {
+			return nil, fmt.Errorf("解析UTXO地址失败: %w", err)
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, fmt.Errorf("创建UTXO脚本失败: %w", err)
+		}
+		return script, nil
+	}
+
+	_, script, err := w.GetOutputContext(ctx, utxo.TxID, utxo.Vout)
+	if err != nil {
+		return nil, fmt.Errorf("获取前序输出失败: %w", err)
+	}
+	return script, nil
+}
+
+// VerifyTransaction 使用脚本引擎逐个验证交易输入的签名
+// UTXO携带PkScript时使用其脚本，否则使用fromAddrType对应的本钱包脚本
+func (w *BitcoinWallet) VerifyTransaction(tx *wire.MsgTx, utxos []UTXO, fromAddrType AddressType) error {
+	if len(utxos) != len(tx.TxIn) {
+		return fmt.Errorf("UTXO数量(%d)与交易输入数量(%d)不一致", len(utxos), len(tx.TxIn))
+	}
+	utxos = alignUTXOs(tx, utxos)
+
+	var fromScript []byte
+	prevFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	prevScripts := make([][]byte, len(utxos))
+
+	for i, utxo := range utxos {
+		script := utxo.PkScript
+		if len(script) == 0 {
+			if fromScript == nil {
+				var err error
+				fromScript, err = w.addressScript(fromAddrType)
+				if err != nil {
+					return fmt.Errorf("创建发送方脚本失败: %w", err)
+				}
+			}
+			script = fromScript
+		}
+
+		prevScripts[i] = script
+		prevFetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, wire.NewTxOut(utxo.Value, script))
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevFetcher)
+	for i, utxo := range utxos {
+		engine, err := txscript.NewEngine(
+			prevScripts[i], tx, i, txscript.StandardVerifyFlags, nil, sigHashes, utxo.Value, prevFetcher,
+		)
+		if err != nil {
+			return fmt.Errorf("创建输入%d的脚本引擎失败: %w", i, err)
+		}
+
+		if err := engine.Execute(); err != nil {
+			return fmt.Errorf("输入%d验证失败: %w", i, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package btc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyTransaction(t *testing.T) {
+	w := newTestWallet(t)
+	prepared, err := w.PrepareTransactionWithInputs(P2WPKH, []PaymentOutput{{Address: testAddress(t, "verify"), Amount: 25000}}, testUTXOs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.VerifyTransaction(prepared.Tx, testUTXOs(), P2WPKH); err != nil {
+		t.Fatalf("正确签名的交易验证失败: %v", err)
+	}
+
+	// 篡改第二个输入的签名
+	tampered := prepared.Tx.Copy()
+	sig := tampered.TxIn[1].Witness[0]
+	sig[len(sig)-2] ^= 0x01
+	err = w.VerifyTransaction(tampered, testUTXOs(), P2WPKH)
+	if err == nil {
+		t.Fatal("签名被篡改的交易应验证失败")
+	}
+	if !strings.Contains(err.Error(), "输入1") {
+		t.Fatalf("错误应指出失败的输入1，实际为%v", err)
+	}
+
+	// 金额不一致时签名哈希不同，同样验证失败
+	utxos := testUTXOs()
+	utxos[0].Value++
+	if err := w.VerifyTransaction(prepared.Tx, utxos, P2WPKH); err == nil || !strings.Contains(err.Error(), "输入0") {
+		t.Fatalf("前序输出金额错误时应指出输入0，实际为%v", err)
+	}
+}
@@ -2,16 +2,17 @@ package btc
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"sort"
+	"math"
+	mathrand "math/rand"
 	"strings"
-	"time"
+	"sync"
+	"sync/atomic"
 
 	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
@@ -27,47 +28,91 @@ const (
 	P2WPKH AddressType = "p2wpkh" // bc1q开头地址
 	P2SH   AddressType = "p2sh"   // 3开头地址
 	P2TR   AddressType = "p2tr"   // bc1p开头地址
+	P2WSH  AddressType = "p2wsh"  // bc1q开头的脚本哈希地址，仅用于识别地址和多签
 )
 
 // Network 网络类型
 type Network string
 
 const (
-	MainNet Network = "mainnet"
-	TestNet Network = "testnet"
+	MainNet  Network = "mainnet"
+	TestNet  Network = "testnet"  // testnet3
+	TestNet4 Network = "testnet4" // BIP94 testnet4
 )
 
 // UTXO 未花费的交易输出
 type UTXO struct {
-	TxID  string `json:"txid"`
-	Vout  uint32 `json:"vout"`
-	Value int64  `json:"value"`
+	TxID            string   `json:"txid"`
+	Vout            uint32   `json:"vout"`
+	Value           int64    `json:"value"`
+	Address         string   `json:"address,omitempty"`          // UTXO所属地址
+	PkScript        []byte   `json:"pk_script,omitempty"`        // UTXO的输出脚本，为空时签名按调用方指定的地址类型处理
+	Status          TxStatus `json:"status"`                     // 所在交易的确认状态
+	DerivationIndex *uint32  `json:"derivation_index,omitempty"` // 所属地址的描述符派生索引，仅由GetUTXOsForDescriptor设置，其他来源为nil
 }
 
 // BitcoinWallet 比特币钱包实现
 type BitcoinWallet struct {
-	privateKey *btcec.PrivateKey
-	publicKey  *btcec.PublicKey
-	network    *chaincfg.Params
-	apiURL     string
-	feeRate    int64 // satoshi per byte
-	httpClient *http.Client
+	privateKey            *btcec.PrivateKey
+	publicKey             *btcec.PublicKey
+	compressed            bool // WIF的压缩标志，只影响WIF导出，地址和签名总是使用压缩公钥
+	network               *chaincfg.Params
+	defaultAPIURL         string                 // 网络默认的API地址
+	backend               Backend                // 区块链数据后端
+	utxoProvider          UTXOProvider           // 自定义UTXO来源，为空时使用backend
+	broadcaster           Broadcaster            // 自定义广播方式，为空时使用backend
+	client                *apiClient             // 内置后端使用的HTTP客户端
+	cacheTTL              atomic.Int64           // 推荐费率和区块高度的缓存有效期(time.Duration)，0表示不缓存
+	feeCache              ttlCache[FeeEstimates] // 推荐费率缓存
+	tipCache              ttlCache[int64]        // 最新区块高度缓存
+	feeRateMilli          int64                  // 费率，单位为千分之一sat/vB(即sat/kvB)
+	minRelayFeeMilli      int64                  // 最低转发费率，单位同feeRateMilli，0表示默认的1 sat/vB
+	maxFeeRateMilli       int64                  // 允许的最高费率，单位同feeRateMilli，0表示不限制
+	maxFeeRatio           float64                // 手续费占转账金额的最大比例，0表示不限制
+	maxFee                int64                  // 手续费绝对上限(satoshi)，0表示不限制
+	allowHighFee          bool                   // 是否跳过手续费上限检查
+	coinSelection         CoinSelectionStrategy  // UTXO选择策略，为空时使用SmallestFirst
+	coinRand              *mathrand.Rand         // RandomImproved策略使用的随机数生成器，为空时从crypto/rand取种子
+	addressIsolation      bool                   // 选择UTXO时是否尽量避免合并不同地址的UTXO
+	frozen                map[string]struct{}    // 冻结的UTXO(txid:vout)，不参与自动选择
+	outputOrdering        OutputOrdering         // 输入输出排序策略，为空时使用Preserve
+	rbf                   bool                   // 是否启用BIP125 RBF
+	lockTime              uint32                 // 交易nLockTime，0表示不启用
+	txVersion             int32                  // 新建交易的版本号，0表示默认的2
+	changeAddress         btcutil.Address        // 自定义找零地址，为空时找零回发送方地址
+	verifyBeforeBroadcast bool                   // 广播前是否本地验证签名
+	verifyOwnership       bool                   // 签名前是否校验UTXO属于本钱包
+	hd                    *HDWallet              // 由HD钱包派生时记录来源，用于导出扩展公钥
+	hdAccount             string                 // 由标准路径派生时的账户路径，找零使用该账户的内部链
+	minConfirmations      int                    // 选择UTXO时要求的最小确认数，0表示不限制
+	allowUnconfirmedOwn   bool                   // 最小确认数是否放行本钱包创建的未确认交易的输出
+	ownTxIDs              map[string]struct{}    // 已知由本钱包创建的交易ID(小写)
+	ownTxMu               sync.Mutex             // 保护ownTxIDs的并发访问
+	lowR                  bool                   // ECDSA签名是否进行low-R grinding
+	auxRand               *[32]byte              // Schnorr签名的BIP340辅助随机数，为空时使用RFC6979随机数
+	dustPolicy            DustPolicy             // 找零低于dust阈值时的处理方式，为空时使用DustToFee
 }
 
-// NewWallet 创建新钱包
-func NewWallet(wif string, network Network) (*BitcoinWallet, error) {
-	var netParams *chaincfg.Params
-	var apiURL string
-
+// networkParams 获取网络对应的链参数和默认API地址
+func networkParams(network Network) (*chaincfg.Params, string, error) {
 	switch network {
 	case MainNet:
-		netParams = &chaincfg.MainNetParams
-		apiURL = "https://blockstream.info/api"
+		return &chaincfg.MainNetParams, "https://blockstream.info/api", nil
 	case TestNet:
-		netParams = &chaincfg.TestNet3Params
-		apiURL = "https://blockstream.info/testnet/api"
+		return &chaincfg.TestNet3Params, "https://blockstream.info/testnet/api", nil
+	case TestNet4:
+		// blockstream.info不支持testnet4，使用mempool.space提供的Esplora兼容接口
+		return testNet4Params, "https://mempool.space/testnet4/api", nil
 	default:
-		return nil, fmt.Errorf("不支持的网络类型: %s", network)
+		return nil, "", fmt.Errorf("不支持的网络类型: %s", network)
+	}
+}
+
+// NewWallet 创建新钱包
+func NewWallet(wif string, network Network) (*BitcoinWallet, error) {
+	netParams, apiURL, err := networkParams(network)
+	if err != nil {
+		return nil, err
 	}
 
 	key, err := btcutil.DecodeWIF(wif)
@@ -79,24 +124,119 @@ func NewWallet(wif string, network Network) (*BitcoinWallet, error) {
 		return nil, fmt.Errorf("私钥网络不匹配")
 	}
 
-	return &BitcoinWallet{
-		privateKey: key.PrivKey,
-		publicKey:  key.PrivKey.PubKey(),
-		network:    netParams,
-		apiURL:     apiURL,
-		feeRate:    1, // 默认费率 1 sat/byte
-		httpClient: &http.Client{Timeout: 10 * time.Second},
-	}, nil
+	return newWallet(key.PrivKey, key.CompressPubKey, netParams, apiURL), nil
+}
+
+// NewWalletFromBytes 使用32字节原始私钥创建钱包，compressed只决定导出WIF时的压缩标志，
+// 地址、签名和PublicKeyHex总是使用压缩公钥
+func NewWalletFromBytes(priv []byte, compressed bool, network Network) (*BitcoinWallet, error) {
+	netParams, apiURL, err := networkParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(priv) != btcec.PrivKeyBytesLen {
+		return nil, fmt.Errorf("私钥长度必须为%d字节", btcec.PrivKeyBytesLen)
+	}
+
+	// 私钥必须在[1, N-1]范围内
+	var scalar btcec.ModNScalar
+	if overflow := scalar.SetByteSlice(priv); overflow || scalar.IsZero() {
+		return nil, fmt.Errorf("私钥超出有效范围")
+	}
+
+	return newWallet(btcec.PrivKeyFromScalar(&scalar), compressed, netParams, apiURL), nil
+}
+
+// NewWalletFromHex 使用十六进制编码的32字节私钥创建钱包
+func NewWalletFromHex(hexKey string, compressed bool, network Network) (*BitcoinWallet, error) {
+	priv, err := hex.DecodeString(strings.TrimSpace(hexKey))
+	if err != nil {
+		return nil, fmt.Errorf("解码十六进制私钥失败: %w", err)
+	}
+
+	return NewWalletFromBytes(priv, compressed, network)
+}
+
+// GenerateWallet 使用crypto/rand生成随机私钥创建新钱包，同时返回用于备份的WIF
+func GenerateWallet(network Network) (*BitcoinWallet, string, error) {
+	netParams, apiURL, err := networkParams(network)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// btcec.NewPrivateKey内部使用crypto/rand
+	privKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("生成私钥失败: %w", err)
+	}
+
+	w := newWallet(privKey, true, netParams, apiURL)
+	wif, err := w.WIF()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return w, wif, nil
+}
+
+// newWallet 使用私钥和网络参数初始化钱包
+func newWallet(privKey *btcec.PrivateKey, compressed bool, netParams *chaincfg.Params, apiURL string) *BitcoinWallet {
+	w := &BitcoinWallet{
+		privateKey:    privKey,
+		publicKey:     privKey.PubKey(),
+		compressed:    compressed,
+		network:       netParams,
+		defaultAPIURL: apiURL,
+		feeRateMilli:  1000, // 默认费率 1 sat/vB
+		maxFeeRatio:   defaultMaxFeeRatio,
+	}
+	w.SetBackend(nil)
+	return w
+}
+
+// WIF 导出钱包私钥的WIF编码
+func (w *BitcoinWallet) WIF() (string, error) {
+	wif, err := btcutil.NewWIF(w.privateKey, w.network, w.compressed)
+	if err != nil {
+		return "", fmt.Errorf("编码WIF失败: %w", err)
+	}
+	return wif.String(), nil
+}
+
+// PublicKeyHex 返回十六进制编码的压缩公钥，与钱包地址使用的公钥一致
+func (w *BitcoinWallet) PublicKeyHex() string {
+	return hex.EncodeToString(w.publicKey.SerializeCompressed())
 }
 
-// SetFeeRate 设置费率
+// PrivateKeyBytes 返回32字节原始私钥的副本，调用方需自行妥善保管，切勿写入日志
+func (w *BitcoinWallet) PrivateKeyBytes() []byte {
+	return w.privateKey.Serialize()
+}
+
+// SetFeeRate 设置整数费率(sat/vB)
 func (w *BitcoinWallet) SetFeeRate(feeRate int64) {
-	w.feeRate = feeRate
+	w.feeRateMilli = feeRate * 1000
+}
+
+// SetFeeRateFloat 设置可带小数的费率(sat/vB)，精度为0.001 sat/vB，如1.5
+// 手续费按虚拟大小乘以费率后向上取整，且不低于最低转发费率
+func (w *BitcoinWallet) SetFeeRateFloat(satPerVByte float64) {
+	if math.IsNaN(satPerVByte) || satPerVByte <= 0 {
+		w.feeRateMilli = 0
+		return
+	}
+	w.feeRateMilli = int64(math.Round(satPerVByte * 1000))
 }
 
-// GetFeeRate 获取费率
+// GetFeeRate 获取费率(sat/vB)，小数费率向上取整
 func (w *BitcoinWallet) GetFeeRate() int64 {
-	return w.feeRate
+	return (w.feeRateMilli + 999) / 1000
+}
+
+// GetFeeRateFloat 获取可带小数的费率(sat/vB)
+func (w *BitcoinWallet) GetFeeRateFloat() float64 {
+	return float64(w.feeRateMilli) / 1000
 }
 
 // GetAddress 获取指定类型的地址
@@ -115,6 +255,46 @@ func (w *BitcoinWallet) GetAddress(addrType AddressType) (string, error) {
 	}
 }
 
+// addressScript 获取指定类型地址的输出脚本
+func (w *BitcoinWallet) addressScript(addrType AddressType) ([]byte, error) {
+	addr, err := w.GetAddress(addrType)
+	if err != nil {
+		return nil, err
+	}
+
+	addrObj, err := btcutil.DecodeAddress(addr, w.network)
+	if err != nil {
+		return nil, fmt.Errorf("解析地址失败: %w", err)
+	}
+
+	return txscript.PayToAddrScript(addrObj)
+}
+
+// ownScriptType 判断输出脚本是否属于本钱包，并返回对应的地址类型
+func (w *BitcoinWallet) ownScriptType(pkScript []byte) (AddressType, bool) {
+	if w.publicKey == nil {
+		// 观察钱包不持有密钥
+		return "", false
+	}
+	for _, addrType := range []AddressType{P2PKH, P2WPKH, P2SH, P2TR} {
+		script, err := w.addressScript(addrType)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(script, pkScript) {
+			return addrType, true
+		}
+	}
+
+	// HD钱包已使用的找零地址同样属于本钱包，签名时使用内部链上对应的子密钥
+	if w.hdChange() {
+		if _, addrType, ok := w.hd.findChangeScript(w.hdAccount, pkScript); ok {
+			return addrType, true
+		}
+	}
+	return "", false
+}
+
 // getP2PKHAddress 获取P2PKH地址
 func (w *BitcoinWallet) getP2PKHAddress() (string, error) {
 	pubKeyHash := btcutil.Hash160(w.publicKey.SerializeCompressed())
@@ -137,13 +317,8 @@ func (w *BitcoinWallet) getP2WPKHAddress() (string, error) {
 
 // getP2SHAddress 获取P2SH地址 (嵌套SegWit)
 func (w *BitcoinWallet) getP2SHAddress() (string, error) {
-	pubKeyHash := btcutil.Hash160(w.publicKey.SerializeCompressed())
-
 	// 创建P2WPKH赎回脚本
-	witnessScript, err := txscript.NewScriptBuilder().
-		AddOp(txscript.OP_0).
-		AddData(pubKeyHash).
-		Script()
+	witnessScript, err := w.nestedRedeemScript()
 	if err != nil {
 		return "", err
 	}
@@ -157,6 +332,19 @@ func (w *BitcoinWallet) getP2SHAddress() (string, error) {
 	return addr.EncodeAddress(), nil
 }
 
+// nestedRedeemScript 获取嵌套SegWit使用的P2WPKH赎回脚本
+func (w *BitcoinWallet) nestedRedeemScript() ([]byte, error) {
+	return nestedP2WPKHRedeemScript(w.publicKey.SerializeCompressed())
+}
+
+// nestedP2WPKHRedeemScript 根据压缩公钥创建P2SH-P2WPKH的赎回脚本(OP_0 <pubkey hash>)
+func nestedP2WPKHRedeemScript(pubKey []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_0).
+		AddData(btcutil.Hash160(pubKey)).
+		Script()
+}
+
 // getP2TRAddress 获取P2TR地址
 func (w *BitcoinWallet) getP2TRAddress() (string, error) {
 	tapKey := txscript.ComputeTaprootKeyNoScript(w.publicKey)
@@ -169,149 +357,215 @@ func (w *BitcoinWallet) getP2TRAddress() (string, error) {
 
 // GetBalance 获取地址余额
 func (w *BitcoinWallet) GetBalance(address string) (int64, error) {
-	url := fmt.Sprintf("%s/address/%s", w.apiURL, address)
+	return w.GetBalanceContext(context.Background(), address)
+}
 
-	resp, err := w.httpClient.Get(url)
+// GetBalanceContext 获取地址余额，支持通过ctx取消请求
+func (w *BitcoinWallet) GetBalanceContext(ctx context.Context, address string) (int64, error) {
+	return w.backend.Balance(ctx, address)
+}
+
+// GetUTXOs 获取地址的UTXO，地址没有UTXO时返回空切片，请求失败或服务端返回非200响应时返回错误
+func (w *BitcoinWallet) GetUTXOs(address string) ([]UTXO, error) {
+	return w.GetUTXOsContext(context.Background(), address)
+}
+
+// GetUTXOsContext 获取地址的UTXO，支持通过ctx取消请求
+func (w *BitcoinWallet) GetUTXOsContext(ctx context.Context, address string) ([]UTXO, error) {
+	utxos, err := w.backend.UTXOs(ctx, address)
 	if err != nil {
-		return 0, fmt.Errorf("请求余额失败: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		msg := strings.TrimSpace(string(body))
-		if msg == "" {
-			msg = resp.Status
+	return w.annotateUTXOs(ctx, address, utxos)
+}
+
+// annotateUTXOs 按需填充UTXO的确认数，并为未携带所属地址和输出脚本的UTXO补全
+func (w *BitcoinWallet) annotateUTXOs(ctx context.Context, address string, utxos []UTXO) ([]UTXO, error) {
+	// 要求多于1个确认时需要按最新区块高度计算确认数
+	if w.minConfirmations > 1 {
+		if err := w.fillConfirmations(ctx, utxos); err != nil {
+			return nil, err
 		}
-		return 0, fmt.Errorf("请求余额失败: %s", msg)
 	}
 
-	var result struct {
-		ChainStats struct {
-			FundedTxoSum int64 `json:"funded_txo_sum"`
-			SpentTxoSum  int64 `json:"spent_txo_sum"`
-		} `json:"chain_stats"`
+	// 尽量填充所属地址和输出脚本，地址无法解析时保持为空
+	var pkScript []byte
+	if addr, err := btcutil.DecodeAddress(address, w.network); err == nil {
+		pkScript, _ = txscript.PayToAddrScript(addr)
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("解析余额失败: %w", err)
+	for i := range utxos {
+		if utxos[i].Address == "" {
+			utxos[i].Address = address
+		}
+		if len(utxos[i].PkScript) == 0 {
+			utxos[i].PkScript = pkScript
+		}
 	}
 
-	return result.ChainStats.FundedTxoSum - result.ChainStats.SpentTxoSum, nil
+	return utxos, nil
 }
 
-// GetUTXOs 获取地址的UTXO
-func (w *BitcoinWallet) GetUTXOs(address string) ([]UTXO, error) {
-	url := fmt.Sprintf("%s/address/%s/utxo", w.apiURL, address)
+// GetTxHex 获取交易的原始十六进制数据
+func (w *BitcoinWallet) GetTxHex(txID string) (string, error) {
+	return w.GetTxHexContext(context.Background(), txID)
+}
 
-	resp, err := w.httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("请求UTXO失败: %w", err)
-	}
-	defer resp.Body.Close()
+// GetTxHexContext 获取交易的原始十六进制数据，支持通过ctx取消请求
+func (w *BitcoinWallet) GetTxHexContext(ctx context.Context, txID string) (string, error) {
+	return w.backend.TxHex(ctx, txID)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		msg := strings.TrimSpace(string(body))
-		if msg == "" {
-			msg = resp.Status
-		}
-		return nil, fmt.Errorf("请求UTXO失败: %s", msg)
+// GetOutput 获取链上交易指定输出的金额和输出脚本，可用于为外部提供的outpoint补全前序输出信息
+func (w *BitcoinWallet) GetOutput(txID string, vout uint32) (int64, []byte, error) {
+	return w.GetOutputContext(context.Background(), txID, vout)
+}
+
+// GetOutputContext 获取交易输出的金额和输出脚本，支持通过ctx取消请求
+// 通过原始交易数据读取并校验交易哈希，不依赖区块浏览器返回的解析结果
+func (w *BitcoinWallet) GetOutputContext(ctx context.Context, txID string, vout uint32) (int64, []byte, error) {
+	tx, err := w.fetchTransaction(ctx, txID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("获取交易失败: %w", err)
 	}
 
-	var utxos []UTXO
-	if err := json.NewDecoder(resp.Body).Decode(&utxos); err != nil {
-		return nil, fmt.Errorf("解析UTXO失败: %w", err)
+	if int(vout) >= len(tx.TxOut) {
+		return 0, nil, fmt.Errorf("交易%s没有输出%d", txID, vout)
 	}
 
-	return utxos, nil
+	output := tx.TxOut[vout]
+	return output.Value, output.PkScript, nil
 }
 
-// GetTxHex 获取交易的原始十六进制数据
-func (w *BitcoinWallet) GetTxHex(txID string) (string, error) {
-	url := fmt.Sprintf("%s/tx/%s/hex", w.apiURL, txID)
+// BroadcastTransaction 广播交易，返回根据交易内容计算的交易ID
+func (w *BitcoinWallet) BroadcastTransaction(txHex string) (string, error) {
+	return w.BroadcastTransactionContext(context.Background(), txHex)
+}
 
-	resp, err := w.httpClient.Get(url)
+// BroadcastTransactionContext 广播交易，支持通过ctx取消请求
+// 返回的交易ID由本地计算，服务端返回的ID与之不一致时同时返回*TxIDMismatchError，
+// 此时交易已被服务端接受，可通过errors.Is(err, ErrTxIDMismatch)判断。
+// 节点返回交易已在内存池或已上链时视为成功，因此可以安全地重试广播
+func (w *BitcoinWallet) BroadcastTransactionContext(ctx context.Context, txHex string) (string, error) {
+	txHex = strings.TrimSpace(txHex)
+	data, err := hex.DecodeString(txHex)
 	if err != nil {
-		return "", fmt.Errorf("请求交易数据失败: %w", err)
+		return "", fmt.Errorf("解码交易失败: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		msg := strings.TrimSpace(string(body))
-		if msg == "" {
-			msg = resp.Status
-		}
-		return "", fmt.Errorf("请求交易数据失败: %s", msg)
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("解析交易失败: %w", err)
 	}
+	localTxID := tx.TxHash().String()
 
-	body, err := io.ReadAll(resp.Body)
+	remoteTxID, err := w.broadcast(ctx, txHex)
 	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+		// 重试广播时节点可能已经收到过该交易，视为广播成功
+		if isAlreadyBroadcast(err) {
+			w.recordBroadcast(&tx, localTxID)
+			return localTxID, nil
+		}
+		return "", err
 	}
+	w.recordBroadcast(&tx, localTxID)
 
-	return string(body), nil
-}
+	if remote := strings.TrimSpace(remoteTxID); !strings.EqualFold(remote, localTxID) {
+		return localTxID, &TxIDMismatchError{Local: localTxID, Remote: remote}
+	}
 
-// BroadcastTransaction 广播交易
-func (w *BitcoinWallet) BroadcastTransaction(txHex string) (string, error) {
-	url := fmt.Sprintf("%s/tx", w.apiURL)
+	return localTxID, nil
+}
 
-	resp, err := w.httpClient.Post(url, "text/plain", bytes.NewBufferString(txHex))
-	if err != nil {
-		return "", fmt.Errorf("广播交易失败: %w", err)
+// recordBroadcast 记录广播成功的交易，HD钱包同时将交易使用的找零索引标记为已使用
+func (w *BitcoinWallet) recordBroadcast(tx *wire.MsgTx, txID string) {
+	w.recordOwnTx(txID)
+	if w.hdChange() {
+		w.hd.markChangeUsed(w.hdAccount, tx)
 	}
-	defer resp.Body.Close()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("读取响应失败: %w", err)
+// alreadyBroadcastMessages 节点表示交易已在内存池或已上链的错误信息(Bitcoin Core及其转发的Esplora响应)
+var alreadyBroadcastMessages = []string{
+	"txn-already-known",
+	"txn-already-in-mempool",
+	"transaction already in block chain",
+	"transaction outputs already in utxo set",
+}
+
+// isAlreadyBroadcast 判断广播失败是否因为交易已被节点接受
+func isAlreadyBroadcast(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		msg := strings.TrimSpace(string(body))
-		if msg == "" {
-			msg = resp.Status
+	body := strings.ToLower(apiErr.Body)
+	for _, msg := range alreadyBroadcastMessages {
+		if strings.Contains(body, msg) {
+			return true
 		}
-		return "", fmt.Errorf("广播失败: %s", msg)
 	}
-
-	return string(body), nil
+	return false
 }
 
 // SelectUTXOs 选择足够的UTXO来支付
+// 返回的切片总是新分配的，与utxos不共享底层数组，之后修改utxos的元素或顺序不会影响选择结果。
+// UTXO按值复制，PkScript等引用类型字段仍与输入共享，调用方不应原地修改这些字节
 func (w *BitcoinWallet) SelectUTXOs(utxos []UTXO, amount int64) ([]UTXO, int64, error) {
+	return w.SelectUTXOsInto(nil, utxos, amount)
+}
+
+// SelectUTXOsInto 与SelectUTXOs相同，但复用dst的底层数组保存结果，容量足够时不再分配内存
+// 适合在循环中反复选择的场景，dst的原有内容会被覆盖，且不能与utxos共享底层数组。
+// 返回的切片可能引用dst的底层数组，下一次以同一dst调用前应处理完本次结果
+func (w *BitcoinWallet) SelectUTXOsInto(dst, utxos []UTXO, amount int64) ([]UTXO, int64, error) {
 	if len(utxos) == 0 {
-		return nil, 0, fmt.Errorf("没有可用的UTXO")
+		return nil, 0, ErrNoUTXOs
 	}
 
 	if amount <= 0 {
 		return nil, 0, fmt.Errorf("金额必须大于0")
 	}
 
-	sorted := append([]UTXO(nil), utxos...)
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Value < sorted[j].Value
-	})
+	utxos = w.filterFrozen(utxos)
+	if len(utxos) == 0 {
+		return nil, 0, fmt.Errorf("%w: 全部UTXO已被冻结", ErrNoSpendableUTXOs)
+	}
 
-	var selected []UTXO
-	var total int64
+	utxos = w.filterByConfirmations(utxos)
+	if len(utxos) == 0 {
+		return nil, 0, fmt.Errorf("%w: 没有满足最小确认数(%d)的UTXO", ErrNoSpendableUTXOs, w.minConfirmations)
+	}
 
-	for _, utxo := range sorted {
-		if utxo.Value <= 0 {
-			continue
+	if w.addressIsolation {
+		// 优先只使用单个地址的UTXO，所有地址都不够时才跨地址选择
+		for _, group := range groupUTXOsByAddress(utxos, amount) {
+			if selected, total, err := w.selectWithStrategy(dst, group, amount); err == nil {
+				return selected, total, nil
+			}
 		}
+	}
 
-		selected = append(selected, utxo)
-		total += utxo.Value
+	return w.selectWithStrategy(dst, utxos, amount)
+}
 
-		if total >= amount {
+// selectWithStrategy 按当前选择策略从utxos中选择足够支付amount的UTXO，结果写入dst的底层数组
+func (w *BitcoinWallet) selectWithStrategy(dst, utxos []UTXO, amount int64) ([]UTXO, int64, error) {
+	switch w.coinSelection {
+	case LargestFirst:
+		return selectGreedy(dst, utxos, amount, true)
+	case RandomImproved:
+		return selectRandomImprove(dst, utxos, amount, w.coinSelectionRand())
+	case BranchAndBound:
+		// 找零低于dust阈值时会并入手续费，因此以dust阈值作为匹配窗口
+		if selected, total, ok := selectBranchAndBound(dst, utxos, amount, dustThreshold); ok {
 			return selected, total, nil
 		}
+		return selectGreedy(dst, utxos, amount, true)
+	default:
+		return selectGreedy(dst, utxos, amount, false)
 	}
-
-	return nil, 0, fmt.Errorf("余额不足: 需要 %d, 可用 %d", amount, total)
 }
 
 // EstimateTxSize 估算交易大小
@@ -328,10 +582,10 @@ func (w *BitcoinWallet) EstimateTxSize(inputs, outputs int, addrType AddressType
 		vSize := (baseSize*3 + baseSize + witnessSize) / 4
 		return vSize
 	case P2SH:
-		// 嵌套SegWit
-		baseSize := 10 + inputs*148 + outputs*34
-		witnessSize := inputs*107 + 2
-		vSize := (baseSize*3 + baseSize + witnessSize) / 4
+		// 嵌套SegWit: scriptSig只包含赎回脚本推送(23字节)，签名和公钥在见证中
+		baseSize := 10 + inputs*(41+23) + outputs*32
+		witnessSize := inputs*108 + 2
+		vSize := (baseSize*3 + baseSize + witnessSize + 3) / 4
 		return vSize
 	case P2TR:
 		// Taproot
@@ -346,13 +600,25 @@ func (w *BitcoinWallet) EstimateTxSize(inputs, outputs int, addrType AddressType
 
 // SignP2PKHTransaction 签名P2PKH交易
 func (w *BitcoinWallet) SignP2PKHTransaction(tx *wire.MsgTx, idx int, pkScript []byte) error {
-	sigHash, err := txscript.CalcSignatureHash(pkScript, txscript.SigHashAll, tx, idx)
-	if err != nil {
-		return fmt.Errorf("计算签名哈希失败: %w", err)
+	return w.SignP2PKHTransactionWithSigHash(tx, idx, pkScript, txscript.SigHashAll)
+}
+
+// SignP2PKHTransactionWithSigHash 使用指定的sighash类型签名P2PKH交易
+// 各WithSigHash签名方法在SIGHASH_SINGLE没有同索引的输出时均返回ErrSigHashSingleNoOutput
+func (w *BitcoinWallet) SignP2PKHTransactionWithSigHash(
+	tx *wire.MsgTx,
+	idx int,
+	pkScript []byte,
+	hashType txscript.SigHashType,
+) error {
+	if err := validateECDSASigHash(tx, idx, hashType); err != nil {
+		return err
 	}
 
-	signature := ecdsa.Sign(w.privateKey, sigHash)
-	sigWithHashType := append(signature.Serialize(), byte(txscript.SigHashAll))
+	sigWithHashType, err := w.p2pkhSignature(tx, idx, pkScript, hashType)
+	if err != nil {
+		return err
+	}
 
 	tx.TxIn[idx].SignatureScript, err = txscript.NewScriptBuilder().
 		AddData(sigWithHashType).
@@ -365,18 +631,40 @@ func (w *BitcoinWallet) SignP2PKHTransaction(tx *wire.MsgTx, idx int, pkScript [
 	return nil
 }
 
+// p2pkhSignature 生成P2PKH输入的签名(附带sighash类型)
+// CalcSignatureHash在交易副本上计算哈希，除idx外的输入脚本都会被清空，
+// 因此多输入交易逐个签名时，已写入的SignatureScript不会影响后续输入的签名哈希
+func (w *BitcoinWallet) p2pkhSignature(tx *wire.MsgTx, idx int, pkScript []byte, hashType txscript.SigHashType) ([]byte, error) {
+	sigHash, err := txscript.CalcSignatureHash(pkScript, hashType, tx, idx)
+	if err != nil {
+		return nil, fmt.Errorf("计算签名哈希失败: %w", err)
+	}
+
+	signature := w.ecdsaSign(sigHash)
+	return append(signature.Serialize(), byte(hashType)), nil
+}
+
 // SignP2WPKHTransaction 签名P2WPKH交易
 func (w *BitcoinWallet) SignP2WPKHTransaction(tx *wire.MsgTx, idx int, value int64, pkScript []byte) error {
-	prevFetcher := txscript.NewCannedPrevOutputFetcher(pkScript, value)
-	sigHash, err := txscript.CalcWitnessSigHash(
-		pkScript, txscript.NewTxSigHashes(tx, prevFetcher), txscript.SigHashAll, tx, idx, value,
-	)
-	if err != nil {
-		return fmt.Errorf("计算witness签名哈希失败: %w", err)
+	return w.SignP2WPKHTransactionWithSigHash(tx, idx, value, pkScript, txscript.SigHashAll)
+}
+
+// SignP2WPKHTransactionWithSigHash 使用指定的sighash类型签名P2WPKH交易
+func (w *BitcoinWallet) SignP2WPKHTransactionWithSigHash(
+	tx *wire.MsgTx,
+	idx int,
+	value int64,
+	pkScript []byte,
+	hashType txscript.SigHashType,
+) error {
+	if err := validateECDSASigHash(tx, idx, hashType); err != nil {
+		return err
 	}
 
-	signature := ecdsa.Sign(w.privateKey, sigHash)
-	sigWithHashType := append(signature.Serialize(), byte(txscript.SigHashAll))
+	sigWithHashType, err := w.witnessV0Signature(tx, idx, value, pkScript, pkScript, hashType)
+	if err != nil {
+		return err
+	}
 
 	tx.TxIn[idx].Witness = wire.TxWitness{
 		sigWithHashType,
@@ -386,35 +674,60 @@ func (w *BitcoinWallet) SignP2WPKHTransaction(tx *wire.MsgTx, idx int, value int
 	return nil
 }
 
+// witnessV0Signature 生成SegWit v0输入的签名(附带sighash类型)
+// scriptCode为P2WPKH见证程序，签名哈希按BIP143规则计算
+func (w *BitcoinWallet) witnessV0Signature(
+	tx *wire.MsgTx,
+	idx int,
+	value int64,
+	pkScript, scriptCode []byte,
+	hashType txscript.SigHashType,
+) ([]byte, error) {
+	prevFetcher := txscript.NewCannedPrevOutputFetcher(pkScript, value)
+	sigHashes := txscript.NewTxSigHashes(tx, prevFetcher)
+
+	sigHash, err := txscript.CalcWitnessSigHash(
+		scriptCode, sigHashes, hashType, tx, idx, value,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("计算witness签名哈希失败: %w", err)
+	}
+
+	signature := w.ecdsaSign(sigHash)
+	return append(signature.Serialize(), byte(hashType)), nil
+}
+
 // SignP2SHTransaction 签名P2SH交易
 func (w *BitcoinWallet) SignP2SHTransaction(tx *wire.MsgTx, idx int, value int64, pkScript []byte) error {
-	// 获取发送方地址的公钥哈希
-	pubKeyHash := btcutil.Hash160(w.publicKey.SerializeCompressed())
+	return w.SignP2SHTransactionWithSigHash(tx, idx, value, pkScript, txscript.SigHashAll)
+}
 
-	// 创建P2WPKH赎回脚本
-	witnessScript, err := txscript.NewScriptBuilder().
-		AddOp(txscript.OP_0).
-		AddData(pubKeyHash).
-		Script()
+// SignP2SHTransactionWithSigHash 使用指定的sighash类型签名P2SH-P2WPKH交易
+func (w *BitcoinWallet) SignP2SHTransactionWithSigHash(
+	tx *wire.MsgTx,
+	idx int,
+	value int64,
+	pkScript []byte,
+	hashType txscript.SigHashType,
+) error {
+	if err := validateECDSASigHash(tx, idx, hashType); err != nil {
+		return err
+	}
+
+	// 创建P2WPKH赎回脚本(OP_0 <pubkey hash>)
+	redeemScript, err := w.nestedRedeemScript()
 	if err != nil {
 		return fmt.Errorf("创建赎回脚本失败: %w", err)
 	}
 
-	// 计算签名哈希（使用P2WPKH脚本，因为这是嵌套SegWit）
-	prevFetcher := txscript.NewCannedPrevOutputFetcher(pkScript, value)
-	sigHashes := txscript.NewTxSigHashes(tx, prevFetcher)
-
-	sigHash, err := txscript.CalcWitnessSigHash(
-		witnessScript, sigHashes, txscript.SigHashAll, tx, idx, value,
-	)
+	// 嵌套SegWit按赎回脚本中的见证程序签名，CalcWitnessSigHash会按BIP143
+	// 把P2WPKH程序展开为OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG作为scriptCode，
+	// 与原生P2WPKH相同；pkScript(P2SH脚本)只用于prevout，不参与scriptCode
+	sigWithHashType, err := w.witnessV0Signature(tx, idx, value, pkScript, redeemScript, hashType)
 	if err != nil {
-		return fmt.Errorf("计算witness签名哈希失败: %w", err)
+		return err
 	}
 
-	// 生成签名
-	signature := ecdsa.Sign(w.privateKey, sigHash)
-	sigWithHashType := append(signature.Serialize(), byte(txscript.SigHashAll))
-
 	// 设置witness数据（签名 + 公钥）
 	tx.TxIn[idx].Witness = wire.TxWitness{
 		sigWithHashType,
@@ -423,7 +736,7 @@ func (w *BitcoinWallet) SignP2SHTransaction(tx *wire.MsgTx, idx int, value int64
 
 	// 设置SignatureScript为完整的赎回脚本（这是P2SH-Nested SegWit的正确方式）
 	tx.TxIn[idx].SignatureScript, err = txscript.NewScriptBuilder().
-		AddData(witnessScript).
+		AddData(redeemScript).
 		Script()
 	if err != nil {
 		return fmt.Errorf("构建签名脚本失败: %w", err)
@@ -434,40 +747,56 @@ func (w *BitcoinWallet) SignP2SHTransaction(tx *wire.MsgTx, idx int, value int64
 
 // SignP2TRTransaction 签名P2TR交易
 func (w *BitcoinWallet) SignP2TRTransaction(tx *wire.MsgTx, idx int, value int64, pkScript []byte) error {
-	// 对于P2TR，需要重新生成正确的prevOutputScript
-	// 因为传入的pkScript可能是通过PayToAddrScript生成的，但P2TR需要特殊的处理
-
-	// 生成P2TR地址
-	p2trAddr, err := w.getP2TRAddress()
-	if err != nil {
-		return fmt.Errorf("获取P2TR地址失败: %w", err)
-	}
+	return w.SignP2TRTransactionWithSigHash(tx, idx, value, pkScript, txscript.SigHashDefault)
+}
 
-	// 解析P2TR地址
-	addrObj, err := btcutil.DecodeAddress(p2trAddr, w.network)
-	if err != nil {
-		return fmt.Errorf("解析P2TR地址失败: %w", err)
+// SignP2TRTransactionWithSigHash 使用指定的sighash类型签名P2TR交易
+func (w *BitcoinWallet) SignP2TRTransactionWithSigHash(
+	tx *wire.MsgTx,
+	idx int,
+	value int64,
+	pkScript []byte,
+	hashType txscript.SigHashType,
+) error {
+	if err := validateTaprootSigHash(tx, idx, hashType); err != nil {
+		return err
 	}
 
-	// 生成正确的P2TR输出脚本
-	prevScript, err := txscript.PayToAddrScript(addrObj)
+	// 对于P2TR，需要重新生成正确的prevOutputScript
+	// 因为传入的pkScript可能是通过PayToAddrScript生成的，但P2TR需要特殊的处理
+	prevScript, err := w.addressScript(P2TR)
 	if err != nil {
 		return fmt.Errorf("生成P2TR脚本失败: %w", err)
 	}
 
 	// 创建PrevOutputFetcher
 	prevFetcher := txscript.NewCannedPrevOutputFetcher(prevScript, value)
-	sighashes := txscript.NewTxSigHashes(tx, prevFetcher)
 
-	// 使用RawTxInTaprootSignature生成Taproot签名
-	sig, err := txscript.RawTxInTaprootSignature(
-		tx, sighashes, idx, value, prevScript, nil, txscript.SigHashDefault, w.privateKey,
-	)
+	sig, err := w.taprootSignature(tx, idx, value, prevScript, prevFetcher, hashType)
 	if err != nil {
-		return fmt.Errorf("生成Taproot签名失败: %w", err)
+		return err
 	}
 
 	// 设置witness数据（只有签名，Taproot key-path不需要公钥）
 	tx.TxIn[idx].Witness = wire.TxWitness{sig}
 	return nil
 }
+
+// taprootSignature 生成Taproot key-path签名
+func (w *BitcoinWallet) taprootSignature(
+	tx *wire.MsgTx,
+	idx int,
+	value int64,
+	prevScript []byte,
+	prevFetcher txscript.PrevOutputFetcher,
+	hashType txscript.SigHashType,
+) ([]byte, error) {
+	sighashes := txscript.NewTxSigHashes(tx, prevFetcher)
+
+	sig, err := w.taprootKeySpendSignature(tx, sighashes, idx, value, prevScript, nil, hashType)
+	if err != nil {
+		return nil, fmt.Errorf("生成Taproot签名失败: %w", err)
+	}
+
+	return sig, nil
+}
@@ -0,0 +1,372 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestKeyExportRoundTrip(t *testing.T) {
+	priv := bytes.Repeat([]byte{0x01}, 32)
+	privKey, _ := btcec.PrivKeyFromBytes(priv)
+
+	cases := []struct {
+		network    Network
+		params     *chaincfg.Params
+		compressed bool
+	}{
+		{MainNet, &chaincfg.MainNetParams, true},
+		{MainNet, &chaincfg.MainNetParams, false},
+		{TestNet, &chaincfg.TestNet3Params, true},
+	}
+	for _, c := range cases {
+		wif, err := btcutil.NewWIF(privKey, c.params, c.compressed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := NewWallet(wif.String(), c.network)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 导出的WIF保留原网络和压缩标志
+		got, err := w.WIF()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != wif.String() {
+			t.Fatalf("%s网络导出的WIF为%s，期望%s", c.network, got, wif.String())
+		}
+
+		if !bytes.Equal(w.PrivateKeyBytes(), priv) {
+			t.Fatalf("导出的私钥为%x，期望%x", w.PrivateKeyBytes(), priv)
+		}
+		if want := hex.EncodeToString(privKey.PubKey().SerializeCompressed()); w.PublicKeyHex() != want {
+			t.Fatalf("导出的公钥为%s，期望%s", w.PublicKeyHex(), want)
+		}
+	}
+
+	// 修改返回的私钥不影响钱包
+	w := newTestWallet(t)
+	exported := w.PrivateKeyBytes()
+	exported[0] ^= 0xff
+	if !bytes.Equal(w.PrivateKeyBytes(), priv) {
+		t.Fatal("PrivateKeyBytes应返回私钥副本")
+	}
+}
+
+func TestNewWalletFromBytesMatchesWIF(t *testing.T) {
+	priv := bytes.Repeat([]byte{0x01}, 32)
+	fromWIF := newTestWallet(t)
+
+	fromBytes, err := NewWalletFromBytes(priv, true, TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromHex, err := NewWalletFromHex(" "+hex.EncodeToString(priv)+"\n", true, TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, addrType := range []AddressType{P2PKH, P2SH, P2WPKH, P2TR} {
+		want, _ := fromWIF.GetAddress(addrType)
+		for name, w := range map[string]*BitcoinWallet{"bytes": fromBytes, "hex": fromHex} {
+			if got, _ := w.GetAddress(addrType); got != want {
+				t.Fatalf("%s创建的钱包%s地址为%s，期望%s", name, addrType, got, want)
+			}
+		}
+	}
+	if wif, _ := fromBytes.WIF(); wif != mustWIF(t, fromWIF) {
+		t.Fatalf("原始私钥钱包导出的WIF为%s，期望%s", wif, mustWIF(t, fromWIF))
+	}
+
+	// 私钥必须为32字节且在[1, N-1]范围内
+	order := "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141"
+	for _, hexKey := range []string{"", "01", hex.EncodeToString(make([]byte, 32)), order, "zz"} {
+		if _, err := NewWalletFromHex(hexKey, true, TestNet); err == nil {
+			t.Fatalf("无效私钥%q应返回错误", hexKey)
+		}
+	}
+}
+
+func TestGenerateWallet(t *testing.T) {
+	w1, wif1, err := GenerateWallet(TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, wif2, err := GenerateWallet(TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wif1 == wif2 || bytes.Equal(w1.PrivateKeyBytes(), w2.PrivateKeyBytes()) {
+		t.Fatal("两次生成的私钥不应相同")
+	}
+
+	for _, w := range []*BitcoinWallet{w1, w2} {
+		for _, addrType := range []AddressType{P2PKH, P2SH, P2WPKH, P2TR} {
+			addr, err := w.GetAddress(addrType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ValidateAddress(addr, TestNet); err != nil || got != addrType {
+				t.Fatalf("生成钱包的%s地址%s无效: %s, %v", addrType, addr, got, err)
+			}
+		}
+	}
+
+	// 备份的WIF可以恢复出同一个钱包
+	restored, err := NewWallet(wif1, TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a1, _ := w1.GetAddress(P2WPKH)
+	a2, _ := restored.GetAddress(P2WPKH)
+	if a1 != a2 {
+		t.Fatalf("WIF恢复的钱包地址为%s，期望%s", a2, a1)
+	}
+}
+
+// mustWIF 导出钱包的WIF，失败时终止测试
+func mustWIF(t *testing.T, w *BitcoinWallet) string {
+	t.Helper()
+
+	wif, err := w.WIF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wif
+}
+
+// testBroadcastServer 启动模拟的广播接口，所有广播请求都以status和body响应
+func testBroadcastServer(t *testing.T, w *BitcoinWallet, status int, body string) {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(status)
+		rw.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testTxHex 返回一笔花费虚构输出的简单交易及其交易ID
+func testTxHex(t *testing.T) (string, string) {
+	t.Helper()
+
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{7}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(10000, []byte{txscript.OP_TRUE}))
+	return serializeTx(t, tx), tx.TxHash().String()
+}
+
+func TestBroadcastTxIDMismatch(t *testing.T) {
+	w := newTestWallet(t)
+	txHex, txID := testTxHex(t)
+	wrongID := strings.Repeat("ab", 32)
+
+	testBroadcastServer(t, w, http.StatusOK, wrongID)
+	got, err := w.BroadcastTransaction(txHex)
+	var mismatch *TxIDMismatchError
+	if !errors.As(err, &mismatch) || !errors.Is(err, ErrTxIDMismatch) {
+		t.Fatalf("服务端返回错误的交易ID时应返回TxIDMismatchError，实际为%v", err)
+	}
+	if mismatch.Local != txID || mismatch.Remote != wrongID {
+		t.Fatalf("不一致详情为本地%s、服务端%s，期望%s、%s", mismatch.Local, mismatch.Remote, txID, wrongID)
+	}
+	if got != txID {
+		t.Fatalf("返回的交易ID为%s，应以本地计算的%s为准", got, txID)
+	}
+
+	// 首尾空白和大小写差异不算不一致
+	testBroadcastServer(t, w, http.StatusOK, " "+strings.ToUpper(txID)+"\n")
+	if got, err := w.BroadcastTransaction(txHex); err != nil || got != txID {
+		t.Fatalf("交易ID一致时应成功，实际为%s, %v", got, err)
+	}
+}
+
+func TestAlreadyBroadcastIsSuccess(t *testing.T) {
+	cases := []struct {
+		body    string
+		already bool
+	}{
+		// Esplora转发的Bitcoin Core RPC错误
+		{`sendrawtransaction RPC error: {"code":-27,"message":"Transaction already in block chain"}`, true},
+		{`sendrawtransaction RPC error: {"code":-27,"message":"Transaction outputs already in utxo set"}`, true},
+		{`sendrawtransaction RPC error: {"code":-26,"message":"txn-already-in-mempool"}`, true},
+		// Bitcoin Core的拒绝原因
+		{"txn-already-known", true},
+		{"txn-already-in-mempool", true},
+		{`sendrawtransaction RPC error: {"code":-25,"message":"bad-txns-inputs-missingorspent"}`, false},
+		{`sendrawtransaction RPC error: {"code":-26,"message":"min relay fee not met"}`, false},
+	}
+
+	for _, c := range cases {
+		if got := isAlreadyBroadcast(&APIError{StatusCode: http.StatusBadRequest, Body: c.body}); got != c.already {
+			t.Fatalf("%q应判断为%v，实际为%v", c.body, c.already, got)
+		}
+	}
+	if isAlreadyBroadcast(errors.New("txn-already-known")) {
+		t.Fatal("非APIError的错误不应视为已广播")
+	}
+
+	// 广播重试时服务端报告交易已知，返回本地交易ID且不报错
+	w := newTestWallet(t)
+	txHex, txID := testTxHex(t)
+	for _, c := range cases {
+		testBroadcastServer(t, w, http.StatusBadRequest, c.body)
+		got, err := w.BroadcastTransaction(txHex)
+		if c.already && (err != nil || got != txID) {
+			t.Fatalf("%q应视为广播成功，实际为%s, %v", c.body, got, err)
+		}
+		if !c.already && err == nil {
+			t.Fatalf("%q应返回错误", c.body)
+		}
+	}
+}
+
+func TestSelectUTXOsCopySemantics(t *testing.T) {
+	w := newTestWallet(t)
+	utxos := []UTXO{
+		{TxID: strings.Repeat("1", 64), Value: 5000},
+		{TxID: strings.Repeat("2", 64), Value: 1000},
+		{TxID: strings.Repeat("3", 64), Value: 3000},
+	}
+	original := append([]UTXO(nil), utxos...)
+
+	selected, total, err := w.SelectUTXOs(utxos, 3500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := append([]UTXO(nil), selected...)
+
+	// 选择不改变输入的顺序，之后修改输入也不影响选择结果
+	if !reflect.DeepEqual(utxos, original) {
+		t.Fatal("选择不应修改输入切片")
+	}
+	for i := range utxos {
+		utxos[i].Value = 0
+		utxos[i].TxID = ""
+	}
+	utxos[0], utxos[2] = utxos[2], utxos[0]
+	if !reflect.DeepEqual(selected, want) {
+		t.Fatalf("修改输入后选择结果变为%+v", selected)
+	}
+	if total != 4000 {
+		t.Fatalf("选中金额为%d，期望1000+3000", total)
+	}
+
+	// SelectUTXOsInto复用dst的底层数组
+	dst := make([]UTXO, 0, len(original))
+	into, total, err := w.SelectUTXOsInto(dst, original, 3500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4000 || len(into) != 2 {
+		t.Fatalf("选中%d个UTXO共%d，期望与SelectUTXOs一致", len(into), total)
+	}
+	if &into[0] != &dst[:1][0] {
+		t.Fatal("容量足够时SelectUTXOsInto应复用dst的底层数组")
+	}
+	if !reflect.DeepEqual(into, want) {
+		t.Fatalf("SelectUTXOsInto的结果为%+v，期望%+v", into, want)
+	}
+	if allocs := testing.AllocsPerRun(100, func() {
+		w.SelectUTXOsInto(dst, original, 3500)
+	}); allocs != 0 {
+		t.Fatalf("复用dst时每次选择分配了%.0f次内存", allocs)
+	}
+}
+
+// testOutputServer 模拟Esplora的交易接口，/tx/{txid}返回解析后的JSON，/tx/{txid}/hex返回原始交易
+// 每次请求的路径记录到requests，返回/tx/{txid}接口的JSON
+func testOutputServer(t *testing.T, w *BitcoinWallet, tx *wire.MsgTx, requests *[]string) string {
+	t.Helper()
+
+	txID := tx.TxHash().String()
+	vouts := make([]string, len(tx.TxOut))
+	for i, txOut := range tx.TxOut {
+		vouts[i] = fmt.Sprintf(`{"scriptpubkey":"%x","value":%d}`, txOut.PkScript, txOut.Value)
+	}
+	txJSON := fmt.Sprintf(`{"txid":"%s","version":2,"locktime":0,"vout":[%s],"status":{"confirmed":true}}`,
+		txID, strings.Join(vouts, ","))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path)
+		switch {
+		case r.URL.Path == "/tx/"+txID:
+			rw.Write([]byte(txJSON))
+		case strings.HasSuffix(r.URL.Path, "/hex"):
+			// 不论请求哪个交易都返回tx，用于测试交易哈希校验
+			rw.Write([]byte(serializeTx(t, tx)))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	if err := w.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	return txJSON
+}
+
+func TestGetOutput(t *testing.T) {
+	w := newTestWallet(t)
+	wpkhScript, _ := w.addressScript(P2WPKH)
+	trScript, _ := w.addressScript(P2TR)
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{9}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(12345, wpkhScript))
+	tx.AddTxOut(wire.NewTxOut(67890, trScript))
+	txID := tx.TxHash().String()
+
+	var requests []string
+	txJSON := testOutputServer(t, w, tx, &requests)
+
+	var canned struct {
+		Vout []struct {
+			ScriptPubKey string `json:"scriptpubkey"`
+			Value        int64  `json:"value"`
+		} `json:"vout"`
+	}
+	if err := json.Unmarshal([]byte(txJSON), &canned); err != nil {
+		t.Fatal(err)
+	}
+
+	value, script, err := w.GetOutput(txID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != canned.Vout[1].Value || hex.EncodeToString(script) != canned.Vout[1].ScriptPubKey {
+		t.Fatalf("输出1为%d聪、脚本%x，期望浏览器返回的%d聪、脚本%s", value, script, canned.Vout[1].Value, canned.Vout[1].ScriptPubKey)
+	}
+	if !bytes.Equal(script, trScript) {
+		t.Fatal("输出1应为P2TR脚本")
+	}
+
+	if _, _, err := w.GetOutput(txID, 2); err == nil {
+		t.Fatal("输出索引超出范围时应返回错误")
+	}
+
+	// 服务端返回的交易与请求的交易ID不符时拒绝使用
+	if _, _, err := w.GetOutput(strings.Repeat("ab", 32), 1); err == nil {
+		t.Fatal("交易哈希不匹配时应返回错误")
+	}
+}
@@ -0,0 +1,306 @@
+package btc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// WatchOnlyWallet 观察钱包，只持有地址或扩展公钥，用于监控冷钱包余额和构建未签名交易
+// 查询和广播与BitcoinWallet共用同一套后端和HTTP设置，所有签名方法均返回ErrWatchOnly
+type WatchOnlyWallet struct {
+	wallet    *BitcoinWallet // 不持有密钥的内部钱包，只用于查询和构建交易
+	addresses []string
+}
+
+// NewWatchOnlyWallet 使用一组地址创建观察钱包，找零默认使用第一个地址
+func NewWatchOnlyWallet(addresses []string, network Network) (*WatchOnlyWallet, error) {
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("地址列表不能为空")
+	}
+
+	netParams, apiURL, err := networkParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &BitcoinWallet{network: netParams, defaultAPIURL: apiURL, feeRateMilli: 1000}
+	w.SetBackend(nil)
+
+	for i, addr := range addresses {
+		if _, err := w.decodeAndValidateAddress(addr); err != nil {
+			return nil, fmt.Errorf("地址%d无效: %w", i, err)
+		}
+	}
+
+	if err := w.SetChangeAddress(addresses[0]); err != nil {
+		return nil, err
+	}
+
+	return &WatchOnlyWallet{
+		wallet:    w,
+		addresses: append([]string(nil), addresses...),
+	}, nil
+}
+
+// NewWatchOnlyWalletFromXPub 使用账户层级的扩展公钥创建观察钱包
+// 按addrType派生接收链(0/i)和找零链(1/i)上各count个地址，找零默认使用找零链上的第一个地址
+func NewWatchOnlyWalletFromXPub(xpub string, addrType AddressType, count int, network Network) (*WatchOnlyWallet, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("地址数量必须大于0")
+	}
+
+	netParams, _, err := networkParams(network)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, fmt.Errorf("解析扩展公钥失败: %w", err)
+	}
+
+	// 允许通用的xpub/tpub，或与地址类型对应的SLIP-0132版本
+	var version [4]byte
+	copy(version[:], account.Version())
+	expected, err := xpubVersionFor(addrType, netParams)
+	if err != nil {
+		return nil, err
+	}
+	generic, _ := xpubVersionFor(P2PKH, netParams)
+	if version != expected && version != generic {
+		return nil, fmt.Errorf("扩展公钥与网络或地址类型不匹配")
+	}
+
+	var receive, change []string
+	for chain := uint32(0); chain <= 1; chain++ {
+		branch, err := account.Derive(chain)
+		if err != nil {
+			return nil, fmt.Errorf("派生密钥失败: %w", err)
+		}
+
+		for i := 0; i < count; i++ {
+			child, err := branch.Derive(uint32(i))
+			if err != nil {
+				return nil, fmt.Errorf("派生密钥失败: %w", err)
+			}
+
+			pubKey, err := child.ECPubKey()
+			if err != nil {
+				return nil, fmt.Errorf("获取公钥失败: %w", err)
+			}
+
+			// 只用于生成地址，不持有私钥
+			keyWallet := &BitcoinWallet{publicKey: pubKey, compressed: true, network: netParams}
+			addr, err := keyWallet.GetAddress(addrType)
+			if err != nil {
+				return nil, err
+			}
+
+			if chain == 0 {
+				receive = append(receive, addr)
+			} else {
+				change = append(change, addr)
+			}
+		}
+	}
+
+	wo, err := NewWatchOnlyWallet(append(receive, change...), network)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := wo.SetChangeAddress(change[0]); err != nil {
+		return nil, err
+	}
+
+	return wo, nil
+}
+
+// Addresses 返回观察的全部地址
+func (wo *WatchOnlyWallet) Addresses() []string {
+	return append([]string(nil), wo.addresses...)
+}
+
+// SetBackend 设置区块链数据后端，传入nil时恢复默认的Esplora后端
+func (wo *WatchOnlyWallet) SetBackend(b Backend) {
+	wo.wallet.SetBackend(b)
+}
+
+// SetAPIEndpoints 设置按优先级排列的多个API地址
+func (wo *WatchOnlyWallet) SetAPIEndpoints(endpoints []string) error {
+	return wo.wallet.SetAPIEndpoints(endpoints)
+}
+
+// SetFeeRate 设置构建交易使用的整数费率(sat/vB)
+func (wo *WatchOnlyWallet) SetFeeRate(feeRate int64) {
+	wo.wallet.SetFeeRate(feeRate)
+}
+
+// SetFeeRateFloat 设置构建交易使用的可带小数的费率(sat/vB)
+func (wo *WatchOnlyWallet) SetFeeRateFloat(satPerVByte float64) {
+	wo.wallet.SetFeeRateFloat(satPerVByte)
+}
+
+// CheckFeeRate 将设置的费率与浏览器当前的推荐费率比较，结果仅供参考
+func (wo *WatchOnlyWallet) CheckFeeRate() (FeeSanity, error) {
+	return wo.wallet.CheckFeeRate()
+}
+
+// CheckFeeRateContext 检查设置的费率，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) CheckFeeRateContext(ctx context.Context) (FeeSanity, error) {
+	return wo.wallet.CheckFeeRateContext(ctx)
+}
+
+// SetChangeAddress 设置构建交易时的找零地址
+func (wo *WatchOnlyWallet) SetChangeAddress(addr string) error {
+	if addr == "" {
+		return fmt.Errorf("观察钱包必须设置找零地址")
+	}
+	return wo.wallet.SetChangeAddress(addr)
+}
+
+// GetBalance 获取地址余额
+func (wo *WatchOnlyWallet) GetBalance(address string) (int64, error) {
+	return wo.GetBalanceContext(context.Background(), address)
+}
+
+// GetBalanceContext 获取地址余额，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) GetBalanceContext(ctx context.Context, address string) (int64, error) {
+	return wo.wallet.GetBalanceContext(ctx, address)
+}
+
+// GetUTXOs 获取地址的UTXO
+func (wo *WatchOnlyWallet) GetUTXOs(address string) ([]UTXO, error) {
+	return wo.GetUTXOsContext(context.Background(), address)
+}
+
+// GetUTXOsContext 获取地址的UTXO，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) GetUTXOsContext(ctx context.Context, address string) ([]UTXO, error) {
+	return wo.wallet.GetUTXOsContext(ctx, address)
+}
+
+// GetUTXOsForDescriptor 按范围描述符派生地址并查询UTXO，返回的UTXO标记了派生索引
+func (wo *WatchOnlyWallet) GetUTXOsForDescriptor(descriptor string, start, count int) ([]UTXO, error) {
+	return wo.wallet.GetUTXOsForDescriptor(descriptor, start, count)
+}
+
+// GetUTXOsForDescriptorContext 按描述符派生范围查询UTXO，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) GetUTXOsForDescriptorContext(ctx context.Context, descriptor string, start, count int) ([]UTXO, error) {
+	return wo.wallet.GetUTXOsForDescriptorContext(ctx, descriptor, start, count)
+}
+
+// GetTransactionHistory 获取地址的交易历史
+func (wo *WatchOnlyWallet) GetTransactionHistory(address string) ([]TxSummary, error) {
+	return wo.GetTransactionHistoryContext(context.Background(), address)
+}
+
+// GetTransactionHistoryContext 获取地址的交易历史，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) GetTransactionHistoryContext(ctx context.Context, address string) ([]TxSummary, error) {
+	return wo.wallet.GetTransactionHistoryContext(ctx, address)
+}
+
+// GetOutput 获取链上交易指定输出的金额和输出脚本
+func (wo *WatchOnlyWallet) GetOutput(txID string, vout uint32) (int64, []byte, error) {
+	return wo.GetOutputContext(context.Background(), txID, vout)
+}
+
+// GetOutputContext 获取交易输出的金额和输出脚本，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) GetOutputContext(ctx context.Context, txID string, vout uint32) (int64, []byte, error) {
+	return wo.wallet.GetOutputContext(ctx, txID, vout)
+}
+
+// ParsePaymentURI 解析BIP21支付链接，地址按观察钱包的网络校验
+func (wo *WatchOnlyWallet) ParsePaymentURI(uri string) (PaymentOutput, map[string]string, error) {
+	return wo.wallet.ParsePaymentURI(uri)
+}
+
+// CreateRawTransactionWithOutputs 构建未签名交易，输入大小按各UTXO的输出脚本估算，
+// UTXO没有输出脚本时按fromAddrType估算
+func (wo *WatchOnlyWallet) CreateRawTransactionWithOutputs(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) (string, error) {
+	return wo.wallet.CreateRawTransactionWithOutputs(fromAddrType, outputs, utxos)
+}
+
+// CreateRawTransactionWithOutputsBase64 构建未签名交易并以base64编码返回
+func (wo *WatchOnlyWallet) CreateRawTransactionWithOutputsBase64(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) (string, error) {
+	return wo.wallet.CreateRawTransactionWithOutputsBase64(fromAddrType, outputs, utxos)
+}
+
+// CreateRawTransactionWithOutputsBytes 构建未签名交易并返回序列化后的原始字节
+func (wo *WatchOnlyWallet) CreateRawTransactionWithOutputsBytes(
+	fromAddrType AddressType,
+	outputs []PaymentOutput,
+	utxos []UTXO,
+) ([]byte, error) {
+	return wo.wallet.CreateRawTransactionWithOutputsBytes(fromAddrType, outputs, utxos)
+}
+
+// BroadcastTransaction 广播在其他设备上签名的交易
+func (wo *WatchOnlyWallet) BroadcastTransaction(txHex string) (string, error) {
+	return wo.BroadcastTransactionContext(context.Background(), txHex)
+}
+
+// BroadcastTransactionContext 广播交易，支持通过ctx取消请求
+func (wo *WatchOnlyWallet) BroadcastTransactionContext(ctx context.Context, txHex string) (string, error) {
+	return wo.wallet.BroadcastTransactionContext(ctx, txHex)
+}
+
+// SignTransaction 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SignTransaction(tx *wire.MsgTx, fromAddrType AddressType, utxos []UTXO) error {
+	return ErrWatchOnly
+}
+
+// SignTransactionContext 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SignTransactionContext(
+	ctx context.Context,
+	tx *wire.MsgTx,
+	fromAddrType AddressType,
+	utxos []UTXO,
+) error {
+	return ErrWatchOnly
+}
+
+// SignRawTransaction观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SignRawTransaction(txHex string, fromAddrType AddressType, utxos []UTXO) (string, error) {
+	return "", ErrWatchOnly
+}
+
+// SignRawTransactionContext 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SignRawTransactionContext(
+	ctx context.Context,
+	txHex string,
+	fromAddrType AddressType,
+	utxos []UTXO,
+) (string, error) {
+	return "", ErrWatchOnly
+}
+
+// SignPSBT 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SignPSBT(psbtBase64 string, fromAddrType AddressType) (string, error) {
+	return "", ErrWatchOnly
+}
+
+// SignMessage 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SignMessage(message string) (string, error) {
+	return "", ErrWatchOnly
+}
+
+// SendMany 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SendMany(fromAddrType AddressType, outputs []PaymentOutput) (string, error) {
+	return "", ErrWatchOnly
+}
+
+// SendAll 观察钱包不能签名，总是返回ErrWatchOnly
+func (wo *WatchOnlyWallet) SendAll(fromAddrType AddressType, toAddress string) (string, error) {
+	return "", ErrWatchOnly
+}
@@ -0,0 +1,71 @@
+package btc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWatchOnlySigningAndBalance(t *testing.T) {
+	w := newTestWallet(t)
+	addr, _ := w.GetAddress(P2WPKH)
+	wo, err := NewWatchOnlyWallet([]string{addr}, TestNet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/address/" + addr:
+			rw.Write([]byte(`{"chain_stats":{"funded_txo_sum":80000,"spent_txo_sum":30000}}`))
+		case "/address/" + addr + "/utxo":
+			rw.Write([]byte(`[{"txid":"` + strings.Repeat("1", 64) + `","vout":0,"value":30000,"status":{"confirmed":true}},` +
+				`{"txid":"` + strings.Repeat("2", 64) + `","vout":1,"value":20000,"status":{"confirmed":true}}]`))
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	if err := wo.SetAPIEndpoints([]string{srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	balance, err := wo.GetBalance(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance != 50000 {
+		t.Fatalf("余额为%d，期望50000", balance)
+	}
+
+	utxos, err := wo.GetUTXOs(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utxos) != 2 || utxos[0].Address != addr {
+		t.Fatalf("UTXO查询结果为%v", utxos)
+	}
+
+	// 可以构建未签名交易
+	outputs := []PaymentOutput{{Address: testAddress(t, "watch"), Amount: 25000}}
+	unsignedHex, err := wo.CreateRawTransactionWithOutputs(P2WPKH, outputs, utxos)
+	if err != nil {
+		t.Fatalf("观察钱包构建未签名交易失败: %v", err)
+	}
+
+	// 所有签名方法都返回ErrWatchOnly
+	signErrs := map[string]error{}
+	_, signErrs["SignRawTransaction"] = wo.SignRawTransaction(unsignedHex, P2WPKH, utxos)
+	signErrs["SignTransaction"] = wo.SignTransaction(deserializeTx(t, unsignedHex), P2WPKH, utxos)
+	_, signErrs["SignPSBT"] = wo.SignPSBT("", P2WPKH)
+	_, signErrs["SignMessage"] = wo.SignMessage("hello")
+	_, signErrs["SendMany"] = wo.SendMany(P2WPKH, outputs)
+	_, signErrs["SendAll"] = wo.SendAll(P2WPKH, outputs[0].Address)
+	for name, err := range signErrs {
+		if !errors.Is(err, ErrWatchOnly) {
+			t.Fatalf("%s应返回ErrWatchOnly，实际为%v", name, err)
+		}
+	}
+}